@@ -0,0 +1,184 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// getHousehold is the front desk's view of a family: the customer, their
+// athletes and their upcoming and past reservations.
+func getHousehold(c *gin.Context) {
+	id := c.Param("id")
+
+	household, err := dbUtils.LoadHousehold(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading household:", err)
+		respondError(c, err)
+		return
+	}
+
+	if household == nil {
+		log.Println("[API] Could not find customer with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *household)
+}
+
+// getAthletes lists a customer's athletes, ?active=true leaves out the ones
+// no longer booked for.
+func getAthletes(c *gin.Context) {
+	id := c.Param("id")
+
+	activeOnly := false
+	if activeStr := c.Query("active"); activeStr != "" {
+		parsed, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			validationErr := &dbUtils.ValidationError{}
+			validationErr.Add("active", "must be true or false")
+			respondValidationError(c, validationErr)
+			return
+		}
+
+		activeOnly = parsed
+	}
+
+	customer, err := dbUtils.LoadCustomerById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading customer:", err)
+		respondError(c, err)
+		return
+	}
+
+	if customer == nil {
+		respondNotFound(c)
+		return
+	}
+
+	athletes, err := dbUtils.LoadCustomerAthletes(c.Request.Context(), pool, id, activeOnly)
+	if err != nil {
+		log.Println("[API] Error loading athletes:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, athletes)
+}
+
+func getAthleteById(c *gin.Context) {
+	id := c.Param("id")
+	athleteId := c.Param("athleteId")
+
+	athlete, err := dbUtils.LoadAthleteById(c.Request.Context(), pool, id, athleteId)
+	if err != nil {
+		log.Println("[API] Error loading athlete:", err)
+		respondError(c, err)
+		return
+	}
+
+	if athlete == nil {
+		log.Println("[API] Could not find athlete with id:", athleteId)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *athlete)
+}
+
+func createAthlete(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.AthleteUpdates
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/customers/"+id+"/athletes", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	customer, err := dbUtils.LoadCustomerById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading customer:", err)
+		respondError(c, err)
+		return
+	}
+
+	if customer == nil {
+		respondNotFound(c)
+		return
+	}
+
+	result, err := dbUtils.InsertAthleteData(c.Request.Context(), pool, id, input)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error inserting athlete:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/customers/"+id+"/athletes/"+result.Id.String())
+	c.JSON(http.StatusCreated, *result)
+}
+
+func updateAthleteById(c *gin.Context) {
+	id := c.Param("id")
+	athleteId := c.Param("athleteId")
+
+	var updates models.AthleteUpdates
+
+	if err := c.ShouldBindJSON(&updates); err != nil {
+		log.Println("[API] Error binding JSON on PUT method at /api/customers/"+id+"/athletes/"+athleteId, err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	result, err := dbUtils.UpdateAthleteData(c.Request.Context(), pool, id, athleteId, updates)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating athlete:", err)
+		respondError(c, err)
+		return
+	}
+
+	if result == nil {
+		log.Println("[API] Cannot update athlete because it does not exist with id:", athleteId)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *result)
+}
+
+func deleteAthleteById(c *gin.Context) {
+	id := c.Param("id")
+	athleteId := c.Param("athleteId")
+
+	rowsAffected, err := dbUtils.DeleteAthleteData(c.Request.Context(), pool, id, athleteId)
+
+	if err != nil {
+		log.Println("[API] Error deleting athlete:", err)
+		respondError(c, err)
+		return
+	}
+
+	if rowsAffected < 1 {
+		log.Println("[API] Could not find athlete to delete with id:", athleteId)
+		respondNotFound(c)
+		return
+	}
+
+	log.Println("[API] Successfully deleted athlete with id:", athleteId)
+	c.Status(http.StatusNoContent)
+}
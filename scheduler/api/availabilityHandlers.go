@@ -0,0 +1,94 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func getAvailability(c *gin.Context) {
+	validationErr := &dbUtils.ValidationError{}
+
+	query := dbUtils.AvailabilityQuery{
+		Date:        parseDateQuery(c, validationErr, "date"),
+		Duration:    parseMinutesQuery(c, validationErr, "duration"),
+		Granularity: parseMinutesQuery(c, validationErr, "granularity"),
+		Kind:        models.ReservationKind(c.DefaultQuery("kind", string(models.ReservationKindTunnel))),
+		From:        parseTimeOfDayQuery(c, validationErr, "from"),
+		To:          parseTimeOfDayQuery(c, validationErr, "to"),
+	}
+
+	if tunnelIdStr := c.Query("tunnel_id"); tunnelIdStr != "" {
+		tunnelId, err := strconv.ParseInt(tunnelIdStr, 10, 32)
+		if err != nil {
+			validationErr.Add("tunnel_id", "must be a whole number")
+		} else {
+			id := int32(tunnelId)
+			query.TunnelId = &id
+		}
+	}
+
+	if coachIdStr := c.Query("coach_id"); coachIdStr != "" {
+		var coachId pgtype.UUID
+		if err := coachId.Scan(coachIdStr); err != nil {
+			validationErr.Add("coach_id", "must be a uuid")
+		} else {
+			query.CoachId = &coachId
+		}
+	}
+
+	if respondValidationError(c, validationErr.Err()) {
+		return
+	}
+
+	availability, err := dbUtils.LoadAvailability(c.Request.Context(), pool, facilityLocation, query)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error loading availability:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, *availability)
+}
+
+// parseMinutesQuery reads an optional whole number of minutes, zero when missing.
+func parseMinutesQuery(c *gin.Context, validationErr *dbUtils.ValidationError, key string) time.Duration {
+	value := c.Query(key)
+	if value == "" {
+		return 0
+	}
+
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes <= 0 {
+		validationErr.Add(key, "must be a positive number of minutes")
+		return 0
+	}
+
+	return time.Duration(minutes) * time.Minute
+}
+
+// parseTimeOfDayQuery reads an optional HH:MM query param, nil when missing.
+func parseTimeOfDayQuery(c *gin.Context, validationErr *dbUtils.ValidationError, key string) *models.TimeOfDay {
+	value := c.Query(key)
+	if value == "" {
+		return nil
+	}
+
+	timeOfDay, err := models.ParseTimeOfDay(value)
+	if err != nil {
+		validationErr.Add(key, "must be a time formatted as HH:MM")
+		return nil
+	}
+
+	return &timeOfDay
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func getBlackoutWindows(c *gin.Context) {
+	validationErr := &dbUtils.ValidationError{}
+	fromTime := parseTimestampQuery(c, validationErr, "from")
+	toTime := parseTimestampQuery(c, validationErr, "to")
+
+	if respondValidationError(c, validationErr.Err()) {
+		return
+	}
+
+	blackoutWindows, err := dbUtils.LoadBlackoutWindowData(c.Request.Context(), pool, fromTime, toTime)
+	if err != nil {
+		log.Println("[API] Error loading blackout windows:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, blackoutWindows)
+}
+
+func getBlackoutWindowById(c *gin.Context) {
+	id := c.Param("id")
+
+	blackoutWindow, err := dbUtils.LoadBlackoutWindowById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading blackout window:", err)
+		respondError(c, err)
+		return
+	}
+
+	if blackoutWindow == nil {
+		log.Println("[API] Could not find blackout window with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *blackoutWindow)
+}
+
+func previewBlackoutWindow(c *gin.Context) {
+	var blackoutWindow models.BlackoutWindow
+
+	if err := c.ShouldBindJSON(&blackoutWindow); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/blackout-windows/preview.", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	impact, err := dbUtils.PreviewBlackoutWindow(c.Request.Context(), pool, blackoutWindow.StartsAt, blackoutWindow.EndsAt)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error previewing blackout window:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, *impact)
+}
+
+func createBlackoutWindow(c *gin.Context) {
+	var blackoutWindow models.BlackoutWindow
+
+	if err := c.ShouldBindJSON(&blackoutWindow); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/blackout-windows.", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	result, err := dbUtils.InsertBlackoutWindowData(c.Request.Context(), pool, blackoutWindow)
+	if respondValidationError(c, err) || respondBlackoutOverlap(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error inserting blackout window:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/blackout-windows/"+result.BlackoutWindow.Id.String())
+	c.JSON(http.StatusCreated, *result)
+}
+
+func updateBlackoutWindowById(c *gin.Context) {
+	id := c.Param("id")
+
+	var blackoutWindowUpdates models.BlackoutWindowUpdates
+
+	if err := c.ShouldBindJSON(&blackoutWindowUpdates); err != nil {
+		log.Println("[API] Error binding JSON on PUT method at /api/blackout-windows/"+id, err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	result, err := dbUtils.UpdateBlackoutWindowData(c.Request.Context(), pool, id, blackoutWindowUpdates)
+	if respondValidationError(c, err) || respondBlackoutOverlap(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating blackout window:", err)
+		respondError(c, err)
+		return
+	}
+
+	if result == nil {
+		log.Println("[API] Cannot update blackout window because it does not exist with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *result)
+}
+
+func deleteBlackoutWindowById(c *gin.Context) {
+	id := c.Param("id")
+
+	rowsAffected, err := dbUtils.DeleteBlackoutWindowData(c.Request.Context(), pool, id)
+
+	if err != nil {
+		log.Println("[API] Error deleting blackout window:", err)
+		respondError(c, err)
+		return
+	}
+
+	// didn't delete anything
+	if rowsAffected < 1 {
+		log.Println("[API] Could not find blackout window to delete with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	log.Println("[API] Successfully deleted blackout window with id:", id)
+	c.Status(http.StatusNoContent)
+}
+
+func respondBlackoutOverlap(c *gin.Context, err error) bool {
+	var overlapErr *dbUtils.BlackoutOverlapError
+	if !errors.As(err, &overlapErr) {
+		return false
+	}
+
+	respondProblem(c, http.StatusConflict, "blackout_overlap", overlapErr.Error(), gin.H{
+		"conflicting_windows": overlapErr.Conflicts,
+	})
+	return true
+}
+
+// parseTimestampQuery reads an optional RFC 3339 query param, the timestamp
+// is left invalid when the param is missing.
+func parseTimestampQuery(c *gin.Context, validationErr *dbUtils.ValidationError, key string) pgtype.Timestamptz {
+	value := c.Query(key)
+	if value == "" {
+		return pgtype.Timestamptz{}
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		validationErr.Add(key, "must be an RFC 3339 timestamp")
+		return pgtype.Timestamptz{}
+	}
+
+	return pgtype.Timestamptz{Time: parsed, Valid: true}
+}
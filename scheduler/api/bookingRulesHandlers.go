@@ -0,0 +1,117 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func getBookingRules(c *gin.Context) {
+	bookingRules, err := dbUtils.LoadBookingRuleData(c.Request.Context(), pool)
+	if err != nil {
+		log.Println("[API] Error loading booking rules:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bookingRules)
+}
+
+func getBookingRuleById(c *gin.Context) {
+	id := c.Param("id")
+
+	bookingRule, err := dbUtils.LoadBookingRuleById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading booking rule:", err)
+		respondError(c, err)
+		return
+	}
+
+	if bookingRule == nil {
+		log.Println("[API] Could not find booking rule with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *bookingRule)
+}
+
+func createBookingRule(c *gin.Context) {
+	var input models.BookingRuleUpdates
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/booking-rules.", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	result, err := dbUtils.InsertBookingRuleData(c.Request.Context(), pool, input)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error inserting booking rule:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/booking-rules/"+result.Id.String())
+	c.JSON(http.StatusCreated, *result)
+}
+
+func updateBookingRuleById(c *gin.Context) {
+	id := c.Param("id")
+
+	var updates models.BookingRuleUpdates
+
+	if err := c.ShouldBindJSON(&updates); err != nil {
+		log.Println("[API] Error binding JSON on PUT method at /api/booking-rules/"+id, err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	result, err := dbUtils.UpdateBookingRuleData(c.Request.Context(), pool, id, updates)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating booking rule:", err)
+		respondError(c, err)
+		return
+	}
+
+	if result == nil {
+		log.Println("[API] Cannot update booking rule because it does not exist with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *result)
+}
+
+func deleteBookingRuleById(c *gin.Context) {
+	id := c.Param("id")
+
+	rowsAffected, err := dbUtils.DeleteBookingRuleData(c.Request.Context(), pool, id)
+
+	if err != nil {
+		log.Println("[API] Error deleting booking rule:", err)
+		respondError(c, err)
+		return
+	}
+
+	// didn't delete anything
+	if rowsAffected < 1 {
+		log.Println("[API] Could not find booking rule to delete with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	log.Println("[API] Successfully deleted booking rule with id:", id)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func getBusinessHours(c *gin.Context) {
+	businessHours, err := dbUtils.LoadBusinessHoursData(c.Request.Context(), pool)
+	if err != nil {
+		log.Println("[API] Error loading business hours:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, businessHours)
+}
+
+func getBusinessHoursByDow(c *gin.Context) {
+	dow, ok := parseDowParam(c)
+	if !ok {
+		return
+	}
+
+	businessHours, err := dbUtils.LoadBusinessHoursByDow(c.Request.Context(), pool, dow)
+	if err != nil {
+		log.Println("[API] Error loading business hours:", err)
+		respondError(c, err)
+		return
+	}
+
+	if businessHours == nil {
+		log.Println("[API] Could not find business hours for dow:", dow)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *businessHours)
+}
+
+func updateBusinessHoursByDow(c *gin.Context) {
+	dow, ok := parseDowParam(c)
+	if !ok {
+		return
+	}
+
+	var businessHoursUpdates models.BusinessHoursUpdates
+
+	if err := c.ShouldBindJSON(&businessHoursUpdates); err != nil {
+		log.Println("[API] Error binding JSON on PUT method at /api/business-hours/"+c.Param("dow"), err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	businessHours, err := dbUtils.UpsertBusinessHoursData(c.Request.Context(), pool, dow, businessHoursUpdates)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating business hours:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, *businessHours)
+}
+
+// parseDowParam reads :dow from the path, answering with a 400 if it is not a valid day.
+func parseDowParam(c *gin.Context) (int32, bool) {
+	validationErr := &dbUtils.ValidationError{}
+
+	dow, err := strconv.ParseInt(c.Param("dow"), 10, 32)
+	if err != nil {
+		validationErr.Add("dow", "must be a whole number between 0 (Sunday) and 6 (Saturday)")
+	} else {
+		dbUtils.ValidateDow(validationErr, "dow", int32(dow))
+	}
+
+	if respondValidationError(c, validationErr.Err()) {
+		return 0, false
+	}
+
+	return int32(dow), true
+}
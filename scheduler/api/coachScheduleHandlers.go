@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func getCoachAvailability(c *gin.Context) {
+	coachId := c.Param("id")
+
+	availability, err := dbUtils.LoadCoachAvailabilityData(c.Request.Context(), pool, coachId)
+	if err != nil {
+		log.Println("[API] Error loading coach availability:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
+
+func createCoachAvailability(c *gin.Context) {
+	coachId := c.Param("id")
+
+	var input models.CoachAvailabilityUpdates
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/coaches/"+coachId+"/availability.", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	result, err := dbUtils.InsertCoachAvailabilityData(c.Request.Context(), pool, coachId, input)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if errors.Is(err, dbUtils.ErrCoachNotFound) {
+		log.Println("[API] Could not find coach with id:", coachId)
+		respondNotFound(c)
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error inserting coach availability:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/coaches/"+coachId+"/availability/"+result.Id.String())
+	c.JSON(http.StatusCreated, *result)
+}
+
+func updateCoachAvailabilityById(c *gin.Context) {
+	coachId := c.Param("id")
+	availabilityId := c.Param("availabilityId")
+
+	var availabilityUpdates models.CoachAvailabilityUpdates
+
+	if err := c.ShouldBindJSON(&availabilityUpdates); err != nil {
+		log.Println("[API] Error binding JSON on PUT method at /api/coaches/"+coachId+"/availability/"+availabilityId, err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	availability, err := dbUtils.UpdateCoachAvailabilityData(c.Request.Context(), pool, coachId, availabilityId, availabilityUpdates)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating coach availability:", err)
+		respondError(c, err)
+		return
+	}
+
+	if availability == nil {
+		log.Println("[API] Cannot update coach availability because it does not exist with id:", availabilityId)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *availability)
+}
+
+func deleteCoachAvailabilityById(c *gin.Context) {
+	coachId := c.Param("id")
+	availabilityId := c.Param("availabilityId")
+
+	rowsAffected, err := dbUtils.DeleteCoachAvailabilityData(c.Request.Context(), pool, coachId, availabilityId)
+
+	if err != nil {
+		log.Println("[API] Error deleting coach availability:", err)
+		respondError(c, err)
+		return
+	}
+
+	// didn't delete anything
+	if rowsAffected < 1 {
+		log.Println("[API] Could not find coach availability to delete with id:", availabilityId)
+		respondNotFound(c)
+		return
+	}
+
+	log.Println("[API] Successfully deleted coach availability with id:", availabilityId)
+	c.Status(http.StatusNoContent)
+}
+
+func getCoachTimeOff(c *gin.Context) {
+	coachId := c.Param("id")
+
+	validationErr := &dbUtils.ValidationError{}
+	fromTime := parseTimestampQuery(c, validationErr, "from")
+	toTime := parseTimestampQuery(c, validationErr, "to")
+
+	if respondValidationError(c, validationErr.Err()) {
+		return
+	}
+
+	timeOff, err := dbUtils.LoadCoachTimeOffData(c.Request.Context(), pool, coachId, fromTime, toTime)
+	if err != nil {
+		log.Println("[API] Error loading coach time off:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, timeOff)
+}
+
+func createCoachTimeOff(c *gin.Context) {
+	coachId := c.Param("id")
+
+	var timeOff models.CoachTimeOff
+
+	if err := c.ShouldBindJSON(&timeOff); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/coaches/"+coachId+"/time-off.", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	result, err := dbUtils.InsertCoachTimeOffData(c.Request.Context(), pool, coachId, timeOff)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if errors.Is(err, dbUtils.ErrCoachNotFound) {
+		log.Println("[API] Could not find coach with id:", coachId)
+		respondNotFound(c)
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error inserting coach time off:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/coaches/"+coachId+"/time-off/"+result.Id.String())
+	c.JSON(http.StatusCreated, *result)
+}
+
+func deleteCoachTimeOffById(c *gin.Context) {
+	coachId := c.Param("id")
+	timeOffId := c.Param("timeOffId")
+
+	rowsAffected, err := dbUtils.DeleteCoachTimeOffData(c.Request.Context(), pool, coachId, timeOffId)
+
+	if err != nil {
+		log.Println("[API] Error deleting coach time off:", err)
+		respondError(c, err)
+		return
+	}
+
+	// didn't delete anything
+	if rowsAffected < 1 {
+		log.Println("[API] Could not find coach time off to delete with id:", timeOffId)
+		respondNotFound(c)
+		return
+	}
+
+	log.Println("[API] Successfully deleted coach time off with id:", timeOffId)
+	c.Status(http.StatusNoContent)
+}
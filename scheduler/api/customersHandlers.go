@@ -0,0 +1,295 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// getCustomers lists the customers, ?q= searches their names, phone numbers
+// and emails.
+func getCustomers(c *gin.Context) {
+	customers, err := dbUtils.LoadCustomerData(c.Request.Context(), pool, c.Query("q"))
+	if err != nil {
+		log.Println("[API] Error loading customers:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, customers)
+}
+
+func getCustomerById(c *gin.Context) {
+	id := c.Param("id")
+
+	customer, err := dbUtils.LoadCustomerById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading customer:", err)
+		respondError(c, err)
+		return
+	}
+
+	if customer == nil {
+		log.Println("[API] Could not find customer with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *customer)
+}
+
+func createCustomer(c *gin.Context) {
+	var input models.CustomerUpdates
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/customers.", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	result, err := dbUtils.InsertCustomerData(c.Request.Context(), pool, input)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error inserting customer:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/customers/"+result.Id.String())
+	c.JSON(http.StatusCreated, *result)
+}
+
+func updateCustomerById(c *gin.Context) {
+	id := c.Param("id")
+
+	var updates models.CustomerUpdates
+
+	if err := c.ShouldBindJSON(&updates); err != nil {
+		log.Println("[API] Error binding JSON on PUT method at /api/customers/"+id, err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	result, err := dbUtils.UpdateCustomerData(c.Request.Context(), pool, id, updates)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating customer:", err)
+		respondError(c, err)
+		return
+	}
+
+	if result == nil {
+		log.Println("[API] Cannot update customer because it does not exist with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *result)
+}
+
+func deleteCustomerById(c *gin.Context) {
+	id := c.Param("id")
+
+	rowsAffected, err := dbUtils.DeleteCustomerData(c.Request.Context(), pool, id)
+
+	if err != nil {
+		log.Println("[API] Error deleting customer:", err)
+		respondError(c, err)
+		return
+	}
+
+	if rowsAffected < 1 {
+		log.Println("[API] Could not find customer to delete with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	log.Println("[API] Successfully deleted customer with id:", id)
+	c.Status(http.StatusNoContent)
+}
+
+func getCustomerReservations(c *gin.Context) {
+	id := c.Param("id")
+
+	customer, err := dbUtils.LoadCustomerById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading customer:", err)
+		respondError(c, err)
+		return
+	}
+
+	if customer == nil {
+		respondNotFound(c)
+		return
+	}
+
+	reservations, err := dbUtils.LoadCustomerReservations(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading customer reservations:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservations)
+}
+
+// getCustomerBackfillReport is the dry run of the customer backfill: which
+// unlinked reservations it would link and which it would leave as ambiguous.
+func getCustomerBackfillReport(c *gin.Context) {
+	report, err := dbUtils.LoadCustomerBackfillReport(c.Request.Context(), pool)
+	if err != nil {
+		log.Println("[API] Error loading customer backfill report:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, *report)
+}
+
+// runCustomerBackfill links the reservations booked without a customer match,
+// e.g. after the front desk sorted out the ambiguous ones.
+func runCustomerBackfill(c *gin.Context) {
+	report, err := dbUtils.RunCustomerBackfill(c.Request.Context(), pool)
+	if err != nil {
+		log.Println("[API] Error backfilling customers:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, *report)
+}
+
+// getCustomerDuplicates lists the likely duplicate customers best first,
+// ?min_score= (0-1) and ?limit= narrow it down.
+func getCustomerDuplicates(c *gin.Context) {
+	minScore, limit, ok := bindDuplicateParams(c)
+	if !ok {
+		return
+	}
+
+	duplicates, err := dbUtils.FindCustomerDuplicates(c.Request.Context(), pool, minScore, limit)
+	if err != nil {
+		log.Println("[API] Error finding duplicate customers:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, duplicates)
+}
+
+// getUnlinkedReservationMatches lists the reservations without a customer
+// that likely belong to one best first, with the same query parameters as
+// getCustomerDuplicates.
+func getUnlinkedReservationMatches(c *gin.Context) {
+	minScore, limit, ok := bindDuplicateParams(c)
+	if !ok {
+		return
+	}
+
+	matches, err := dbUtils.FindUnlinkedReservationMatches(c.Request.Context(), pool, minScore, limit)
+	if err != nil {
+		log.Println("[API] Error finding customers of unlinked reservations:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, matches)
+}
+
+// bindDuplicateParams reads ?min_score= and ?limit=, false once it responded
+// with a validation error.
+func bindDuplicateParams(c *gin.Context) (float64, int32, bool) {
+	validationErr := &dbUtils.ValidationError{}
+
+	minScore := dbUtils.DefaultDuplicateMinScore
+	if minScoreStr := c.Query("min_score"); minScoreStr != "" {
+		parsed, err := strconv.ParseFloat(minScoreStr, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			validationErr.Add("min_score", "must be a number between 0 and 1")
+		}
+
+		minScore = parsed
+	}
+
+	var limit int32 = 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.ParseInt(limitStr, 10, 32)
+		if err != nil || parsed <= 0 {
+			validationErr.Add("limit", "must be a positive integer")
+		}
+
+		limit = int32(parsed)
+	}
+
+	if respondValidationError(c, validationErr.Err()) {
+		return 0, 0, false
+	}
+
+	return minScore, limit, true
+}
+
+// mergeCustomer merges the body's duplicate_id into the customer of the URL.
+func mergeCustomer(c *gin.Context) {
+	id := c.Param("id")
+
+	var request models.CustomerMergeRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/customers/"+id+"/merge", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	result, err := dbUtils.MergeCustomers(c.Request.Context(), pool, id, request)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error merging customers:", err)
+		respondError(c, err)
+		return
+	}
+
+	if result == nil {
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *result)
+}
+
+func getCustomerMerges(c *gin.Context) {
+	id := c.Param("id")
+
+	customer, err := dbUtils.LoadCustomerById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading customer:", err)
+		respondError(c, err)
+		return
+	}
+
+	if customer == nil {
+		respondNotFound(c)
+		return
+	}
+
+	merges, err := dbUtils.LoadCustomerMerges(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading customer merges:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, merges)
+}
@@ -0,0 +1,331 @@
+package db_utils
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// LoadCustomerAthletes lists a customer's athletes, only the ones still
+// booked for when activeOnly is set.
+func LoadCustomerAthletes(ctx context.Context, conn IDBConn, customerId string, activeOnly bool) ([]models.Athlete, error) {
+	athletes := make([]models.Athlete, 0)
+
+	args := pgx.NamedArgs{
+		"customer_id": customerId,
+		"active_only": activeOnly,
+	}
+
+	query := `
+		SELECT * FROM athletes
+		WHERE customer_id = @customer_id AND (NOT @active_only OR is_active)
+		ORDER BY birth_year ASC, first_name ASC
+	`
+
+	err := pgxscan.Select(ctx, conn, &athletes, query, args)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return athletes, nil
+}
+
+// LoadAthleteById finds one of the customer's athletes, nil for an athlete
+// of another customer.
+func LoadAthleteById(ctx context.Context, conn IDBConn, customerId, id string) (*models.Athlete, error) {
+	var athlete models.Athlete
+
+	query := `SELECT * FROM athletes WHERE id=$1 AND customer_id=$2`
+
+	err := pgxscan.Get(ctx, conn, &athlete, query, id, customerId)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] No athlete found with id:", id)
+		return nil, nil
+	} else if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &athlete, nil
+}
+
+func InsertAthleteData(ctx context.Context, conn IDBConn, customerId string, input models.AthleteUpdates) (*models.Athlete, error) {
+	athlete := models.Athlete{Sport: models.SportBaseball, IsActive: true}
+
+	validationErr := &ValidationError{}
+	if input.FirstName == nil {
+		validationErr.Add("first_name", "is required")
+	}
+
+	if input.LastName == nil {
+		validationErr.Add("last_name", "is required")
+	}
+
+	if input.BirthYear == nil {
+		validationErr.Add("birth_year", "is required")
+	}
+
+	applyAthleteUpdates(validationErr, &athlete, input)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	args := pgx.NamedArgs{
+		"customer_id": customerId,
+		"first_name":  athlete.FirstName,
+		"last_name":   athlete.LastName,
+		"birth_year":  athlete.BirthYear,
+		"sport":       athlete.Sport,
+		"bats":        athlete.Bats,
+		"throws":      athlete.Throws,
+		"position":    athlete.Position,
+		"notes":       athlete.Notes,
+		"is_active":   athlete.IsActive,
+	}
+
+	const query = `
+		INSERT INTO athletes (
+			customer_id,
+			first_name,
+			last_name,
+			birth_year,
+			sport,
+			bats,
+			throws,
+			position,
+			notes,
+			is_active
+		)
+
+		VALUES (
+			@customer_id,
+			@first_name,
+			@last_name,
+			@birth_year,
+			@sport::sport,
+			@bats::handedness,
+			@throws::handedness,
+			@position::field_position,
+			@notes,
+			@is_active
+		)
+
+		RETURNING *;
+	`
+
+	var out models.Athlete
+	if err := pgxscan.Get(ctx, conn, &out, query, args); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func UpdateAthleteData(ctx context.Context, conn IDBConn, customerId, id string, updates models.AthleteUpdates) (*models.Athlete, error) {
+	existing, err := LoadAthleteById(ctx, conn, customerId, id)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	validationErr := &ValidationError{}
+	applyAthleteUpdates(validationErr, existing, updates)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	var updatedAthlete models.Athlete
+
+	args := pgx.NamedArgs{
+		"id":          id,
+		"customer_id": customerId,
+		"first_name":  existing.FirstName,
+		"last_name":   existing.LastName,
+		"birth_year":  existing.BirthYear,
+		"sport":       existing.Sport,
+		"bats":        existing.Bats,
+		"throws":      existing.Throws,
+		"position":    existing.Position,
+		"notes":       existing.Notes,
+		"is_active":   existing.IsActive,
+	}
+
+	query := `
+			UPDATE athletes
+			SET
+				first_name = @first_name,
+				last_name = @last_name,
+				birth_year = @birth_year,
+				sport = @sport::sport,
+				bats = @bats::handedness,
+				throws = @throws::handedness,
+				position = @position::field_position,
+				notes = @notes,
+				is_active = @is_active,
+				updated_at = now()
+			WHERE id = @id AND customer_id = @customer_id
+			RETURNING *
+	`
+
+	err = pgxscan.Get(ctx, conn, &updatedAthlete, query, args)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find athlete with id:", id)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating athlete:", err)
+		return nil, err
+	}
+
+	return &updatedAthlete, nil
+}
+
+// DeleteAthleteData removes an athlete no reservation names yet, athletes
+// with reservations are kept and deactivated instead.
+func DeleteAthleteData(ctx context.Context, conn IDBConn, customerId, id string) (int64, error) {
+	cmdTag, err := conn.Exec(
+		ctx,
+		"DELETE FROM athletes WHERE id=$1 AND customer_id=$2",
+		id,
+		customerId,
+	)
+
+	if err != nil {
+		log.Println("[API] Error deleting athlete:", err)
+		return 0, err
+	}
+
+	return cmdTag.RowsAffected(), err
+}
+
+// LoadHousehold gathers a customer, all their athletes and their
+// reservations, upcoming ones are those starting from now on.
+func LoadHousehold(ctx context.Context, conn IDBConn, customerId string) (*models.Household, error) {
+	customer, err := LoadCustomerById(ctx, conn, customerId)
+	if err != nil || customer == nil {
+		return nil, err
+	}
+
+	athletes, err := LoadCustomerAthletes(ctx, conn, customerId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	household := &models.Household{
+		Customer: *customer,
+		Athletes: athletes,
+		Upcoming: make([]models.Reservation, 0),
+		Past:     make([]models.Reservation, 0),
+	}
+
+	upcomingQuery := `
+		SELECT * FROM reservations
+		WHERE customer_id = $1 AND start_time >= now()
+		ORDER BY start_time ASC
+	`
+
+	err = pgxscan.Select(ctx, conn, &household.Upcoming, upcomingQuery, customerId)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	pastQuery := `
+		SELECT * FROM reservations
+		WHERE customer_id = $1 AND start_time < now()
+		ORDER BY start_time DESC
+	`
+
+	err = pgxscan.Select(ctx, conn, &household.Past, pastQuery, customerId)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return household, nil
+}
+
+func applyAthleteUpdates(validationErr *ValidationError, athlete *models.Athlete, updates models.AthleteUpdates) {
+	if updates.FirstName != nil {
+		if strings.TrimSpace(*updates.FirstName) == "" {
+			validationErr.Add("first_name", "cannot be empty")
+		} else {
+			athlete.FirstName = strings.TrimSpace(*updates.FirstName)
+		}
+	}
+
+	if updates.LastName != nil {
+		if strings.TrimSpace(*updates.LastName) == "" {
+			validationErr.Add("last_name", "cannot be empty")
+		} else {
+			athlete.LastName = strings.TrimSpace(*updates.LastName)
+		}
+	}
+
+	if updates.BirthYear != nil {
+		if *updates.BirthYear < 1900 || int(*updates.BirthYear) > time.Now().Year() {
+			validationErr.Add("birth_year", "must be a past year")
+		} else {
+			athlete.BirthYear = *updates.BirthYear
+		}
+	}
+
+	if updates.Sport != nil {
+		if *updates.Sport != models.SportBaseball && *updates.Sport != models.SportSoftball {
+			validationErr.Add("sport", "must be baseball or softball")
+		} else {
+			athlete.Sport = *updates.Sport
+		}
+	}
+
+	// an empty bats/throws/position clears it
+	if updates.Bats != nil {
+		switch *updates.Bats {
+		case "":
+			athlete.Bats = nil
+		case models.HandRight, models.HandLeft, models.HandSwitch:
+			athlete.Bats = updates.Bats
+		default:
+			validationErr.Add("bats", "must be right, left or switch")
+		}
+	}
+
+	if updates.Throws != nil {
+		switch *updates.Throws {
+		case "":
+			athlete.Throws = nil
+		case models.HandRight, models.HandLeft:
+			athlete.Throws = updates.Throws
+		default:
+			validationErr.Add("throws", "must be right or left")
+		}
+	}
+
+	if updates.Position != nil {
+		switch {
+		case *updates.Position == "":
+			athlete.Position = nil
+		case slices.Contains(models.FieldPositions, *updates.Position):
+			athlete.Position = updates.Position
+		default:
+			validationErr.Add("position", "must be one of "+strings.Join(models.FieldPositions, ", "))
+		}
+	}
+
+	if updates.Notes != nil {
+		athlete.Notes = updates.Notes
+	}
+
+	if updates.IsActive != nil {
+		athlete.IsActive = *updates.IsActive
+	}
+}
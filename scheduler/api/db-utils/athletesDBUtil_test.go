@@ -0,0 +1,163 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func athleteRows() *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"id", "customer_id", "first_name", "last_name", "birth_year", "sport", "is_active"})
+}
+
+func Test_InsertAthleteData(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	customerId := uuid.NewString()
+	athleteId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	firstName, lastName := "Sam", "Doe"
+	var birthYear int32 = 2013
+	bats, position := models.HandSwitch, "shortstop"
+
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO athletes")).
+		WithArgs(customerId, firstName, lastName, birthYear, models.SportBaseball, &bats, (*string)(nil), &position, (*string)(nil), true).
+		WillReturnRows(athleteRows().AddRow(athleteId, customerId, firstName, lastName, birthYear, models.SportBaseball, true))
+
+	// exercise
+	athlete, err := InsertAthleteData(context.Background(), mockConn, customerId, models.AthleteUpdates{
+		FirstName: &firstName,
+		LastName:  &lastName,
+		BirthYear: &birthYear,
+		Bats:      &bats,
+		Position:  &position,
+	})
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if athlete.Id != athleteId || athlete.Sport != models.SportBaseball {
+		t.Fatal("expected the new baseball athlete, got", athlete)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_InsertAthleteData_Invalid(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	firstName := "Sam"
+	var birthYear int32 = 3013
+	sport, throws, position := "cricket", models.HandSwitch, "rover"
+
+	// exercise
+	athlete, err := InsertAthleteData(context.Background(), mockConn, uuid.NewString(), models.AthleteUpdates{
+		FirstName: &firstName,
+		BirthYear: &birthYear,
+		Sport:     &sport,
+		Throws:    &throws,
+		Position:  &position,
+	})
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	for _, field := range []string{"last_name", "birth_year", "sport", "throws", "position"} {
+		if validationErr.Fields[field] == "" {
+			t.Error("expected an error for", field, "got", validationErr.Fields)
+		}
+	}
+
+	if athlete != nil {
+		t.Fatal("expected no athlete, got", athlete)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_LoadHousehold(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	customerId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	athleteId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	upcomingId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	pastId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM customers WHERE id=$1`)).WithArgs(customerId.String()).WillReturnRows(
+		customerRows().AddRow(customerId, "Jane", "Doe", "812-555-0100", nil, "8125550100"),
+	)
+	mockConn.ExpectQuery(regexp.QuoteMeta("SELECT * FROM athletes")).WithArgs(customerId.String(), false).WillReturnRows(
+		athleteRows().AddRow(athleteId, customerId, "Sam", "Doe", int32(2013), models.SportBaseball, true),
+	)
+	mockConn.ExpectQuery(regexp.QuoteMeta("start_time >= now()")).WithArgs(customerId.String()).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "customer_id", "athlete_id"}).AddRow(upcomingId, &customerId, &athleteId),
+	)
+	mockConn.ExpectQuery(regexp.QuoteMeta("start_time < now()")).WithArgs(customerId.String()).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "customer_id", "athlete_id"}).AddRow(pastId, &customerId, nil),
+	)
+
+	// exercise
+	household, err := LoadHousehold(context.Background(), mockConn, customerId.String())
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if household.Customer.Id != customerId || len(household.Athletes) != 1 {
+		t.Fatal("expected the customer and their athlete, got", household)
+	}
+
+	if len(household.Upcoming) != 1 || household.Upcoming[0].Id != upcomingId ||
+		len(household.Past) != 1 || household.Past[0].Id != pastId {
+		t.Fatal("expected one upcoming and one past reservation, got", household.Upcoming, household.Past)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_LoadHousehold_NoCustomer(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	customerId := uuid.NewString()
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM customers WHERE id=$1`)).WithArgs(customerId).WillReturnRows(
+		customerRows(),
+	)
+
+	// exercise
+	household, err := LoadHousehold(context.Background(), mockConn, customerId)
+
+	// verify
+	if err != nil || household != nil {
+		t.Fatal("expected no household, got", household, err)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
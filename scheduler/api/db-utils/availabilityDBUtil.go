@@ -0,0 +1,304 @@
+package db_utils
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const DefaultSlotGranularity = 30 * time.Minute
+
+type AvailabilityQuery struct {
+	Date        pgtype.Date
+	Duration    time.Duration
+	Granularity time.Duration
+	Kind        models.ReservationKind
+	TunnelId    *int32
+	CoachId     *pgtype.UUID
+
+	// a reservation being moved, its own slot doesn't count as busy
+	ExcludeId *pgtype.UUID
+
+	// optional local wall-clock bounds inside the day's hours
+	From *models.TimeOfDay
+	To   *models.TimeOfDay
+}
+
+// Interval is a half-open [Start, End) span of time.
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (i Interval) Overlaps(other Interval) bool {
+	return i.Start.Before(other.End) && other.Start.Before(i.End)
+}
+
+// LoadAvailability works out the bookable start times per active tunnel for
+// one local date in loc, taking the effective hours, blackout windows and
+// held/confirmed reservations (for the tunnel and, for lessons, the coach)
+// into account, turnover buffers included. Lessons are also limited to the
+// coach's working windows.
+func LoadAvailability(ctx context.Context, conn IDBConn, loc *time.Location, query AvailabilityQuery) (*models.Availability, error) {
+	if err := validateAvailabilityQuery(query); err != nil {
+		return nil, err
+	}
+
+	if query.Granularity <= 0 {
+		query.Granularity = DefaultSlotGranularity
+	}
+
+	availability := &models.Availability{
+		Date:               query.Date,
+		Timezone:           loc.String(),
+		Kind:               query.Kind,
+		DurationMinutes:    int32(query.Duration / time.Minute),
+		GranularityMinutes: int32(query.Granularity / time.Minute),
+		Tunnels:            make([]models.TunnelAvailability, 0),
+	}
+
+	hours, err := ResolveEffectiveHours(ctx, conn, query.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	availability.IsOpen = hours.IsOpen
+	availability.OpenTime = hours.OpenTime
+	availability.CloseTime = hours.CloseTime
+
+	if !hours.IsOpen {
+		return availability, nil
+	}
+
+	window := Interval{
+		Start: hours.OpenTime.On(query.Date, loc),
+		End:   hours.CloseTime.On(query.Date, loc),
+	}
+
+	if query.From != nil && query.From.On(query.Date, loc).After(window.Start) {
+		window.Start = query.From.On(query.Date, loc)
+	}
+
+	if query.To != nil && query.To.On(query.Date, loc).Before(window.End) {
+		window.End = query.To.On(query.Date, loc)
+	}
+
+	if !window.Start.Before(window.End) {
+		return availability, nil
+	}
+
+	tunnels, err := LoadTunnelData(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	windowStart := pgtype.Timestamptz{Time: window.Start, Valid: true}
+	windowEnd := pgtype.Timestamptz{Time: window.End, Valid: true}
+
+	_, coachBuffer, err := LoadTurnoverBuffers(ctx, conn, nil, query.CoachId)
+	if err != nil {
+		return nil, err
+	}
+
+	// a slot at the end of the window still needs its turnover clear of the next booking
+	lookahead := coachBuffer
+	for _, tunnel := range tunnels {
+		if tunnel.TurnoverBuffer() > lookahead {
+			lookahead = tunnel.TurnoverBuffer()
+		}
+	}
+
+	reservations, err := LoadActiveReservationsWithTurnover(ctx, conn, windowStart, pgtype.Timestamptz{Time: window.End.Add(lookahead), Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	if query.ExcludeId != nil {
+		reservations = excludeReservation(reservations, *query.ExcludeId)
+	}
+
+	blackouts, err := LoadOverlappingBlackoutWindows(ctx, conn, windowStart, windowEnd, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// intervals that block every tunnel: blackouts and the coach's other bookings
+	shared := make([]Interval, 0, len(blackouts))
+	for _, blackout := range blackouts {
+		shared = append(shared, Interval{Start: blackout.StartsAt.Time, End: blackout.EndsAt.Time})
+	}
+
+	if query.CoachId != nil {
+		for _, reservation := range reservations {
+			if reservation.CoachId != nil && *reservation.CoachId == *query.CoachId {
+				shared = append(shared, coachBusyInterval(reservation, coachBuffer))
+			}
+		}
+
+		coachBusy, err := LoadCoachBusyIntervals(ctx, conn, loc, *query.CoachId, query.Date, window)
+		if err != nil {
+			return nil, err
+		}
+
+		shared = append(shared, coachBusy...)
+	}
+
+	for _, tunnel := range tunnels {
+		if !tunnel.IsActive || (query.TunnelId != nil && tunnel.Id != *query.TunnelId) {
+			continue
+		}
+
+		busy := append(make([]Interval, 0, len(shared)), shared...)
+		for _, reservation := range reservations {
+			if reservation.TunnelId != nil && *reservation.TunnelId == tunnel.Id {
+				busy = append(busy, tunnelBusyInterval(reservation, tunnel.TurnoverBuffer()))
+			}
+		}
+
+		slots := make([]models.TimeSlot, 0)
+		for _, slot := range FindOpenSlots(window, query.Duration, query.Granularity, busy) {
+			slots = append(slots, models.TimeSlot{StartTime: slot.Start.In(loc), EndTime: slot.End.In(loc)})
+		}
+
+		availability.Tunnels = append(availability.Tunnels, models.TunnelAvailability{
+			TunnelId:   tunnel.Id,
+			TunnelName: tunnel.Name,
+			Slots:      slots,
+		})
+	}
+
+	return availability, nil
+}
+
+// FindOpenSlots steps through the window every granularity starting at its
+// start and keeps each slot of the given duration that fits in the window
+// without touching a busy interval. Steps are in elapsed time, so a window
+// that crosses a DST change still yields evenly spaced real-world slots.
+func FindOpenSlots(window Interval, duration, granularity time.Duration, busy []Interval) []Interval {
+	slots := make([]Interval, 0)
+
+	if duration <= 0 || granularity <= 0 {
+		return slots
+	}
+
+	for start := window.Start; !start.Add(duration).After(window.End); start = start.Add(granularity) {
+		slot := Interval{Start: start, End: start.Add(duration)}
+
+		free := true
+		for _, interval := range busy {
+			if slot.Overlaps(interval) {
+				free = false
+				break
+			}
+		}
+
+		if free {
+			slots = append(slots, slot)
+		}
+	}
+
+	return slots
+}
+
+func reservationInterval(reservation models.Reservation) Interval {
+	return Interval{Start: reservation.StartTime.Time, End: reservation.EndTime.Time}
+}
+
+// LoadTurnoverBuffers looks up the turnover buffers of a tunnel and coach, a
+// nil or unknown id has no buffer.
+func LoadTurnoverBuffers(ctx context.Context, conn IDBConn, tunnelId *int32, coachId *pgtype.UUID) (time.Duration, time.Duration, error) {
+	var tunnelBuffer, coachBuffer time.Duration
+
+	if tunnelId != nil {
+		tunnel, err := LoadTunnelById(ctx, conn, *tunnelId)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if tunnel != nil {
+			tunnelBuffer = tunnel.TurnoverBuffer()
+		}
+	}
+
+	if coachId != nil {
+		coach, err := LoadCoachById(ctx, conn, *coachId)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if coach != nil {
+			coachBuffer = coach.TurnoverBuffer()
+		}
+	}
+
+	return tunnelBuffer, coachBuffer, nil
+}
+
+// tunnelBusyInterval is the span a reservation keeps its tunnel from a new
+// booking there: from the buffer before it starts (the new booking's own
+// turnover) until its turnover afterwards is done.
+func tunnelBusyInterval(reservation models.Reservation, buffer time.Duration) Interval {
+	return turnoverInterval(reservation, reservation.TunnelTurnoverEnd, buffer)
+}
+
+// coachBusyInterval is the same for a new lesson with the reservation's coach.
+func coachBusyInterval(reservation models.Reservation, buffer time.Duration) Interval {
+	return turnoverInterval(reservation, reservation.CoachTurnoverEnd, buffer)
+}
+
+func turnoverInterval(reservation models.Reservation, turnoverEnd pgtype.Timestamptz, buffer time.Duration) Interval {
+	busy := Interval{Start: reservation.StartTime.Time.Add(-buffer), End: reservation.EndTime.Time}
+	if turnoverEnd.Valid && turnoverEnd.Time.After(busy.End) {
+		busy.End = turnoverEnd.Time
+	}
+
+	return busy
+}
+
+func excludeReservation(reservations []models.Reservation, id pgtype.UUID) []models.Reservation {
+	kept := make([]models.Reservation, 0, len(reservations))
+	for _, reservation := range reservations {
+		if reservation.Id != id {
+			kept = append(kept, reservation)
+		}
+	}
+
+	return kept
+}
+
+func validateAvailabilityQuery(query AvailabilityQuery) error {
+	validationErr := &ValidationError{}
+
+	if !query.Date.Valid {
+		validationErr.Add("date", "is required")
+	}
+
+	if query.Duration <= 0 {
+		validationErr.Add("duration", "must be a positive number of minutes")
+	}
+
+	if query.Granularity < 0 {
+		validationErr.Add("granularity", "must be a positive number of minutes")
+	}
+
+	switch query.Kind {
+	case models.ReservationKindTunnel:
+		if query.CoachId != nil {
+			validationErr.Add("coach_id", "only applies to lesson availability")
+		}
+	case models.ReservationKindLesson:
+		if query.CoachId == nil {
+			validationErr.Add("coach_id", "is required for lesson availability")
+		}
+	default:
+		validationErr.Add("kind", "must be 'tunnel' or 'lesson'")
+	}
+
+	if query.From != nil && query.To != nil && query.From.Microseconds >= query.To.Microseconds {
+		validationErr.Add("to", "must be after from")
+	}
+
+	return validationErr.Err()
+}
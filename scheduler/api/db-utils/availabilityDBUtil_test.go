@@ -0,0 +1,185 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const turnoverReservationsQuery = `
+		SELECT * FROM reservations
+		WHERE status IN ('held', 'confirmed')
+			AND start_time < @to_time
+			AND GREATEST(end_time, tunnel_turnover_end, coach_turnover_end) > @from_time
+		ORDER BY start_time ASC
+	`
+
+func Test_FindOpenSlots(t *testing.T) {
+	// setup
+	start := time.Date(2025, 11, 29, 15, 0, 0, 0, time.UTC)
+	window := Interval{Start: start, End: start.Add(4 * time.Hour)}
+	busy := []Interval{{Start: start.Add(time.Hour), End: start.Add(90 * time.Minute)}}
+
+	// exercise
+	result := FindOpenSlots(window, time.Hour, 30*time.Minute, busy)
+
+	// verify
+	// 15:00 and 16:30, 17:00 fit; 15:30 and 16:00 run into the busy block
+	expected := []time.Time{start, start.Add(90 * time.Minute), start.Add(2 * time.Hour), start.Add(150 * time.Minute), start.Add(3 * time.Hour)}
+
+	if len(result) != len(expected) {
+		t.Fatal("expected", len(expected), "slots, got", result)
+	}
+
+	for i, slot := range result {
+		if !slot.Start.Equal(expected[i]) {
+			t.Fatal("expected slot starting at", expected[i], "got", slot.Start)
+		}
+	}
+}
+
+func Test_FindOpenSlots_DaylightSavingEnds(t *testing.T) {
+	// setup
+	loc := indianapolis(t)
+
+	// 2025-11-02 repeats 1 AM, so midnight to 4 AM local is 5 real hours
+	window := Interval{
+		Start: time.Date(2025, 11, 2, 0, 0, 0, 0, loc),
+		End:   time.Date(2025, 11, 2, 4, 0, 0, 0, loc),
+	}
+
+	// exercise
+	result := FindOpenSlots(window, time.Hour, time.Hour, nil)
+
+	// verify
+	if len(result) != 5 {
+		t.Fatal("expected 5 hourly slots, got", len(result))
+	}
+
+	if !result[4].End.Equal(window.End) {
+		t.Fatal("expected the last slot to end at", window.End, "got", result[4].End)
+	}
+}
+
+func Test_LoadAvailability(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	loc := indianapolis(t)
+	onDate, _ := models.ParseDate("2025-11-29")
+	from := models.NewTimeOfDay(10, 0)
+	to := models.NewTimeOfDay(14, 0)
+
+	windowStart := pgtype.Timestamptz{Time: time.Date(2025, 11, 29, 10, 0, 0, 0, loc), Valid: true}
+	windowEnd := pgtype.Timestamptz{Time: time.Date(2025, 11, 29, 14, 0, 0, 0, loc), Valid: true}
+
+	var tunnelId int32 = 1
+	reservationId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	expectSaturdayHours(mockConn, onDate)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM tunnels`)).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "name", "is_active", "turnover_buffer_minutes"}).
+			AddRow(int32(1), "Tunnel 1", true, int32(30)).
+			AddRow(int32(2), "Tunnel 2", true, int32(5)).
+			AddRow(int32(3), "Tunnel 3", false, int32(5)),
+	)
+
+	// the 10:00 booking on tunnel 1 keeps it busy until 11:30 with turnover
+	mockConn.ExpectQuery(regexp.QuoteMeta(turnoverReservationsQuery)).WithArgs(pgx.NamedArgs{
+		"from_time": windowStart,
+		"to_time":   pgtype.Timestamptz{Time: windowEnd.Time.Add(30 * time.Minute), Valid: true},
+	}).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "tunnel_id", "start_time", "end_time", "tunnel_turnover_end"}).
+			AddRow(
+				reservationId,
+				&tunnelId,
+				windowStart,
+				pgtype.Timestamptz{Time: windowStart.Time.Add(time.Hour), Valid: true},
+				pgtype.Timestamptz{Time: windowStart.Time.Add(90 * time.Minute), Valid: true},
+			),
+	)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(overlappingBlackoutWindowsQuery)).WithArgs(pgx.NamedArgs{
+		"from_time":  windowStart,
+		"to_time":    windowEnd,
+		"exclude_id": "",
+	}).WillReturnRows(pgxmock.NewRows([]string{"id"}))
+
+	// exercise
+	result, err := LoadAvailability(context.Background(), mockConn, loc, AvailabilityQuery{
+		Date:        onDate,
+		Duration:    time.Hour,
+		Granularity: time.Hour,
+		Kind:        models.ReservationKindTunnel,
+		From:        &from,
+		To:          &to,
+	})
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(result.Tunnels) != 2 {
+		t.Fatal("expected 2 active tunnels, got", len(result.Tunnels))
+	}
+
+	if len(result.Tunnels[0].Slots) != 2 {
+		t.Fatal("expected 2 slots for tunnel 1, got", result.Tunnels[0].Slots)
+	}
+
+	if len(result.Tunnels[1].Slots) != 4 {
+		t.Fatal("expected 4 slots for tunnel 2, got", result.Tunnels[1].Slots)
+	}
+
+	if result.Tunnels[0].Slots[0].StartTime.Hour() != 12 {
+		t.Fatal("expected tunnel 1 to open up at 12:00 after turnover, got", result.Tunnels[0].Slots[0].StartTime)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_LoadAvailability_LessonWithoutCoach(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	onDate, _ := models.ParseDate("2025-11-29")
+
+	// exercise
+	result, err := LoadAvailability(context.Background(), mockConn, time.UTC, AvailabilityQuery{
+		Date:     onDate,
+		Duration: time.Hour,
+		Kind:     models.ReservationKindLesson,
+	})
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	if _, ok := validationErr.Fields["coach_id"]; !ok {
+		t.Fatal("expected coach_id field error, got", validationErr.Fields)
+	}
+
+	if result != nil {
+		t.Fatal("expected no result, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
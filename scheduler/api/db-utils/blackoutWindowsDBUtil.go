@@ -0,0 +1,282 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// BlackoutOverlapError is returned when a blackout would overlap an existing
+// one (the exclusion constraint on blackout_windows, SQLSTATE 23P01).
+type BlackoutOverlapError struct {
+	Conflicts []models.BlackoutWindow
+}
+
+func (e *BlackoutOverlapError) Error() string {
+	reasons := make([]string, 0, len(e.Conflicts))
+	for _, conflict := range e.Conflicts {
+		reasons = append(reasons, fmt.Sprintf("%q", conflict.Reason))
+	}
+
+	return "blackout window overlaps existing blackout windows: " + strings.Join(reasons, ", ")
+}
+
+// LoadBlackoutWindowData lists blackouts overlapping [from, to). Either bound
+// may be left invalid to leave that side of the range open.
+func LoadBlackoutWindowData(ctx context.Context, conn IDBConn, from, to pgtype.Timestamptz) ([]models.BlackoutWindow, error) {
+	blackoutWindows := make([]models.BlackoutWindow, 0)
+
+	args := pgx.NamedArgs{
+		"from_time": from,
+		"to_time":   to,
+	}
+
+	query := `
+		SELECT * FROM blackout_windows
+		WHERE (@from_time::timestamptz IS NULL OR ends_at > @from_time::timestamptz)
+			AND (@to_time::timestamptz IS NULL OR starts_at < @to_time::timestamptz)
+		ORDER BY starts_at ASC
+	`
+
+	err := pgxscan.Select(ctx, conn, &blackoutWindows, query, args)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return blackoutWindows, nil
+}
+
+func LoadBlackoutWindowById(ctx context.Context, conn IDBConn, id string) (*models.BlackoutWindow, error) {
+	var blackoutWindow models.BlackoutWindow
+
+	query := `SELECT * FROM blackout_windows WHERE id=$1`
+
+	err := pgxscan.Get(ctx, conn, &blackoutWindow, query, id)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] No blackout window found with id:", id)
+		return nil, nil
+	} else if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &blackoutWindow, nil
+}
+
+// LoadOverlappingBlackoutWindows finds the blackouts that overlap [from, to),
+// ignoring excludeId (pass "" to check against all of them).
+func LoadOverlappingBlackoutWindows(ctx context.Context, conn IDBConn, from, to pgtype.Timestamptz, excludeId string) ([]models.BlackoutWindow, error) {
+	blackoutWindows := make([]models.BlackoutWindow, 0)
+
+	args := pgx.NamedArgs{
+		"from_time":  from,
+		"to_time":    to,
+		"exclude_id": excludeId,
+	}
+
+	query := `
+		SELECT * FROM blackout_windows
+		WHERE tstzrange(starts_at, ends_at, '[)') && tstzrange(@from_time, @to_time, '[)')
+			AND (@exclude_id = '' OR id <> @exclude_id::uuid)
+		ORDER BY starts_at ASC
+	`
+
+	err := pgxscan.Select(ctx, conn, &blackoutWindows, query, args)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return blackoutWindows, nil
+}
+
+// PreviewBlackoutWindow reports what creating a blackout over [from, to) would
+// affect without writing anything.
+func PreviewBlackoutWindow(ctx context.Context, conn IDBConn, from, to pgtype.Timestamptz) (*models.BlackoutImpact, error) {
+	validationErr := &ValidationError{}
+	validateBlackoutRange(validationErr, from, to)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	reservations, err := LoadActiveReservationsOverlapping(ctx, conn, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts, err := LoadOverlappingBlackoutWindows(ctx, conn, from, to, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BlackoutImpact{
+		StartsAt:             from,
+		EndsAt:               to,
+		ImpactedReservations: reservations,
+		ConflictingWindows:   conflicts,
+	}, nil
+}
+
+func InsertBlackoutWindowData(ctx context.Context, conn IDBConn, b models.BlackoutWindow) (*models.BlackoutWindowResult, error) {
+	validationErr := &ValidationError{}
+	validateBlackoutRange(validationErr, b.StartsAt, b.EndsAt)
+	if strings.TrimSpace(b.Reason) == "" {
+		validationErr.Add("reason", "is required")
+	}
+
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	args := pgx.NamedArgs{
+		"starts_at": b.StartsAt,
+		"ends_at":   b.EndsAt,
+		"reason":    b.Reason,
+	}
+
+	const query = `
+		INSERT INTO blackout_windows (
+			starts_at,
+			ends_at,
+			reason
+		)
+
+		VALUES (
+			@starts_at,
+			@ends_at,
+			@reason
+		)
+
+		RETURNING *;
+	`
+
+	var out models.BlackoutWindow
+	if err := pgxscan.Get(ctx, conn, &out, query, args); err != nil {
+		return nil, blackoutOverlap(ctx, conn, err, b.StartsAt, b.EndsAt, "")
+	}
+
+	reservations, err := LoadActiveReservationsOverlapping(ctx, conn, out.StartsAt, out.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BlackoutWindowResult{BlackoutWindow: out, ImpactedReservations: reservations}, nil
+}
+
+func UpdateBlackoutWindowData(ctx context.Context, conn IDBConn, id string, updates models.BlackoutWindowUpdates) (*models.BlackoutWindowResult, error) {
+	existing, err := LoadBlackoutWindowById(ctx, conn, id)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	if updates.StartsAt != nil {
+		existing.StartsAt = *updates.StartsAt
+	}
+
+	if updates.EndsAt != nil {
+		existing.EndsAt = *updates.EndsAt
+	}
+
+	validationErr := &ValidationError{}
+	validateBlackoutRange(validationErr, existing.StartsAt, existing.EndsAt)
+	if updates.Reason != nil && strings.TrimSpace(*updates.Reason) == "" {
+		validationErr.Add("reason", "cannot be empty")
+	}
+
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	var updatedBlackoutWindow models.BlackoutWindow
+
+	args := pgx.NamedArgs{
+		"id":        id,
+		"starts_at": existing.StartsAt,
+		"ends_at":   existing.EndsAt,
+		"reason":    updates.Reason,
+	}
+
+	query := `
+			UPDATE blackout_windows
+			SET
+				starts_at = @starts_at,
+				ends_at = @ends_at,
+				reason = COALESCE(@reason, reason)
+			WHERE id = @id
+			RETURNING *
+	`
+
+	err = pgxscan.Get(ctx, conn, &updatedBlackoutWindow, query, args)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find blackout window with id:", id)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating blackout window:", err)
+		return nil, blackoutOverlap(ctx, conn, err, existing.StartsAt, existing.EndsAt, id)
+	}
+
+	reservations, err := LoadActiveReservationsOverlapping(ctx, conn, updatedBlackoutWindow.StartsAt, updatedBlackoutWindow.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BlackoutWindowResult{BlackoutWindow: updatedBlackoutWindow, ImpactedReservations: reservations}, nil
+}
+
+func DeleteBlackoutWindowData(ctx context.Context, conn IDBConn, id string) (int64, error) {
+	cmdTag, err := conn.Exec(
+		ctx,
+		"DELETE FROM blackout_windows WHERE id=$1",
+		id,
+	)
+
+	if err != nil {
+		log.Println("[API] Error deleting blackout window:", err)
+		return 0, err
+	}
+
+	return cmdTag.RowsAffected(), err
+}
+
+func validateBlackoutRange(validationErr *ValidationError, from, to pgtype.Timestamptz) {
+	if !from.Valid {
+		validationErr.Add("starts_at", "is required")
+	}
+
+	if !to.Valid {
+		validationErr.Add("ends_at", "is required")
+	}
+
+	if from.Valid && to.Valid && !from.Time.Before(to.Time) {
+		validationErr.Add("ends_at", "must be after starts_at")
+	}
+}
+
+// blackoutOverlap swaps an exclusion violation for a *BlackoutOverlapError
+// carrying the windows that are in the way.
+func blackoutOverlap(ctx context.Context, conn IDBConn, err error, from, to pgtype.Timestamptz, excludeId string) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23P01" {
+		return err
+	}
+
+	conflicts, loadErr := LoadOverlappingBlackoutWindows(ctx, conn, from, to, excludeId)
+	if loadErr != nil {
+		return err
+	}
+
+	return &BlackoutOverlapError{Conflicts: conflicts}
+}
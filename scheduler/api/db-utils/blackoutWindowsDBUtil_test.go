@@ -0,0 +1,242 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const insertBlackoutWindowQuery = `
+		INSERT INTO blackout_windows (
+			starts_at,
+			ends_at,
+			reason
+		)
+
+		VALUES (
+			@starts_at,
+			@ends_at,
+			@reason
+		)
+
+		RETURNING *;
+	`
+
+const overlappingReservationsQuery = `
+		SELECT * FROM reservations
+		WHERE status IN ('held', 'confirmed')
+			AND tstzrange(start_time, end_time, '[)') && tstzrange(@from_time, @to_time, '[)')
+		ORDER BY start_time ASC
+	`
+
+const overlappingBlackoutWindowsQuery = `
+		SELECT * FROM blackout_windows
+		WHERE tstzrange(starts_at, ends_at, '[)') && tstzrange(@from_time, @to_time, '[)')
+			AND (@exclude_id = '' OR id <> @exclude_id::uuid)
+		ORDER BY starts_at ASC
+	`
+
+func testBlackoutWindow() models.BlackoutWindow {
+	startsAt := time.Date(2025, 12, 24, 12, 0, 0, 0, time.UTC)
+
+	return models.BlackoutWindow{
+		StartsAt: pgtype.Timestamptz{Time: startsAt, Valid: true},
+		EndsAt:   pgtype.Timestamptz{Time: startsAt.Add(6 * time.Hour), Valid: true},
+		Reason:   "Turf replacement",
+	}
+}
+
+func Test_InsertBlackoutWindowData(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	blackoutWindow := testBlackoutWindow()
+	pgU := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	reservationId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(insertBlackoutWindowQuery)).WithArgs(pgx.NamedArgs{
+		"starts_at": blackoutWindow.StartsAt,
+		"ends_at":   blackoutWindow.EndsAt,
+		"reason":    blackoutWindow.Reason,
+	}).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "starts_at", "ends_at", "reason"}).
+			AddRow(pgU, blackoutWindow.StartsAt, blackoutWindow.EndsAt, blackoutWindow.Reason),
+	)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(overlappingReservationsQuery)).WithArgs(pgx.NamedArgs{
+		"from_time": blackoutWindow.StartsAt,
+		"to_time":   blackoutWindow.EndsAt,
+	}).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "customer_first_name", "customer_last_name"}).
+			AddRow(reservationId, "John", "Doe"),
+	)
+
+	// exercise
+	result, err := InsertBlackoutWindowData(context.Background(), mockConn, blackoutWindow)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if result.BlackoutWindow.Id != pgU {
+		t.Fatal("expected id", pgU.String(), "got", result.BlackoutWindow.Id.String())
+	}
+
+	if len(result.ImpactedReservations) != 1 {
+		t.Fatal("expected 1 impacted reservation, got", len(result.ImpactedReservations))
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_InsertBlackoutWindowData_Overlap(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	blackoutWindow := testBlackoutWindow()
+	conflictId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(insertBlackoutWindowQuery)).WithArgs(pgx.NamedArgs{
+		"starts_at": blackoutWindow.StartsAt,
+		"ends_at":   blackoutWindow.EndsAt,
+		"reason":    blackoutWindow.Reason,
+	}).WillReturnError(&pgconn.PgError{Code: "23P01"})
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(overlappingBlackoutWindowsQuery)).WithArgs(pgx.NamedArgs{
+		"from_time":  blackoutWindow.StartsAt,
+		"to_time":    blackoutWindow.EndsAt,
+		"exclude_id": "",
+	}).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "reason"}).
+			AddRow(conflictId, "Christmas Eve"),
+	)
+
+	// exercise
+	result, err := InsertBlackoutWindowData(context.Background(), mockConn, blackoutWindow)
+
+	// verify
+	var overlapErr *BlackoutOverlapError
+	if !errors.As(err, &overlapErr) {
+		t.Fatal("expected overlap error, got", err)
+	}
+
+	if len(overlapErr.Conflicts) != 1 || overlapErr.Conflicts[0].Id != conflictId {
+		t.Fatal("expected the conflicting window, got", overlapErr.Conflicts)
+	}
+
+	if result != nil {
+		t.Fatal("expected no result, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_InsertBlackoutWindowData_Invalid(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	blackoutWindow := testBlackoutWindow()
+	blackoutWindow.EndsAt = blackoutWindow.StartsAt
+	blackoutWindow.Reason = " "
+
+	// exercise
+	result, err := InsertBlackoutWindowData(context.Background(), mockConn, blackoutWindow)
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	if len(validationErr.Fields) != 2 {
+		t.Fatal("expected ends_at and reason errors, got", validationErr.Fields)
+	}
+
+	if result != nil {
+		t.Fatal("expected no result, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_PreviewBlackoutWindow(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	blackoutWindow := testBlackoutWindow()
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(overlappingReservationsQuery)).WithArgs(pgx.NamedArgs{
+		"from_time": blackoutWindow.StartsAt,
+		"to_time":   blackoutWindow.EndsAt,
+	}).WillReturnRows(pgxmock.NewRows([]string{"id"}))
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(overlappingBlackoutWindowsQuery)).WithArgs(pgx.NamedArgs{
+		"from_time":  blackoutWindow.StartsAt,
+		"to_time":    blackoutWindow.EndsAt,
+		"exclude_id": "",
+	}).WillReturnRows(pgxmock.NewRows([]string{"id"}))
+
+	// exercise
+	result, err := PreviewBlackoutWindow(context.Background(), mockConn, blackoutWindow.StartsAt, blackoutWindow.EndsAt)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if result.ImpactedReservations == nil || len(result.ImpactedReservations) != 0 {
+		t.Fatal("expected an empty list of impacted reservations, got", result.ImpactedReservations)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_DeleteBlackoutWindowData_Error(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	pgU := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockConn.ExpectExec(regexp.QuoteMeta(`DELETE FROM blackout_windows WHERE id=$1`)).
+		WithArgs(pgU.String()).
+		WillReturnError(errors.New("test error"))
+
+	// exercise
+	result, err := DeleteBlackoutWindowData(context.Background(), mockConn, pgU.String())
+
+	// verify
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	if result != 0 {
+		t.Fatal("expected 0 deleted rows, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
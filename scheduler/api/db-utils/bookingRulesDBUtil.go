@@ -0,0 +1,380 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const (
+	RuleDurationNotAllowed = "duration_not_allowed"
+	RuleStartGranularity   = "start_granularity"
+	RuleMinLeadTime        = "min_lead_time"
+	RuleMaxHorizon         = "max_horizon"
+)
+
+func LoadBookingRuleData(ctx context.Context, conn IDBConn) ([]models.BookingRule, error) {
+	bookingRules := make([]models.BookingRule, 0)
+
+	query := `SELECT * FROM booking_rules ORDER BY reservation_kind ASC, customer_tier ASC NULLS FIRST`
+
+	err := pgxscan.Select(ctx, conn, &bookingRules, query)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return bookingRules, nil
+}
+
+func LoadBookingRuleById(ctx context.Context, conn IDBConn, id string) (*models.BookingRule, error) {
+	var bookingRule models.BookingRule
+
+	query := `SELECT * FROM booking_rules WHERE id=$1`
+
+	err := pgxscan.Get(ctx, conn, &bookingRule, query, id)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] No booking rule found with id:", id)
+		return nil, nil
+	} else if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &bookingRule, nil
+}
+
+// LoadBookingRuleFor finds the rule a booking of kind made under tier has to
+// follow: the tier's own rule when there is one, otherwise the kind's
+// default. nil means the kind has no rules.
+func LoadBookingRuleFor(ctx context.Context, conn IDBConn, kind models.ReservationKind, tier *string) (*models.BookingRule, error) {
+	var bookingRule models.BookingRule
+
+	args := pgx.NamedArgs{
+		"reservation_kind": kind,
+		"customer_tier":    tier,
+	}
+
+	query := `
+		SELECT * FROM booking_rules
+		WHERE reservation_kind = @reservation_kind
+			AND (customer_tier IS NULL OR customer_tier = @customer_tier)
+		ORDER BY customer_tier ASC NULLS LAST
+		LIMIT 1
+	`
+
+	err := pgxscan.Get(ctx, conn, &bookingRule, query, args)
+
+	if pgxscan.NotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &bookingRule, nil
+}
+
+func InsertBookingRuleData(ctx context.Context, conn IDBConn, input models.BookingRuleUpdates) (*models.BookingRule, error) {
+	bookingRule := models.BookingRule{AllowedDurations: []int32{}, StartGranularityMinutes: 1}
+
+	validationErr := &ValidationError{}
+	if input.Kind == nil {
+		validationErr.Add("reservation_kind", "is required")
+	}
+
+	applyBookingRuleUpdates(validationErr, &bookingRule, input)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	args := pgx.NamedArgs{
+		"reservation_kind":          bookingRule.Kind,
+		"customer_tier":             bookingRule.CustomerTier,
+		"allowed_durations":         bookingRule.AllowedDurations,
+		"start_granularity_minutes": bookingRule.StartGranularityMinutes,
+		"min_lead_minutes":          bookingRule.MinLeadMinutes,
+		"max_horizon_days":          bookingRule.MaxHorizonDays,
+	}
+
+	const query = `
+		INSERT INTO booking_rules (
+			reservation_kind,
+			customer_tier,
+			allowed_durations,
+			start_granularity_minutes,
+			min_lead_minutes,
+			max_horizon_days
+		)
+
+		VALUES (
+			@reservation_kind,
+			@customer_tier,
+			@allowed_durations,
+			@start_granularity_minutes,
+			@min_lead_minutes,
+			@max_horizon_days
+		)
+
+		RETURNING *;
+	`
+
+	var out models.BookingRule
+	if err := pgxscan.Get(ctx, conn, &out, query, args); err != nil {
+		return nil, bookingRuleTaken(err)
+	}
+
+	return &out, nil
+}
+
+func UpdateBookingRuleData(ctx context.Context, conn IDBConn, id string, updates models.BookingRuleUpdates) (*models.BookingRule, error) {
+	existing, err := LoadBookingRuleById(ctx, conn, id)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	validationErr := &ValidationError{}
+	applyBookingRuleUpdates(validationErr, existing, updates)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	var updatedBookingRule models.BookingRule
+
+	args := pgx.NamedArgs{
+		"id":                        id,
+		"reservation_kind":          existing.Kind,
+		"customer_tier":             existing.CustomerTier,
+		"allowed_durations":         existing.AllowedDurations,
+		"start_granularity_minutes": existing.StartGranularityMinutes,
+		"min_lead_minutes":          existing.MinLeadMinutes,
+		"max_horizon_days":          existing.MaxHorizonDays,
+	}
+
+	query := `
+			UPDATE booking_rules
+			SET
+				reservation_kind = @reservation_kind,
+				customer_tier = @customer_tier,
+				allowed_durations = @allowed_durations,
+				start_granularity_minutes = @start_granularity_minutes,
+				min_lead_minutes = @min_lead_minutes,
+				max_horizon_days = @max_horizon_days,
+				updated_at = now()
+			WHERE id = @id
+			RETURNING *
+	`
+
+	err = pgxscan.Get(ctx, conn, &updatedBookingRule, query, args)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find booking rule with id:", id)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating booking rule:", err)
+		return nil, bookingRuleTaken(err)
+	}
+
+	return &updatedBookingRule, nil
+}
+
+func DeleteBookingRuleData(ctx context.Context, conn IDBConn, id string) (int64, error) {
+	cmdTag, err := conn.Exec(
+		ctx,
+		"DELETE FROM booking_rules WHERE id=$1",
+		id,
+	)
+
+	if err != nil {
+		log.Println("[API] Error deleting booking rule:", err)
+		return 0, err
+	}
+
+	return cmdTag.RowsAffected(), err
+}
+
+// CheckBookingRules evaluates the rule that applies to the reservation's kind
+// and customer tier, see EvaluateBookingRule.
+func CheckBookingRules(ctx context.Context, conn IDBConn, loc *time.Location, check ScheduleCheck) error {
+	rule, err := LoadBookingRuleFor(ctx, conn, check.Kind, check.CustomerTier)
+	if err != nil || rule == nil {
+		return err
+	}
+
+	return EvaluateBookingRule(*rule, loc, check.StartTime, check.EndTime, check.Now)
+}
+
+// EvaluateBookingRule lists every way [start, end) breaks rule. now is when
+// the booking is being made, a zero now skips the lead time and horizon so
+// edits that keep the start time aren't held to them.
+func EvaluateBookingRule(rule models.BookingRule, loc *time.Location, start, end pgtype.Timestamptz, now time.Time) error {
+	violations := &RuleViolationError{}
+	subject := bookingRuleSubject(rule)
+
+	length := end.Time.Sub(start.Time)
+	if len(rule.AllowedDurations) > 0 && !slices.ContainsFunc(rule.AllowedDurations, func(minutes int32) bool {
+		return length == time.Duration(minutes)*time.Minute
+	}) {
+		allowed := make([]string, 0, len(rule.AllowedDurations))
+		for _, minutes := range rule.AllowedDurations {
+			allowed = append(allowed, fmt.Sprint(minutes))
+		}
+
+		violations.Add(RuleDurationNotAllowed, fmt.Sprintf(
+			"%s must last %s minutes, requested %v.",
+			subject,
+			strings.Join(allowed, ", "),
+			length.Minutes(),
+		))
+	}
+
+	// wall clock time, so the grid doesn't shift on DST days
+	localStart := start.Time.In(loc)
+	sinceMidnight := time.Duration(localStart.Hour())*time.Hour +
+		time.Duration(localStart.Minute())*time.Minute +
+		time.Duration(localStart.Second())*time.Second +
+		time.Duration(localStart.Nanosecond())
+
+	if granularity := time.Duration(rule.StartGranularityMinutes) * time.Minute; granularity > 0 && sinceMidnight%granularity != 0 {
+		violations.Add(RuleStartGranularity, fmt.Sprintf(
+			"%s can only start every %s, requested %s.",
+			subject,
+			describeMinutes(rule.StartGranularityMinutes),
+			localStart.Format("15:04:05"),
+		))
+	}
+
+	if !now.IsZero() {
+		if rule.MinLeadMinutes > 0 && start.Time.Before(now.Add(time.Duration(rule.MinLeadMinutes)*time.Minute)) {
+			violations.Add(RuleMinLeadTime, fmt.Sprintf(
+				"%s must be booked at least %s ahead.",
+				subject,
+				describeMinutes(rule.MinLeadMinutes),
+			))
+		}
+
+		if rule.MaxHorizonDays != nil && start.Time.After(now.AddDate(0, 0, int(*rule.MaxHorizonDays))) {
+			violations.Add(RuleMaxHorizon, fmt.Sprintf(
+				"%s can be booked at most %s ahead.",
+				subject,
+				describeMinutes(*rule.MaxHorizonDays*24*60),
+			))
+		}
+	}
+
+	return violations.Err()
+}
+
+// applyBookingRuleUpdates merges the raw input onto bookingRule. A
+// max_horizon_days of 0 removes the horizon.
+func applyBookingRuleUpdates(validationErr *ValidationError, bookingRule *models.BookingRule, updates models.BookingRuleUpdates) {
+	if updates.Kind != nil {
+		if *updates.Kind != models.ReservationKindTunnel && *updates.Kind != models.ReservationKindLesson {
+			validationErr.Add("reservation_kind", "must be tunnel or lesson")
+		} else {
+			bookingRule.Kind = *updates.Kind
+		}
+	}
+
+	if updates.CustomerTier != nil {
+		if strings.TrimSpace(*updates.CustomerTier) == "" {
+			validationErr.Add("customer_tier", "cannot be empty")
+		} else {
+			bookingRule.CustomerTier = updates.CustomerTier
+		}
+	}
+
+	if updates.AllowedDurations != nil {
+		durations := slices.Clone(*updates.AllowedDurations)
+		if slices.ContainsFunc(durations, func(minutes int32) bool { return minutes <= 0 }) {
+			validationErr.Add("allowed_durations", "must all be greater than 0")
+		} else {
+			slices.Sort(durations)
+			bookingRule.AllowedDurations = slices.Compact(durations)
+		}
+	}
+
+	if updates.StartGranularityMinutes != nil {
+		if *updates.StartGranularityMinutes < 1 || *updates.StartGranularityMinutes > 24*60 {
+			validationErr.Add("start_granularity_minutes", "must be between 1 and 1440")
+		} else {
+			bookingRule.StartGranularityMinutes = *updates.StartGranularityMinutes
+		}
+	}
+
+	if updates.MinLeadMinutes != nil {
+		if *updates.MinLeadMinutes < 0 {
+			validationErr.Add("min_lead_minutes", "cannot be negative")
+		} else {
+			bookingRule.MinLeadMinutes = *updates.MinLeadMinutes
+		}
+	}
+
+	if updates.MaxHorizonDays != nil {
+		switch {
+		case *updates.MaxHorizonDays < 0:
+			validationErr.Add("max_horizon_days", "cannot be negative")
+		case *updates.MaxHorizonDays == 0:
+			bookingRule.MaxHorizonDays = nil
+		default:
+			bookingRule.MaxHorizonDays = updates.MaxHorizonDays
+		}
+	}
+}
+
+// bookingRuleTaken turns the UNIQUE (reservation_kind, customer_tier)
+// violation into a field error.
+func bookingRuleTaken(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		validationErr := &ValidationError{}
+		validationErr.Add("customer_tier", "a booking rule already exists for this kind and tier")
+		return validationErr
+	}
+
+	return err
+}
+
+// bookingRuleSubject names who a rule applies to in violation messages.
+func bookingRuleSubject(rule models.BookingRule) string {
+	subject := "Tunnel rentals"
+	if rule.Kind == models.ReservationKindLesson {
+		subject = "Lessons"
+	}
+
+	if rule.CustomerTier != nil {
+		subject += fmt.Sprintf(" for %s customers", *rule.CustomerTier)
+	}
+
+	return subject
+}
+
+// describeMinutes spells out a number of minutes in the largest whole unit.
+func describeMinutes(minutes int32) string {
+	unit, count := "minute", minutes
+	switch {
+	case minutes > 0 && minutes%(24*60) == 0:
+		unit, count = "day", minutes/(24*60)
+	case minutes > 0 && minutes%60 == 0:
+		unit, count = "hour", minutes/60
+	}
+
+	if count != 1 {
+		unit += "s"
+	}
+
+	return fmt.Sprintf("%d %s", count, unit)
+}
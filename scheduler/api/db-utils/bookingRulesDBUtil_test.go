@@ -0,0 +1,160 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func testTunnelBookingRule() models.BookingRule {
+	var horizon int32 = 30
+
+	return models.BookingRule{
+		Kind:                    models.ReservationKindTunnel,
+		AllowedDurations:        []int32{30, 60, 90},
+		StartGranularityMinutes: 30,
+		MinLeadMinutes:          120,
+		MaxHorizonDays:          &horizon,
+	}
+}
+
+func Test_EvaluateBookingRule_ListsEveryViolation(t *testing.T) {
+	// setup
+	loc, _ := time.LoadLocation("America/Indiana/Indianapolis")
+	now := time.Date(2025, 9, 2, 17, 0, 0, 0, loc)
+
+	// 45 minutes starting at quarter past, an hour from now
+	start := pgtype.Timestamptz{Time: time.Date(2025, 9, 2, 18, 15, 0, 0, loc), Valid: true}
+	end := pgtype.Timestamptz{Time: time.Date(2025, 9, 2, 19, 0, 0, 0, loc), Valid: true}
+
+	// exercise
+	err := EvaluateBookingRule(testTunnelBookingRule(), loc, start, end, now)
+
+	// verify
+	var violationErr *RuleViolationError
+	if !errors.As(err, &violationErr) {
+		t.Fatal("expected rule violations, got", err)
+	}
+
+	rules := make([]string, 0, len(violationErr.Violations))
+	for _, violation := range violationErr.Violations {
+		rules = append(rules, violation.Rule)
+	}
+
+	expected := []string{RuleDurationNotAllowed, RuleStartGranularity, RuleMinLeadTime}
+	if len(rules) != len(expected) {
+		t.Fatal("expected", expected, "got", rules)
+	}
+
+	for i := range expected {
+		if rules[i] != expected[i] {
+			t.Fatal("expected", expected, "got", rules)
+		}
+	}
+}
+
+func Test_EvaluateBookingRule_Horizon(t *testing.T) {
+	// setup
+	loc := time.UTC
+	now := time.Date(2025, 9, 2, 12, 0, 0, 0, loc)
+	start := pgtype.Timestamptz{Time: time.Date(2025, 10, 3, 18, 0, 0, 0, loc), Valid: true}
+	end := pgtype.Timestamptz{Time: time.Date(2025, 10, 3, 19, 0, 0, 0, loc), Valid: true}
+
+	// exercise
+	err := EvaluateBookingRule(testTunnelBookingRule(), loc, start, end, now)
+
+	// verify
+	var violationErr *RuleViolationError
+	if !errors.As(err, &violationErr) || len(violationErr.Violations) != 1 || violationErr.Violations[0].Rule != RuleMaxHorizon {
+		t.Fatal("expected only the horizon to be broken, got", err)
+	}
+
+	// an edit that keeps the start time isn't held to the horizon
+	if err = EvaluateBookingRule(testTunnelBookingRule(), loc, start, end, time.Time{}); err != nil {
+		t.Fatal("expected no violations without a booking time, got", err)
+	}
+}
+
+func Test_LoadBookingRuleFor(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	query := `
+		SELECT * FROM booking_rules
+		WHERE reservation_kind = @reservation_kind
+			AND (customer_tier IS NULL OR customer_tier = @customer_tier)
+		ORDER BY customer_tier ASC NULLS LAST
+		LIMIT 1
+	`
+
+	tier := "member"
+	pgU := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	rows := pgxmock.NewRows([]string{"id", "reservation_kind", "customer_tier", "allowed_durations"}).
+		AddRow(pgU, models.ReservationKindTunnel, &tier, []int32{30, 60})
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(pgx.NamedArgs{
+		"reservation_kind": models.ReservationKindTunnel,
+		"customer_tier":    &tier,
+	}).WillReturnRows(rows)
+
+	// exercise
+	result, err := LoadBookingRuleFor(context.Background(), mockConn, models.ReservationKindTunnel, &tier)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if result == nil || result.Id != pgU || len(result.AllowedDurations) != 2 {
+		t.Fatal("expected the member rule, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_InsertBookingRuleData_Invalid(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	durations := []int32{30, 0}
+	var granularity int32 = 0
+
+	// exercise
+	result, err := InsertBookingRuleData(context.Background(), mockConn, models.BookingRuleUpdates{
+		AllowedDurations:        &durations,
+		StartGranularityMinutes: &granularity,
+	})
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	for _, field := range []string{"reservation_kind", "allowed_durations", "start_granularity_minutes"} {
+		if _, ok := validationErr.Fields[field]; !ok {
+			t.Fatal("expected a", field, "error, got", validationErr.Fields)
+		}
+	}
+
+	if result != nil {
+		t.Fatal("expected no result, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,143 @@
+package db_utils
+
+import (
+	"context"
+	"log"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func LoadBusinessHoursData(ctx context.Context, conn IDBConn) ([]models.BusinessHours, error) {
+	businessHours := make([]models.BusinessHours, 0)
+
+	query := `SELECT * FROM business_hours ORDER BY dow`
+
+	err := pgxscan.Select(ctx, conn, &businessHours, query)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return businessHours, nil
+}
+
+func LoadBusinessHoursByDow(ctx context.Context, conn IDBConn, dow int32) (*models.BusinessHours, error) {
+	var businessHours models.BusinessHours
+
+	query := `SELECT * FROM business_hours WHERE dow=$1`
+
+	err := pgxscan.Get(ctx, conn, &businessHours, query, dow)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] No business hours found for dow:", dow)
+		return nil, nil
+	} else if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &businessHours, nil
+}
+
+// UpsertBusinessHoursData applies the updates on top of the current hours for
+// that day (or creates the day if it is missing). The merged result is
+// validated first, so bad input comes back as a *ValidationError.
+func UpsertBusinessHoursData(ctx context.Context, conn IDBConn, dow int32, updates models.BusinessHoursUpdates) (*models.BusinessHours, error) {
+	validationErr := &ValidationError{}
+	ValidateDow(validationErr, "dow", dow)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	existing, err := LoadBusinessHoursByDow(ctx, conn, dow)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := models.BusinessHours{Dow: dow, IsOpen: true}
+	if existing != nil {
+		merged = *existing
+	}
+
+	applyHoursUpdates(validationErr, &merged.OpenTime, &merged.CloseTime, &merged.IsOpen, updates.OpenTime, updates.CloseTime, updates.IsOpen)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	args := pgx.NamedArgs{
+		"dow":        merged.Dow,
+		"open_time":  merged.OpenTime,
+		"close_time": merged.CloseTime,
+		"is_open":    merged.IsOpen,
+	}
+
+	const query = `
+		INSERT INTO business_hours (dow, open_time, close_time, is_open)
+		VALUES (@dow, @open_time, @close_time, @is_open)
+		ON CONFLICT (dow) DO UPDATE
+		SET open_time = EXCLUDED.open_time,
+			close_time = EXCLUDED.close_time,
+			is_open = EXCLUDED.is_open
+		RETURNING *;
+	`
+
+	var out models.BusinessHours
+	if err := pgxscan.Get(ctx, conn, &out, query, args); err != nil {
+		log.Println("[API] Error upserting business hours:", err)
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func ValidateDow(validationErr *ValidationError, field string, dow int32) {
+	if dow < 0 || dow > 6 {
+		validationErr.Add(field, "must be between 0 (Sunday) and 6 (Saturday)")
+	}
+}
+
+// applyHoursUpdates parses the raw open/close times onto the current values
+// and checks the same rules as the CHECK (open_time < close_time) constraint.
+func applyHoursUpdates(
+	validationErr *ValidationError,
+	openTime, closeTime *models.TimeOfDay,
+	isOpen *bool,
+	openUpdate, closeUpdate *string,
+	isOpenUpdate *bool,
+) {
+	if openUpdate != nil {
+		parsed, err := models.ParseTimeOfDay(*openUpdate)
+		if err != nil {
+			validationErr.Add("open_time", "must be a time formatted as HH:MM")
+		} else {
+			*openTime = parsed
+		}
+	}
+
+	if closeUpdate != nil {
+		parsed, err := models.ParseTimeOfDay(*closeUpdate)
+		if err != nil {
+			validationErr.Add("close_time", "must be a time formatted as HH:MM")
+		} else {
+			*closeTime = parsed
+		}
+	}
+
+	if isOpenUpdate != nil {
+		*isOpen = *isOpenUpdate
+	}
+
+	if !openTime.Valid {
+		validationErr.Add("open_time", "is required")
+	}
+
+	if !closeTime.Valid {
+		validationErr.Add("close_time", "is required")
+	}
+
+	if openTime.Valid && closeTime.Valid && openTime.Microseconds >= closeTime.Microseconds {
+		validationErr.Add("close_time", "must be after open_time")
+	}
+}
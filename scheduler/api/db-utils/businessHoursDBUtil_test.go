@@ -0,0 +1,216 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const upsertBusinessHoursQuery = `
+		INSERT INTO business_hours (dow, open_time, close_time, is_open)
+		VALUES (@dow, @open_time, @close_time, @is_open)
+		ON CONFLICT (dow) DO UPDATE
+		SET open_time = EXCLUDED.open_time,
+			close_time = EXCLUDED.close_time,
+			is_open = EXCLUDED.is_open
+		RETURNING *;
+	`
+
+func Test_LoadBusinessHoursData(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	rows := pgxmock.NewRows([]string{"dow", "open_time", "close_time", "is_open"}).
+		AddRow(int32(0), models.NewTimeOfDay(10, 0), models.NewTimeOfDay(17, 0), true).
+		AddRow(int32(1), models.NewTimeOfDay(15, 0), models.NewTimeOfDay(21, 0), true)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM business_hours ORDER BY dow`)).WillReturnRows(rows)
+
+	// exercise
+	result, err := LoadBusinessHoursData(context.Background(), mockConn)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(result) != 2 {
+		t.Fatal("expected 2 rows, got", len(result))
+	}
+
+	if result[1].OpenTime.String() != "15:00" {
+		t.Fatal("expected open time 15:00, got", result[1].OpenTime.String())
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_LoadBusinessHoursData_Error(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM business_hours ORDER BY dow`)).WillReturnError(errors.New("test error"))
+
+	// exercise
+	result, err := LoadBusinessHoursData(context.Background(), mockConn)
+
+	// verify
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	if result != nil {
+		t.Fatal("expected no result, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_LoadBusinessHoursByDow_NotFound(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM business_hours WHERE dow=$1`)).
+		WithArgs(int32(3)).
+		WillReturnError(pgx.ErrNoRows)
+
+	// exercise
+	result, err := LoadBusinessHoursByDow(context.Background(), mockConn, 3)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if result != nil {
+		t.Fatal("expected no result, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_UpsertBusinessHoursData(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	pgU := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	closeTime := "18:30"
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM business_hours WHERE dow=$1`)).
+		WithArgs(int32(0)).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"id", "dow", "open_time", "close_time", "is_open"}).
+				AddRow(pgU, int32(0), models.NewTimeOfDay(10, 0), models.NewTimeOfDay(17, 0), true),
+		)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(upsertBusinessHoursQuery)).WithArgs(pgx.NamedArgs{
+		"dow":        int32(0),
+		"open_time":  models.NewTimeOfDay(10, 0),
+		"close_time": models.NewTimeOfDay(18, 30),
+		"is_open":    true,
+	}).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "dow", "open_time", "close_time", "is_open"}).
+			AddRow(pgU, int32(0), models.NewTimeOfDay(10, 0), models.NewTimeOfDay(18, 30), true),
+	)
+
+	// exercise
+	result, err := UpsertBusinessHoursData(context.Background(), mockConn, 0, models.BusinessHoursUpdates{CloseTime: &closeTime})
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if result == nil {
+		t.Fatal("expected result, got none")
+	}
+
+	if result.CloseTime.String() != closeTime {
+		t.Fatal("expected close time", closeTime, "got", result.CloseTime.String())
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_UpsertBusinessHoursData_CloseBeforeOpen(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	openTime := "17:00"
+	closeTime := "09:00"
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM business_hours WHERE dow=$1`)).
+		WithArgs(int32(6)).
+		WillReturnError(pgx.ErrNoRows)
+
+	// exercise
+	result, err := UpsertBusinessHoursData(context.Background(), mockConn, 6, models.BusinessHoursUpdates{
+		OpenTime:  &openTime,
+		CloseTime: &closeTime,
+	})
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	if _, ok := validationErr.Fields["close_time"]; !ok {
+		t.Fatal("expected close_time field error, got", validationErr.Fields)
+	}
+
+	if result != nil {
+		t.Fatal("expected no result, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_UpsertBusinessHoursData_InvalidDow(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	// exercise
+	result, err := UpsertBusinessHoursData(context.Background(), mockConn, 7, models.BusinessHoursUpdates{})
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	if _, ok := validationErr.Fields["dow"]; !ok {
+		t.Fatal("expected dow field error, got", validationErr.Fields)
+	}
+
+	if result != nil {
+		t.Fatal("expected no result, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,244 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// NoCoachAvailableError is returned when no active coach with the specialty
+// can take a lesson, Candidates says why for each coach considered.
+type NoCoachAvailableError struct {
+	Specialty  string
+	Candidates []models.CoachCandidate
+}
+
+func (e *NoCoachAvailableError) Error() string {
+	return fmt.Sprintf("no %s coach is free for that time", e.Specialty)
+}
+
+// InsertLessonWithAssignedCoach books a lesson that didn't name a coach with
+// the active coach teaching the lesson type's specialty who is free and has
+// the least lesson time booked that week, see assignCoach. A missing
+// tunnel_id is filled in by tunnelStrategy in the same transaction. The
+// returned reservation explains the pick in CoachAssignment.
+func InsertLessonWithAssignedCoach(ctx context.Context, conn IDBTxConn, loc *time.Location, tunnelStrategy TunnelAssignmentStrategy, lessonType models.LessonType, r models.Reservation) (*models.Reservation, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	assignment, err := assignCoach(ctx, tx, loc, lessonType.Specialty, r)
+	if err != nil {
+		return nil, err
+	}
+
+	r.CoachId = &assignment.CoachId
+
+	if r.TunnelId == nil {
+		tunnelId, err := assignTunnel(ctx, tx, loc, tunnelStrategy, r)
+		if err != nil {
+			return nil, err
+		}
+
+		r.TunnelId = &tunnelId
+	}
+
+	out, err := InsertReservationData(ctx, tx, r)
+	if err != nil {
+		log.Println("[API] Error inserting reservation:", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing reservation:", err)
+		return nil, err
+	}
+
+	out.CoachAssignment = assignment
+	return out, nil
+}
+
+// assignCoach picks the coach for r inside tx among the active coaches with
+// specialty: they must be clear of other bookings (turnover included) and,
+// unless the hours are overridden, working and not off at that time. Of
+// those the one with the fewest held/confirmed lesson minutes in the lesson's
+// local Monday-Sunday week wins, ties go by name. The candidate coaches stay
+// locked until tx ends so concurrent requests don't pick the same coach.
+func assignCoach(ctx context.Context, tx IDBConn, loc *time.Location, specialty string, r models.Reservation) (*models.CoachAssignment, error) {
+	coaches := make([]models.Coach, 0)
+
+	query := `
+		SELECT * FROM coaches
+		WHERE is_active AND @specialty::coach_specialty = ANY (specialties)
+		ORDER BY last_name ASC, first_name ASC
+		FOR NO KEY UPDATE
+	`
+
+	err := pgxscan.Select(ctx, tx, &coaches, query, pgx.NamedArgs{"specialty": specialty})
+	if err != nil {
+		log.Println("[API] Error locking coaches:", err)
+		return nil, err
+	}
+
+	slot := reservationInterval(r)
+
+	localStart := r.StartTime.Time.In(loc)
+	daysSinceMonday := (int(localStart.Weekday()) + 6) % 7
+	weekStart := time.Date(localStart.Year(), localStart.Month(), localStart.Day()-daysSinceMonday, 0, 0, 0, 0, loc)
+
+	weekMinutes, err := LoadCoachLessonMinutes(ctx, tx,
+		pgtype.Timestamptz{Time: weekStart, Valid: true},
+		pgtype.Timestamptz{Time: weekStart.AddDate(0, 0, 7), Valid: true},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var maxBuffer time.Duration
+	for _, coach := range coaches {
+		maxBuffer = max(maxBuffer, coach.TurnoverBuffer())
+	}
+
+	nearby, err := LoadActiveReservationsWithTurnover(ctx, tx, r.StartTime,
+		pgtype.Timestamptz{Time: r.EndTime.Time.Add(maxBuffer), Valid: true},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]models.CoachCandidate, 0, len(coaches))
+	best := -1
+
+	for _, coach := range coaches {
+		candidate := models.CoachCandidate{
+			CoachId:           coach.Id,
+			CoachName:         coachName(coach),
+			WeekLessonMinutes: weekMinutes[coach.Id],
+		}
+
+		candidate.Reason, err = coachUnavailableReason(ctx, tx, loc, coach, r, slot, nearby)
+		if err != nil {
+			return nil, err
+		}
+
+		if candidate.Reason == "" {
+			candidate.Available = true
+			candidate.Reason = fmt.Sprintf("Free, with %s of lessons booked that week.", describeMinutes(candidate.WeekLessonMinutes))
+
+			if best < 0 || candidate.WeekLessonMinutes < candidates[best].WeekLessonMinutes {
+				best = len(candidates)
+			}
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	if best < 0 {
+		return nil, &NoCoachAvailableError{Specialty: specialty, Candidates: candidates}
+	}
+
+	available := 0
+	for _, candidate := range candidates {
+		if candidate.Available {
+			available++
+		}
+	}
+
+	picked := candidates[best]
+	return &models.CoachAssignment{
+		CoachId:   picked.CoachId,
+		CoachName: picked.CoachName,
+		Specialty: specialty,
+		Reason: fmt.Sprintf(
+			"%s teaches %s, is free %s-%s and has the least lesson time booked that week (%s) of the %d available coach(es).",
+			picked.CoachName,
+			specialty,
+			localStart.Format("15:04"),
+			r.EndTime.Time.In(loc).Format("15:04"),
+			describeMinutes(picked.WeekLessonMinutes),
+			available,
+		),
+		Candidates: candidates,
+	}, nil
+}
+
+// coachUnavailableReason explains why coach can't take r, "" when they can.
+func coachUnavailableReason(ctx context.Context, conn IDBConn, loc *time.Location, coach models.Coach, r models.Reservation, slot Interval, nearby []models.Reservation) (string, error) {
+	for _, reservation := range nearby {
+		if reservation.CoachId == nil || *reservation.CoachId != coach.Id {
+			continue
+		}
+
+		if coachBusyInterval(reservation, coach.TurnoverBuffer()).Overlaps(slot) {
+			return fmt.Sprintf(
+				"Already booked %s-%s.",
+				reservation.StartTime.Time.In(loc).Format("15:04"),
+				reservation.EndTime.Time.In(loc).Format("15:04"),
+			), nil
+		}
+	}
+
+	if r.OverrideHours {
+		return "", nil
+	}
+
+	err := CheckCoachAvailability(ctx, conn, loc, coach.Id, r.StartTime, r.EndTime)
+
+	var violationErr *RuleViolationError
+	if errors.As(err, &violationErr) {
+		return violationErr.Error(), nil
+	}
+
+	return "", err
+}
+
+// LoadCoachLessonMinutes adds up the held/confirmed lesson minutes of every
+// coach starting in [from, to).
+func LoadCoachLessonMinutes(ctx context.Context, conn IDBConn, from, to pgtype.Timestamptz) (map[pgtype.UUID]int32, error) {
+	var rows []struct {
+		CoachId       pgtype.UUID `db:"coach_id"`
+		BookedMinutes int32       `db:"booked_minutes"`
+	}
+
+	args := pgx.NamedArgs{
+		"from_time": from,
+		"to_time":   to,
+	}
+
+	query := `
+		SELECT coach_id, SUM(duration_minutes)::int AS booked_minutes FROM reservations
+		WHERE coach_id IS NOT NULL
+			AND status IN ('held', 'confirmed')
+			AND start_time >= @from_time
+			AND start_time < @to_time
+		GROUP BY coach_id
+	`
+
+	err := pgxscan.Select(ctx, conn, &rows, query, args)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	minutes := make(map[pgtype.UUID]int32, len(rows))
+	for _, row := range rows {
+		minutes[row.CoachId] = row.BookedMinutes
+	}
+
+	return minutes, nil
+}
+
+func coachName(coach models.Coach) string {
+	return strings.TrimSpace(coach.FirstName + " " + coach.LastName)
+}
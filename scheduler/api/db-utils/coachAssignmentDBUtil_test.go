@@ -0,0 +1,152 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const lockSpecialtyCoachesQuery = `
+		SELECT * FROM coaches
+		WHERE is_active AND @specialty::coach_specialty = ANY (specialties)
+		ORDER BY last_name ASC, first_name ASC
+		FOR NO KEY UPDATE
+	`
+
+func testUnassignedLesson() (models.Reservation, models.LessonType) {
+	reservation := testAssignedReservation()
+	reservation.Kind = models.ReservationKindLesson
+
+	var tunnelId int32 = 1
+	reservation.TunnelId = &tunnelId
+
+	return reservation, models.LessonType{Name: "Hitting 60", Specialty: models.SpecialtyHitting, DefaultDuration: 60, IsActive: true}
+}
+
+// expectFreeCoach queues a coach with no working windows and no time off.
+func expectFreeCoach(mockConn pgxmock.PgxConnIface, coachId pgtype.UUID) {
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM coach_availability WHERE coach_id = $1)`)).
+		WithArgs(coachId).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(coachTimeOffQuery)).WithArgs(anyArgs(3)...).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+}
+
+func Test_InsertLessonWithAssignedCoach(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	reservation, lessonType := testUnassignedLesson()
+	busier := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	quieter := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta(lockSpecialtyCoachesQuery)).WithArgs(anyArgs(1)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "first_name", "last_name", "is_active", "turnover_buffer_minutes"}).
+			AddRow(busier, "Ann", "Able", true, int32(10)).
+			AddRow(quieter, "Bob", "Baker", true, int32(10)),
+	)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta("SELECT coach_id, SUM(duration_minutes)::int AS booked_minutes FROM reservations")).
+		WithArgs(anyArgs(2)...).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"coach_id", "booked_minutes"}).
+				AddRow(busier, int32(240)).
+				AddRow(quieter, int32(60)),
+		)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(turnoverReservationsQuery)).WithArgs(anyArgs(2)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id"}),
+	)
+
+	expectFreeCoach(mockConn, busier)
+	expectFreeCoach(mockConn, quieter)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO reservations")).WithArgs(anyArgs(23)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "coach_id"}).
+			AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, &quieter),
+	)
+
+	mockConn.ExpectCommit()
+	mockConn.ExpectRollback()
+
+	// exercise
+	result, err := InsertLessonWithAssignedCoach(context.Background(), mockConn, time.UTC, LowestNumberStrategy{}, lessonType, reservation)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if result.CoachAssignment == nil || result.CoachAssignment.CoachId != quieter {
+		t.Fatal("expected the coach with fewer lesson minutes to be assigned, got", result.CoachAssignment)
+	}
+
+	if len(result.CoachAssignment.Candidates) != 2 || !strings.Contains(result.CoachAssignment.Reason, "Bob Baker") {
+		t.Fatal("expected the assignment to explain the pick, got", result.CoachAssignment)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_InsertLessonWithAssignedCoach_NoneFree(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	reservation, lessonType := testUnassignedLesson()
+	coachId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	taken := testBooking(2, 17, 18)
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta(lockSpecialtyCoachesQuery)).WithArgs(anyArgs(1)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "first_name", "last_name", "is_active", "turnover_buffer_minutes"}).
+			AddRow(coachId, "Ann", "Able", true, int32(10)),
+	)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta("SELECT coach_id, SUM(duration_minutes)::int AS booked_minutes FROM reservations")).
+		WithArgs(anyArgs(2)...).
+		WillReturnRows(pgxmock.NewRows([]string{"coach_id", "booked_minutes"}).AddRow(coachId, int32(60)))
+
+	// the coach's lesson ends at 18:00 and they still need their 10 minute break
+	mockConn.ExpectQuery(regexp.QuoteMeta(turnoverReservationsQuery)).WithArgs(anyArgs(2)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "tunnel_id", "coach_id", "start_time", "end_time", "coach_turnover_end"}).
+			AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, taken.TunnelId, &coachId, taken.StartTime, taken.EndTime,
+				pgtype.Timestamptz{Time: taken.EndTime.Time.Add(10 * time.Minute), Valid: true}),
+	)
+
+	mockConn.ExpectRollback()
+
+	// exercise
+	result, err := InsertLessonWithAssignedCoach(context.Background(), mockConn, time.UTC, LowestNumberStrategy{}, lessonType, reservation)
+
+	// verify
+	var noCoachErr *NoCoachAvailableError
+	if !errors.As(err, &noCoachErr) {
+		t.Fatal("expected no coach to be available, got", err)
+	}
+
+	if len(noCoachErr.Candidates) != 1 || noCoachErr.Candidates[0].Available {
+		t.Fatal("expected the busy coach to be listed as unavailable, got", noCoachErr.Candidates)
+	}
+
+	if result != nil {
+		t.Fatal("expected nothing to be booked, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,518 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const (
+	RuleCoachUnavailable = "coach_unavailable"
+	RuleCoachTimeOff     = "coach_time_off"
+)
+
+// ErrCoachNotFound is returned when writing schedule data for a coach that doesn't exist.
+var ErrCoachNotFound = errors.New("coach not found")
+
+func LoadCoachAvailabilityData(ctx context.Context, conn IDBConn, coachId string) ([]models.CoachAvailability, error) {
+	availability := make([]models.CoachAvailability, 0)
+
+	query := `
+		SELECT * FROM coach_availability
+		WHERE coach_id = $1
+		ORDER BY dow ASC, start_time ASC
+	`
+
+	err := pgxscan.Select(ctx, conn, &availability, query, coachId)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return availability, nil
+}
+
+// LoadCoachAvailabilityOnDate returns the windows that apply to a coach on a
+// local calendar date (matching day of week and effective date range).
+func LoadCoachAvailabilityOnDate(ctx context.Context, conn IDBConn, coachId pgtype.UUID, onDate pgtype.Date) ([]models.CoachAvailability, error) {
+	availability := make([]models.CoachAvailability, 0)
+
+	args := pgx.NamedArgs{
+		"coach_id": coachId,
+		"dow":      int32(onDate.Time.Weekday()),
+		"on_date":  onDate,
+	}
+
+	query := `
+		SELECT * FROM coach_availability
+		WHERE coach_id = @coach_id
+			AND dow = @dow
+			AND effective_from <= @on_date
+			AND (effective_to IS NULL OR effective_to >= @on_date)
+		ORDER BY start_time ASC
+	`
+
+	err := pgxscan.Select(ctx, conn, &availability, query, args)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return availability, nil
+}
+
+// HasCoachAvailability reports whether the coach has any weekly windows set up at all.
+func HasCoachAvailability(ctx context.Context, conn IDBConn, coachId pgtype.UUID) (bool, error) {
+	var exists bool
+
+	err := conn.QueryRow(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM coach_availability WHERE coach_id = $1)`,
+		coachId,
+	).Scan(&exists)
+
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func InsertCoachAvailabilityData(ctx context.Context, conn IDBConn, coachId string, input models.CoachAvailabilityUpdates) (*models.CoachAvailability, error) {
+	var availability models.CoachAvailability
+
+	validationErr := &ValidationError{}
+	if input.Dow == nil {
+		validationErr.Add("dow", "is required")
+	}
+
+	applyCoachAvailabilityUpdates(validationErr, &availability, input)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	args := pgx.NamedArgs{
+		"coach_id":       coachId,
+		"dow":            availability.Dow,
+		"start_time":     availability.StartTime,
+		"end_time":       availability.EndTime,
+		"effective_from": availability.EffectiveFrom,
+		"effective_to":   availability.EffectiveTo,
+	}
+
+	const query = `
+		INSERT INTO coach_availability (
+			coach_id,
+			dow,
+			start_time,
+			end_time,
+			effective_from,
+			effective_to
+		)
+
+		VALUES (
+			@coach_id,
+			@dow,
+			@start_time,
+			@end_time,
+			COALESCE(@effective_from, CURRENT_DATE),
+			@effective_to
+		)
+
+		RETURNING *;
+	`
+
+	var out models.CoachAvailability
+	if err := pgxscan.Get(ctx, conn, &out, query, args); err != nil {
+		return nil, coachNotFound(err)
+	}
+
+	return &out, nil
+}
+
+func UpdateCoachAvailabilityData(ctx context.Context, conn IDBConn, coachId, id string, updates models.CoachAvailabilityUpdates) (*models.CoachAvailability, error) {
+	var existing models.CoachAvailability
+
+	err := pgxscan.Get(ctx, conn, &existing, `SELECT * FROM coach_availability WHERE id=$1 AND coach_id=$2`, id, coachId)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find coach availability with id:", id)
+		return nil, nil
+	} else if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	validationErr := &ValidationError{}
+	applyCoachAvailabilityUpdates(validationErr, &existing, updates)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	var updatedAvailability models.CoachAvailability
+
+	args := pgx.NamedArgs{
+		"id":             id,
+		"coach_id":       coachId,
+		"dow":            existing.Dow,
+		"start_time":     existing.StartTime,
+		"end_time":       existing.EndTime,
+		"effective_from": existing.EffectiveFrom,
+		"effective_to":   existing.EffectiveTo,
+	}
+
+	query := `
+			UPDATE coach_availability
+			SET
+				dow = @dow,
+				start_time = @start_time,
+				end_time = @end_time,
+				effective_from = @effective_from,
+				effective_to = @effective_to,
+				updated_at = now()
+			WHERE id = @id AND coach_id = @coach_id
+			RETURNING *
+	`
+
+	err = pgxscan.Get(ctx, conn, &updatedAvailability, query, args)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find coach availability with id:", id)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating coach availability:", err)
+		return nil, err
+	}
+
+	return &updatedAvailability, nil
+}
+
+func DeleteCoachAvailabilityData(ctx context.Context, conn IDBConn, coachId, id string) (int64, error) {
+	cmdTag, err := conn.Exec(
+		ctx,
+		"DELETE FROM coach_availability WHERE id=$1 AND coach_id=$2",
+		id,
+		coachId,
+	)
+
+	if err != nil {
+		log.Println("[API] Error deleting coach availability:", err)
+		return 0, err
+	}
+
+	return cmdTag.RowsAffected(), err
+}
+
+// LoadCoachTimeOffData lists a coach's time off overlapping [from, to). Either
+// bound may be left invalid to leave that side of the range open.
+func LoadCoachTimeOffData(ctx context.Context, conn IDBConn, coachId string, from, to pgtype.Timestamptz) ([]models.CoachTimeOff, error) {
+	timeOff := make([]models.CoachTimeOff, 0)
+
+	args := pgx.NamedArgs{
+		"coach_id":  coachId,
+		"from_time": from,
+		"to_time":   to,
+	}
+
+	query := `
+		SELECT * FROM coach_time_off
+		WHERE coach_id = @coach_id
+			AND (@from_time::timestamptz IS NULL OR ends_at > @from_time::timestamptz)
+			AND (@to_time::timestamptz IS NULL OR starts_at < @to_time::timestamptz)
+		ORDER BY starts_at ASC
+	`
+
+	err := pgxscan.Select(ctx, conn, &timeOff, query, args)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return timeOff, nil
+}
+
+func InsertCoachTimeOffData(ctx context.Context, conn IDBConn, coachId string, t models.CoachTimeOff) (*models.CoachTimeOff, error) {
+	validationErr := &ValidationError{}
+	if !t.StartsAt.Valid {
+		validationErr.Add("starts_at", "is required")
+	}
+
+	if !t.EndsAt.Valid {
+		validationErr.Add("ends_at", "is required")
+	}
+
+	if t.StartsAt.Valid && t.EndsAt.Valid && !t.StartsAt.Time.Before(t.EndsAt.Time) {
+		validationErr.Add("ends_at", "must be after starts_at")
+	}
+
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	args := pgx.NamedArgs{
+		"coach_id":  coachId,
+		"starts_at": t.StartsAt,
+		"ends_at":   t.EndsAt,
+		"reason":    t.Reason,
+	}
+
+	const query = `
+		INSERT INTO coach_time_off (
+			coach_id,
+			starts_at,
+			ends_at,
+			reason
+		)
+
+		VALUES (
+			@coach_id,
+			@starts_at,
+			@ends_at,
+			@reason
+		)
+
+		RETURNING *;
+	`
+
+	var out models.CoachTimeOff
+	if err := pgxscan.Get(ctx, conn, &out, query, args); err != nil {
+		return nil, coachNotFound(err)
+	}
+
+	return &out, nil
+}
+
+func DeleteCoachTimeOffData(ctx context.Context, conn IDBConn, coachId, id string) (int64, error) {
+	cmdTag, err := conn.Exec(
+		ctx,
+		"DELETE FROM coach_time_off WHERE id=$1 AND coach_id=$2",
+		id,
+		coachId,
+	)
+
+	if err != nil {
+		log.Println("[API] Error deleting coach time off:", err)
+		return 0, err
+	}
+
+	return cmdTag.RowsAffected(), err
+}
+
+// LoadCoachBusyIntervals returns the parts of window (on the local date in
+// loc) the coach can't teach: outside their weekly windows and during time
+// off. Coaches with no weekly windows at all aren't restricted beyond their
+// time off, so lessons keep working for coaches nobody has set up yet.
+func LoadCoachBusyIntervals(ctx context.Context, conn IDBConn, loc *time.Location, coachId pgtype.UUID, onDate pgtype.Date, window Interval) ([]Interval, error) {
+	busy := make([]Interval, 0)
+
+	hasAvailability, err := HasCoachAvailability(ctx, conn, coachId)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasAvailability {
+		windows, err := LoadCoachAvailabilityOnDate(ctx, conn, coachId, onDate)
+		if err != nil {
+			return nil, err
+		}
+
+		working := make([]Interval, 0, len(windows))
+		for _, availability := range windows {
+			working = append(working, Interval{Start: availability.StartTime.On(onDate, loc), End: availability.EndTime.On(onDate, loc)})
+		}
+
+		busy = append(busy, complementIntervals(window, working)...)
+	}
+
+	timeOff, err := LoadCoachTimeOffData(
+		ctx,
+		conn,
+		coachId.String(),
+		pgtype.Timestamptz{Time: window.Start, Valid: true},
+		pgtype.Timestamptz{Time: window.End, Valid: true},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range timeOff {
+		busy = append(busy, Interval{Start: entry.StartsAt.Time, End: entry.EndsAt.Time})
+	}
+
+	return busy, nil
+}
+
+// CheckCoachAvailability makes sure [start, end) sits inside one of the
+// coach's working windows for that local date and clear of their time off.
+func CheckCoachAvailability(ctx context.Context, conn IDBConn, loc *time.Location, coachId pgtype.UUID, start, end pgtype.Timestamptz) error {
+	violations := &RuleViolationError{}
+	onDate := models.LocalDate(start.Time, loc)
+	requested := Interval{Start: start.Time, End: end.Time}
+
+	hasAvailability, err := HasCoachAvailability(ctx, conn, coachId)
+	if err != nil {
+		return err
+	}
+
+	if hasAvailability {
+		windows, err := LoadCoachAvailabilityOnDate(ctx, conn, coachId, onDate)
+		if err != nil {
+			return err
+		}
+
+		inWindow := false
+		for _, window := range windows {
+			if !requested.Start.Before(window.StartTime.On(onDate, loc)) && !requested.End.After(window.EndTime.On(onDate, loc)) {
+				inWindow = true
+				break
+			}
+		}
+
+		if !inWindow {
+			violations.Add(RuleCoachUnavailable, fmt.Sprintf(
+				"The coach is not scheduled to work %s-%s on %s.",
+				requested.Start.In(loc).Format("15:04"),
+				requested.End.In(loc).Format("15:04"),
+				requested.Start.In(loc).Format(scheduleDateLayout),
+			))
+		}
+	}
+
+	timeOff, err := LoadCoachTimeOffData(ctx, conn, coachId.String(), start, end)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range timeOff {
+		reason := ""
+		if entry.Reason != nil && *entry.Reason != "" {
+			reason = " (" + *entry.Reason + ")"
+		}
+
+		message := fmt.Sprintf(
+			"The coach is off from %s to %s%s.",
+			entry.StartsAt.Time.In(loc).Format("Jan 2 15:04"),
+			entry.EndsAt.Time.In(loc).Format("Jan 2 15:04"),
+			reason,
+		)
+
+		violations.Add(RuleCoachTimeOff, message)
+	}
+
+	return violations.Err()
+}
+
+func applyCoachAvailabilityUpdates(validationErr *ValidationError, availability *models.CoachAvailability, updates models.CoachAvailabilityUpdates) {
+	if updates.Dow != nil {
+		ValidateDow(validationErr, "dow", *updates.Dow)
+		availability.Dow = *updates.Dow
+	}
+
+	if updates.StartTime != nil {
+		parsed, err := models.ParseTimeOfDay(*updates.StartTime)
+		if err != nil {
+			validationErr.Add("start_time", "must be a time formatted as HH:MM")
+		} else {
+			availability.StartTime = parsed
+		}
+	}
+
+	if updates.EndTime != nil {
+		parsed, err := models.ParseTimeOfDay(*updates.EndTime)
+		if err != nil {
+			validationErr.Add("end_time", "must be a time formatted as HH:MM")
+		} else {
+			availability.EndTime = parsed
+		}
+	}
+
+	if updates.EffectiveFrom != nil {
+		parsed, err := models.ParseDate(*updates.EffectiveFrom)
+		if err != nil {
+			validationErr.Add("effective_from", "must be a date formatted as YYYY-MM-DD")
+		} else {
+			availability.EffectiveFrom = parsed
+		}
+	}
+
+	// an empty string clears the end date
+	if updates.EffectiveTo != nil {
+		if *updates.EffectiveTo == "" {
+			availability.EffectiveTo = pgtype.Date{}
+		} else if parsed, err := models.ParseDate(*updates.EffectiveTo); err != nil {
+			validationErr.Add("effective_to", "must be a date formatted as YYYY-MM-DD")
+		} else {
+			availability.EffectiveTo = parsed
+		}
+	}
+
+	if !availability.StartTime.Valid {
+		validationErr.Add("start_time", "is required")
+	}
+
+	if !availability.EndTime.Valid {
+		validationErr.Add("end_time", "is required")
+	}
+
+	if availability.StartTime.Valid && availability.EndTime.Valid && availability.StartTime.Microseconds >= availability.EndTime.Microseconds {
+		validationErr.Add("end_time", "must be after start_time")
+	}
+
+	if availability.EffectiveFrom.Valid && availability.EffectiveTo.Valid && availability.EffectiveTo.Time.Before(availability.EffectiveFrom.Time) {
+		validationErr.Add("effective_to", "must not be before effective_from")
+	}
+}
+
+// subtractInterval cuts cut out of every interval, splitting where needed.
+func subtractInterval(intervals []Interval, cut Interval) []Interval {
+	out := make([]Interval, 0, len(intervals)+1)
+
+	for _, interval := range intervals {
+		if !interval.Overlaps(cut) {
+			out = append(out, interval)
+			continue
+		}
+
+		if interval.Start.Before(cut.Start) {
+			out = append(out, Interval{Start: interval.Start, End: cut.Start})
+		}
+
+		if cut.End.Before(interval.End) {
+			out = append(out, Interval{Start: cut.End, End: interval.End})
+		}
+	}
+
+	return out
+}
+
+// complementIntervals returns the parts of window not covered by free, so
+// they can be handed to FindOpenSlots as busy time.
+func complementIntervals(window Interval, free []Interval) []Interval {
+	busy := []Interval{window}
+	for _, interval := range free {
+		busy = subtractInterval(busy, interval)
+	}
+
+	return busy
+}
+
+// coachNotFound turns the coach_id foreign key violation into ErrCoachNotFound.
+func coachNotFound(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return ErrCoachNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,224 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const coachAvailabilityOnDateQuery = `
+		SELECT * FROM coach_availability
+		WHERE coach_id = @coach_id
+			AND dow = @dow
+			AND effective_from <= @on_date
+			AND (effective_to IS NULL OR effective_to >= @on_date)
+		ORDER BY start_time ASC
+	`
+
+const coachTimeOffQuery = `
+		SELECT * FROM coach_time_off
+		WHERE coach_id = @coach_id
+			AND (@from_time::timestamptz IS NULL OR ends_at > @from_time::timestamptz)
+			AND (@to_time::timestamptz IS NULL OR starts_at < @to_time::timestamptz)
+		ORDER BY starts_at ASC
+	`
+
+// expectTuesdayAfternoons queues a coach who works 15:00-18:00 on tuesdays.
+func expectTuesdayAfternoons(mockConn pgxmock.PgxConnIface, coachId pgtype.UUID, onDate pgtype.Date) {
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM coach_availability WHERE coach_id = $1)`)).
+		WithArgs(coachId).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(coachAvailabilityOnDateQuery)).WithArgs(pgx.NamedArgs{
+		"coach_id": coachId,
+		"dow":      int32(2),
+		"on_date":  onDate,
+	}).WillReturnRows(
+		pgxmock.NewRows([]string{"coach_id", "dow", "start_time", "end_time"}).
+			AddRow(coachId, int32(2), models.NewTimeOfDay(15, 0), models.NewTimeOfDay(18, 0)),
+	)
+}
+
+func Test_InsertCoachAvailabilityData_Invalid(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	dow := int32(9)
+	startTime := "18:00"
+	endTime := "15:00"
+
+	// exercise
+	result, err := InsertCoachAvailabilityData(context.Background(), mockConn, uuid.NewString(), models.CoachAvailabilityUpdates{
+		Dow:       &dow,
+		StartTime: &startTime,
+		EndTime:   &endTime,
+	})
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	if _, ok := validationErr.Fields["dow"]; !ok {
+		t.Fatal("expected dow field error, got", validationErr.Fields)
+	}
+
+	if _, ok := validationErr.Fields["end_time"]; !ok {
+		t.Fatal("expected end_time field error, got", validationErr.Fields)
+	}
+
+	if result != nil {
+		t.Fatal("expected no result, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_InsertCoachTimeOffData_CoachNotFound(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	coachId := uuid.NewString()
+	startsAt := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	timeOff := models.CoachTimeOff{
+		StartsAt: pgtype.Timestamptz{Time: startsAt, Valid: true},
+		EndsAt:   pgtype.Timestamptz{Time: startsAt.Add(24 * time.Hour), Valid: true},
+	}
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`INSERT INTO coach_time_off`)).
+		WithArgs(pgx.NamedArgs{
+			"coach_id":  coachId,
+			"starts_at": timeOff.StartsAt,
+			"ends_at":   timeOff.EndsAt,
+			"reason":    timeOff.Reason,
+		}).
+		WillReturnError(&pgconn.PgError{Code: "23503"})
+
+	// exercise
+	result, err := InsertCoachTimeOffData(context.Background(), mockConn, coachId, timeOff)
+
+	// verify
+	if !errors.Is(err, ErrCoachNotFound) {
+		t.Fatal("expected ErrCoachNotFound, got", err)
+	}
+
+	if result != nil {
+		t.Fatal("expected no result, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_CheckCoachAvailability_OutsideWindow(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	loc := indianapolis(t)
+	coachId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	onDate, _ := models.ParseDate("2025-12-02")
+	start := pgtype.Timestamptz{Time: time.Date(2025, 12, 2, 17, 30, 0, 0, loc), Valid: true}
+	end := pgtype.Timestamptz{Time: time.Date(2025, 12, 2, 18, 30, 0, 0, loc), Valid: true}
+
+	expectTuesdayAfternoons(mockConn, coachId, onDate)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(coachTimeOffQuery)).WithArgs(pgx.NamedArgs{
+		"coach_id":  coachId.String(),
+		"from_time": start,
+		"to_time":   end,
+	}).WillReturnRows(pgxmock.NewRows([]string{"id"}))
+
+	// exercise
+	err := CheckCoachAvailability(context.Background(), mockConn, loc, coachId, start, end)
+
+	// verify
+	var violationErr *RuleViolationError
+	if !errors.As(err, &violationErr) {
+		t.Fatal("expected rule violation error, got", err)
+	}
+
+	if len(violationErr.Violations) != 1 || violationErr.Violations[0].Rule != RuleCoachUnavailable {
+		t.Fatal("expected a coach unavailable violation, got", violationErr.Violations)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_CheckCoachAvailability_TimeOff(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	loc := indianapolis(t)
+	coachId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	onDate, _ := models.ParseDate("2025-12-02")
+	start := pgtype.Timestamptz{Time: time.Date(2025, 12, 2, 15, 0, 0, 0, loc), Valid: true}
+	end := pgtype.Timestamptz{Time: time.Date(2025, 12, 2, 16, 0, 0, 0, loc), Valid: true}
+	reason := "Dentist"
+
+	expectTuesdayAfternoons(mockConn, coachId, onDate)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(coachTimeOffQuery)).WithArgs(pgx.NamedArgs{
+		"coach_id":  coachId.String(),
+		"from_time": start,
+		"to_time":   end,
+	}).WillReturnRows(
+		pgxmock.NewRows([]string{"coach_id", "starts_at", "ends_at", "reason"}).
+			AddRow(coachId, start, end, &reason),
+	)
+
+	// exercise
+	err := CheckCoachAvailability(context.Background(), mockConn, loc, coachId, start, end)
+
+	// verify
+	var violationErr *RuleViolationError
+	if !errors.As(err, &violationErr) {
+		t.Fatal("expected rule violation error, got", err)
+	}
+
+	if len(violationErr.Violations) != 1 || violationErr.Violations[0].Rule != RuleCoachTimeOff {
+		t.Fatal("expected a time off violation, got", violationErr.Violations)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_SubtractInterval(t *testing.T) {
+	// setup
+	start := time.Date(2025, 12, 2, 15, 0, 0, 0, time.UTC)
+	intervals := []Interval{{Start: start, End: start.Add(3 * time.Hour)}}
+	cut := Interval{Start: start.Add(time.Hour), End: start.Add(2 * time.Hour)}
+
+	// exercise
+	result := subtractInterval(intervals, cut)
+
+	// verify
+	if len(result) != 2 {
+		t.Fatal("expected the interval to be split in 2, got", result)
+	}
+
+	if !result[0].End.Equal(cut.Start) || !result[1].Start.Equal(cut.End) {
+		t.Fatal("expected the cut to be removed, got", result)
+	}
+}
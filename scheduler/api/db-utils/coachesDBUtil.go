@@ -6,6 +6,7 @@ import (
 
 	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
 )
 
@@ -23,13 +24,39 @@ func LoadCoachesData(ctx context.Context, conn IDBConn) ([]models.Coach, error)
 	return coaches, nil
 }
 
+func LoadCoachById(ctx context.Context, conn IDBConn, id pgtype.UUID) (*models.Coach, error) {
+	var coach models.Coach
+
+	err := pgxscan.Get(ctx, conn, &coach, `SELECT * FROM coaches WHERE id=$1`, id)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find coach with id:", id.String())
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &coach, nil
+}
+
 func InsertCoachData(ctx context.Context, conn IDBConn, c models.Coach) (*models.Coach, error) {
+	validationErr := &ValidationError{}
+	validateTurnoverBuffer(validationErr, c.TurnoverBufferMinutes)
+
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
 	args := pgx.NamedArgs{
-		"first_name":  c.FirstName,
-		"last_name":   c.LastName,
-		"phone":       c.Phone,
-		"email":       c.Email,
-		"specialties": c.Specialties,
+		"first_name":              c.FirstName,
+		"last_name":               c.LastName,
+		"phone":                   c.Phone,
+		"email":                   c.Email,
+		"specialties":             c.Specialties,
+		"turnover_buffer_minutes": c.TurnoverBufferMinutes,
 	}
 
 	const query = `
@@ -38,7 +65,8 @@ func InsertCoachData(ctx context.Context, conn IDBConn, c models.Coach) (*models
 			last_name,
 			phone,
 			email,
-			specialties
+			specialties,
+			turnover_buffer_minutes
 		)
 
 		VALUES (
@@ -46,7 +74,8 @@ func InsertCoachData(ctx context.Context, conn IDBConn, c models.Coach) (*models
 			@last_name,
 			@phone,
 			@email,
-			@specialties::coach_specialty[]
+			@specialties::coach_specialty[],
+			@turnover_buffer_minutes
 		)
 
 		RETURNING *;
@@ -61,17 +90,27 @@ func InsertCoachData(ctx context.Context, conn IDBConn, c models.Coach) (*models
 }
 
 func UpdateCoachData(ctx context.Context, conn IDBConn, id string, updates models.CoachUpdates) (*models.Coach, error) {
+	if updates.TurnoverBufferMinutes != nil {
+		validationErr := &ValidationError{}
+		validateTurnoverBuffer(validationErr, *updates.TurnoverBufferMinutes)
+
+		if err := validationErr.Err(); err != nil {
+			return nil, err
+		}
+	}
+
 	var updatedCoach models.Coach
 
 	// send update to the db
 	args := pgx.NamedArgs{
-		"id":          id,
-		"first_name":  updates.FirstName,
-		"last_name":   updates.LastName,
-		"phone":       updates.Phone,
-		"email":       updates.Email,
-		"is_active":   updates.IsActive,
-		"specialties": updates.Specialties,
+		"id":                      id,
+		"first_name":              updates.FirstName,
+		"last_name":               updates.LastName,
+		"phone":                   updates.Phone,
+		"email":                   updates.Email,
+		"is_active":               updates.IsActive,
+		"specialties":             updates.Specialties,
+		"turnover_buffer_minutes": updates.TurnoverBufferMinutes,
 	}
 
 	query := `
@@ -83,6 +122,7 @@ func UpdateCoachData(ctx context.Context, conn IDBConn, id string, updates model
 				email = COALESCE(@email, email),
 				is_active = COALESCE(@is_active, is_active),
 				specialties = COALESCE(@specialties, specialties),
+				turnover_buffer_minutes = COALESCE(@turnover_buffer_minutes, turnover_buffer_minutes),
 				updated_at = now()
 			WHERE id = @id
 			RETURNING *
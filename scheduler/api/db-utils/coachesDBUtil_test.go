@@ -81,7 +81,8 @@ func Test_InsertCoachData(t *testing.T) {
 			last_name,
 			phone,
 			email,
-			specialties
+			specialties,
+			turnover_buffer_minutes
 		)
 
 		VALUES (
@@ -89,7 +90,8 @@ func Test_InsertCoachData(t *testing.T) {
 			@last_name,
 			@phone,
 			@email,
-			@specialties::coach_specialty[]
+			@specialties::coach_specialty[],
+			@turnover_buffer_minutes
 		)
 
 		RETURNING *;
@@ -98,12 +100,13 @@ func Test_InsertCoachData(t *testing.T) {
 	pgU := pgtype.UUID{Bytes: [16]byte(u), Valid: true}
 	
 	testCoach := models.Coach{
-		Id:          pgU,
-		FirstName:   "John",
-		LastName:    "Doe",
-		Email:       nil,
-		Phone:       "1112223333",
-		Specialties: []string{models.SpecialtyHitting},
+		Id:                    pgU,
+		FirstName:             "John",
+		LastName:              "Doe",
+		Email:                 nil,
+		Phone:                 "1112223333",
+		Specialties:           []string{models.SpecialtyHitting},
+		TurnoverBufferMinutes: models.DefaultCoachTurnoverMinutes,
 	}
 	
 	rows := pgxmock.NewRows([]string{"id", "first_name", "last_name", "email", "phone", "specialties"}).
@@ -122,6 +125,7 @@ func Test_InsertCoachData(t *testing.T) {
 		testCoach.Phone,
 		testCoach.Email,
 		testCoach.Specialties,
+		testCoach.TurnoverBufferMinutes,
 	).WillReturnRows(rows)
 	
 	// exercise
@@ -156,7 +160,8 @@ func Test_InsertCoachData_Error(t *testing.T) {
 			last_name,
 			phone,
 			email,
-			specialties
+			specialties,
+			turnover_buffer_minutes
 		)
 
 		VALUES (
@@ -164,7 +169,8 @@ func Test_InsertCoachData_Error(t *testing.T) {
 			@last_name,
 			@phone,
 			@email,
-			@specialties::coach_specialty[]
+			@specialties::coach_specialty[],
+			@turnover_buffer_minutes
 		)
 
 		RETURNING *;
@@ -173,12 +179,13 @@ func Test_InsertCoachData_Error(t *testing.T) {
 	pgU := pgtype.UUID{Bytes: [16]byte(u), Valid: true}
 	
 	testCoach := models.Coach{
-		Id:          pgU,
-		FirstName:   "John",
-		LastName:    "Doe",
-		Email:       nil,
-		Phone:       "1112223333",
-		Specialties: []string{models.SpecialtyHitting},
+		Id:                    pgU,
+		FirstName:             "John",
+		LastName:              "Doe",
+		Email:                 nil,
+		Phone:                 "1112223333",
+		Specialties:           []string{models.SpecialtyHitting},
+		TurnoverBufferMinutes: models.DefaultCoachTurnoverMinutes,
 	}
 	
 	mockConn.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(
@@ -187,6 +194,7 @@ func Test_InsertCoachData_Error(t *testing.T) {
 		testCoach.Phone,
 		testCoach.Email,
 		testCoach.Specialties,
+		testCoach.TurnoverBufferMinutes,
 	).WillReturnError(errors.New("test error"))
 	
 	// exercise
@@ -220,6 +228,7 @@ func Test_UpdateCoachData(t *testing.T) {
 				email = COALESCE(@email, email),
 				is_active = COALESCE(@is_active, is_active),
 				specialties = COALESCE(@specialties, specialties),
+				turnover_buffer_minutes = COALESCE(@turnover_buffer_minutes, turnover_buffer_minutes),
 				updated_at = now()
 			WHERE id = @id
 			RETURNING *
@@ -254,13 +263,14 @@ func Test_UpdateCoachData(t *testing.T) {
 		)
 	
 	mockConn.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(pgx.NamedArgs{
-		"first_name":  testCoachUpdates.FirstName,
-		"last_name":   testCoachUpdates.LastName,
-		"phone":       testCoachUpdates.Phone,
-		"email":       testCoachUpdates.Email,
-		"is_active":   testCoachUpdates.IsActive,
-		"specialties": testCoachUpdates.Specialties,
-		"id":          pgUUID.String(),
+		"first_name":              testCoachUpdates.FirstName,
+		"last_name":               testCoachUpdates.LastName,
+		"phone":                   testCoachUpdates.Phone,
+		"email":                   testCoachUpdates.Email,
+		"is_active":               testCoachUpdates.IsActive,
+		"specialties":             testCoachUpdates.Specialties,
+		"turnover_buffer_minutes": testCoachUpdates.TurnoverBufferMinutes,
+		"id":                      pgUUID.String(),
 	}).WillReturnRows(rows)
 	
 	// exercise
@@ -298,6 +308,7 @@ func Test_UpdateCoachData_Error(t *testing.T) {
 				email = COALESCE(@email, email),
 				is_active = COALESCE(@is_active, is_active),
 				specialties = COALESCE(@specialties, specialties),
+				turnover_buffer_minutes = COALESCE(@turnover_buffer_minutes, turnover_buffer_minutes),
 				updated_at = now()
 			WHERE id = @id
 			RETURNING *
@@ -321,13 +332,14 @@ func Test_UpdateCoachData_Error(t *testing.T) {
 	}
 	
 	mockConn.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(pgx.NamedArgs{
-		"first_name":  testCoachUpdates.FirstName,
-		"last_name":   testCoachUpdates.LastName,
-		"phone":       testCoachUpdates.Phone,
-		"email":       testCoachUpdates.Email,
-		"is_active":   testCoachUpdates.IsActive,
-		"specialties": testCoachUpdates.Specialties,
-		"id":          pgUUID.String(),
+		"first_name":              testCoachUpdates.FirstName,
+		"last_name":               testCoachUpdates.LastName,
+		"phone":                   testCoachUpdates.Phone,
+		"email":                   testCoachUpdates.Email,
+		"is_active":               testCoachUpdates.IsActive,
+		"specialties":             testCoachUpdates.Specialties,
+		"turnover_buffer_minutes": testCoachUpdates.TurnoverBufferMinutes,
+		"id":                      pgUUID.String(),
 	}).WillReturnError(errors.New("test error"))
 	
 	// exercise
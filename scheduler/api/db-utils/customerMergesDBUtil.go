@@ -0,0 +1,390 @@
+package db_utils
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// DefaultDuplicateMinScore is the score a pair needs to be reported when the
+// caller doesn't pick one, a shared phone number with a similar name gets there.
+const DefaultDuplicateMinScore = 0.5
+
+// FindCustomerDuplicates scores the pairs of customers sharing a normalized
+// phone number or email, or with names pg_trgm finds similar: 0.45 for the
+// phone, 0.3 for the email and up to 0.25 for the name similarity. Pairs
+// scoring at least minScore come back best first, at most limit of them.
+// Only customers are compared, reservations never linked to one are scored
+// by FindUnlinkedReservationMatches.
+func FindCustomerDuplicates(ctx context.Context, conn IDBConn, minScore float64, limit int32) ([]models.CustomerDuplicate, error) {
+	var pairs []struct {
+		CustomerId     pgtype.UUID `db:"customer_id"`
+		DuplicateId    pgtype.UUID `db:"duplicate_id"`
+		SamePhone      bool        `db:"same_phone"`
+		SameEmail      bool        `db:"same_email"`
+		NameSimilarity float64     `db:"name_similarity"`
+		Score          float64     `db:"score"`
+	}
+
+	args := pgx.NamedArgs{
+		"min_score": minScore,
+		"limit":     limit,
+	}
+
+	query := `
+		SELECT * FROM (
+			SELECT
+				p.*,
+				(CASE WHEN p.same_phone THEN 0.45 ELSE 0 END
+					+ CASE WHEN p.same_email THEN 0.3 ELSE 0 END
+					+ 0.25 * p.name_similarity)::float8 AS score
+			FROM (
+				SELECT
+					a.id AS customer_id,
+					b.id AS duplicate_id,
+					a.phone_normalized = b.phone_normalized AS same_phone,
+					COALESCE(a.email_normalized = b.email_normalized, false) AS same_email,
+					similarity(lower(a.first_name || ' ' || a.last_name), lower(b.first_name || ' ' || b.last_name))::float8 AS name_similarity
+				FROM customers a
+				JOIN customers b ON (a.created_at, a.id) < (b.created_at, b.id)
+					AND (a.phone_normalized = b.phone_normalized
+						OR a.email_normalized = b.email_normalized
+						OR lower(a.first_name || ' ' || a.last_name) % lower(b.first_name || ' ' || b.last_name))
+			) p
+		) scored
+		WHERE score >= @min_score
+		ORDER BY score DESC, customer_id ASC
+		LIMIT @limit
+	`
+
+	err := pgxscan.Select(ctx, conn, &pairs, query, args)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	duplicates := make([]models.CustomerDuplicate, 0, len(pairs))
+	if len(pairs) == 0 {
+		return duplicates, nil
+	}
+
+	ids := make([]pgtype.UUID, 0, 2*len(pairs))
+	for _, pair := range pairs {
+		ids = append(ids, pair.CustomerId, pair.DuplicateId)
+	}
+
+	var customers []models.Customer
+	err = pgxscan.Select(ctx, conn, &customers, `SELECT * FROM customers WHERE id = ANY ($1)`, ids)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	byId := make(map[pgtype.UUID]models.Customer, len(customers))
+	for _, customer := range customers {
+		byId[customer.Id] = customer
+	}
+
+	for _, pair := range pairs {
+		duplicates = append(duplicates, models.CustomerDuplicate{
+			Customer:       byId[pair.CustomerId],
+			Duplicate:      byId[pair.DuplicateId],
+			Score:          pair.Score,
+			SamePhone:      pair.SamePhone,
+			SameEmail:      pair.SameEmail,
+			NameSimilarity: pair.NameSimilarity,
+		})
+	}
+
+	return duplicates, nil
+}
+
+// FindUnlinkedReservationMatches scores the reservations without a customer
+// against the customers, the way FindCustomerDuplicates scores customer
+// pairs, e.g. bookings the backfill left alone because several customers
+// share their phone number.
+func FindUnlinkedReservationMatches(ctx context.Context, conn IDBConn, minScore float64, limit int32) ([]models.CustomerReservationMatch, error) {
+	var pairs []struct {
+		ReservationId  pgtype.UUID `db:"reservation_id"`
+		CustomerId     pgtype.UUID `db:"customer_id"`
+		SamePhone      bool        `db:"same_phone"`
+		SameEmail      bool        `db:"same_email"`
+		NameSimilarity float64     `db:"name_similarity"`
+		Score          float64     `db:"score"`
+	}
+
+	args := pgx.NamedArgs{
+		"min_score": minScore,
+		"limit":     limit,
+	}
+
+	query := `
+		SELECT * FROM (
+			SELECT
+				p.*,
+				(CASE WHEN p.same_phone THEN 0.45 ELSE 0 END
+					+ CASE WHEN p.same_email THEN 0.3 ELSE 0 END
+					+ 0.25 * p.name_similarity)::float8 AS score
+			FROM (
+				SELECT
+					r.id AS reservation_id,
+					c.id AS customer_id,
+					COALESCE(normalize_phone(r.customer_phone) = c.phone_normalized, false) AS same_phone,
+					COALESCE(normalize_email(r.customer_email) = c.email_normalized, false) AS same_email,
+					similarity(lower(r.customer_first_name || ' ' || r.customer_last_name), lower(c.first_name || ' ' || c.last_name))::float8 AS name_similarity
+				FROM reservations r
+				JOIN customers c ON normalize_phone(r.customer_phone) = c.phone_normalized
+					OR normalize_email(r.customer_email) = c.email_normalized
+					OR lower(r.customer_first_name || ' ' || r.customer_last_name) % lower(c.first_name || ' ' || c.last_name)
+				WHERE r.customer_id IS NULL
+			) p
+		) scored
+		WHERE score >= @min_score
+		ORDER BY score DESC, reservation_id ASC
+		LIMIT @limit
+	`
+
+	err := pgxscan.Select(ctx, conn, &pairs, query, args)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	matches := make([]models.CustomerReservationMatch, 0, len(pairs))
+	if len(pairs) == 0 {
+		return matches, nil
+	}
+
+	reservationIds := make([]pgtype.UUID, 0, len(pairs))
+	customerIds := make([]pgtype.UUID, 0, len(pairs))
+	for _, pair := range pairs {
+		reservationIds = append(reservationIds, pair.ReservationId)
+		customerIds = append(customerIds, pair.CustomerId)
+	}
+
+	var reservations []models.Reservation
+	err = pgxscan.Select(ctx, conn, &reservations, `SELECT * FROM reservations WHERE id = ANY ($1)`, reservationIds)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	var customers []models.Customer
+	err = pgxscan.Select(ctx, conn, &customers, `SELECT * FROM customers WHERE id = ANY ($1)`, customerIds)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	reservationsById := make(map[pgtype.UUID]models.Reservation, len(reservations))
+	for _, reservation := range reservations {
+		reservationsById[reservation.Id] = reservation
+	}
+
+	customersById := make(map[pgtype.UUID]models.Customer, len(customers))
+	for _, customer := range customers {
+		customersById[customer.Id] = customer
+	}
+
+	for _, pair := range pairs {
+		matches = append(matches, models.CustomerReservationMatch{
+			Reservation:    reservationsById[pair.ReservationId],
+			Customer:       customersById[pair.CustomerId],
+			Score:          pair.Score,
+			SamePhone:      pair.SamePhone,
+			SameEmail:      pair.SameEmail,
+			NameSimilarity: pair.NameSimilarity,
+		})
+	}
+
+	return matches, nil
+}
+
+// MergeCustomers merges the request's duplicate into the surviving customer
+// in one transaction: its athletes (their reservations follow through the
+// foreign key), its other reservations and its merge history move over, the
+// survivor picks up an email and notes it lacks, the duplicate is recorded
+// in customer_merges and deleted. Nil when the survivor doesn't exist.
+func MergeCustomers(ctx context.Context, conn IDBTxConn, survivorId string, request models.CustomerMergeRequest) (*models.CustomerMergeResult, error) {
+	validationErr := &ValidationError{}
+	if !request.DuplicateId.Valid {
+		validationErr.Add("duplicate_id", "is required")
+	} else if request.DuplicateId.String() == survivorId {
+		validationErr.Add("duplicate_id", "cannot be the customer itself")
+	}
+
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var locked []models.Customer
+
+	err = pgxscan.Select(ctx, tx, &locked, `SELECT * FROM customers WHERE id = ANY ($1) ORDER BY id FOR UPDATE`, []string{survivorId, request.DuplicateId.String()})
+	if err != nil {
+		log.Println("[API] Error locking customers:", err)
+		return nil, err
+	}
+
+	var survivor, duplicate *models.Customer
+	for i := range locked {
+		if locked[i].Id == request.DuplicateId {
+			duplicate = &locked[i]
+		} else {
+			survivor = &locked[i]
+		}
+	}
+
+	if survivor == nil {
+		log.Println("[API] No customer found with id:", survivorId)
+		return nil, nil
+	}
+
+	if duplicate == nil {
+		validationErr.Add("duplicate_id", "does not exist")
+		return nil, validationErr
+	}
+
+	args := pgx.NamedArgs{
+		"survivor_id":  survivor.Id,
+		"duplicate_id": duplicate.Id,
+	}
+
+	var reservationsMoved int32
+	err = pgxscan.Get(ctx, tx, &reservationsMoved, `SELECT COUNT(*)::int FROM reservations WHERE customer_id = @duplicate_id`, args)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	athletesTag, err := tx.Exec(ctx, `UPDATE athletes SET customer_id = @survivor_id, updated_at = now() WHERE customer_id = @duplicate_id`, args)
+	if err != nil {
+		log.Println("[API] Error moving athletes:", err)
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE reservations SET customer_id = @survivor_id, updated_at = now() WHERE customer_id = @duplicate_id`, args)
+	if err != nil {
+		log.Println("[API] Error moving reservations:", err)
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE customer_merges SET surviving_customer_id = @survivor_id WHERE surviving_customer_id = @duplicate_id`, args)
+	if err != nil {
+		log.Println("[API] Error moving merge history:", err)
+		return nil, err
+	}
+
+	mergeArgs := pgx.NamedArgs{
+		"survivor_id":        survivor.Id,
+		"duplicate_id":       duplicate.Id,
+		"reservations_moved": reservationsMoved,
+		"athletes_moved":     int32(athletesTag.RowsAffected()),
+		"merged_by":          request.MergedBy,
+		"reason":             request.Reason,
+	}
+
+	mergeQuery := `
+		INSERT INTO customer_merges (
+			surviving_customer_id,
+			merged_customer_id,
+			merged_customer,
+			reservations_moved,
+			athletes_moved,
+			merged_by,
+			reason
+		)
+
+		SELECT
+			@survivor_id,
+			c.id,
+			to_jsonb(c),
+			@reservations_moved,
+			@athletes_moved,
+			@merged_by,
+			@reason
+		FROM customers c
+		WHERE c.id = @duplicate_id
+
+		RETURNING *;
+	`
+
+	var merge models.CustomerMerge
+	if err := pgxscan.Get(ctx, tx, &merge, mergeQuery, mergeArgs); err != nil {
+		log.Println("[API] Error recording customer merge:", err)
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM customers WHERE id=$1`, duplicate.Id); err != nil {
+		log.Println("[API] Error deleting merged customer:", err)
+		return nil, err
+	}
+
+	email, notes := survivor.Email, mergedNotes(survivor.Notes, duplicate.Notes)
+	if email == nil {
+		email = duplicate.Email
+	}
+
+	survivorArgs := pgx.NamedArgs{
+		"id":    survivor.Id,
+		"email": email,
+		"notes": notes,
+	}
+
+	var out models.Customer
+	err = pgxscan.Get(ctx, tx, &out, `UPDATE customers SET email = @email, notes = @notes, updated_at = now() WHERE id = @id RETURNING *`, survivorArgs)
+	if err != nil {
+		log.Println("[API] Error updating customer:", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing customer merge:", err)
+		return nil, err
+	}
+
+	return &models.CustomerMergeResult{Customer: out, Merge: merge}, nil
+}
+
+// LoadCustomerMerges lists the customers merged into a customer, latest first.
+func LoadCustomerMerges(ctx context.Context, conn IDBConn, customerId string) ([]models.CustomerMerge, error) {
+	merges := make([]models.CustomerMerge, 0)
+
+	query := `SELECT * FROM customer_merges WHERE surviving_customer_id = $1 ORDER BY merged_at DESC`
+
+	err := pgxscan.Select(ctx, conn, &merges, query, customerId)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return merges, nil
+}
+
+// mergedNotes keeps both customers' notes, the survivor's first.
+func mergedNotes(survivor, duplicate *string) *string {
+	parts := make([]string, 0, 2)
+	for _, notes := range []*string{survivor, duplicate} {
+		if notes != nil && strings.TrimSpace(*notes) != "" {
+			parts = append(parts, strings.TrimSpace(*notes))
+		}
+	}
+
+	if len(parts) == 0 {
+		return survivor
+	}
+
+	joined := strings.Join(parts, "\n")
+	return &joined
+}
@@ -0,0 +1,231 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const lockCustomersQuery = `SELECT * FROM customers WHERE id = ANY ($1) ORDER BY id FOR UPDATE`
+
+func Test_FindCustomerDuplicates(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	customerId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	duplicateId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockConn.ExpectQuery(regexp.QuoteMeta("WHERE score >= @min_score")).WithArgs(DefaultDuplicateMinScore, int32(50)).WillReturnRows(
+		pgxmock.NewRows([]string{"customer_id", "duplicate_id", "same_phone", "same_email", "name_similarity", "score"}).
+			AddRow(customerId, duplicateId, true, false, 0.6, 0.6),
+	)
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM customers WHERE id = ANY ($1)`)).WithArgs([]pgtype.UUID{customerId, duplicateId}).WillReturnRows(
+		customerRows().
+			AddRow(customerId, "John", "Smith", "(317) 5551234", nil, "3175551234").
+			AddRow(duplicateId, "Jon", "Smith", "317-555-1234", nil, "3175551234"),
+	)
+
+	// exercise
+	duplicates, err := FindCustomerDuplicates(context.Background(), mockConn, DefaultDuplicateMinScore, 50)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(duplicates) != 1 {
+		t.Fatal("expected one duplicate pair, got", duplicates)
+	}
+
+	pair := duplicates[0]
+	if pair.Customer.FirstName != "John" || pair.Duplicate.FirstName != "Jon" || !pair.SamePhone || pair.Score != 0.6 {
+		t.Fatal("expected John and Jon Smith sharing a phone number, got", pair)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_FindUnlinkedReservationMatches(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	reservationId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	customerId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockConn.ExpectQuery(regexp.QuoteMeta("WHERE r.customer_id IS NULL")).WithArgs(DefaultDuplicateMinScore, int32(50)).WillReturnRows(
+		pgxmock.NewRows([]string{"reservation_id", "customer_id", "same_phone", "same_email", "name_similarity", "score"}).
+			AddRow(reservationId, customerId, true, false, 0.6, 0.6),
+	)
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM reservations WHERE id = ANY ($1)`)).WithArgs([]pgtype.UUID{reservationId}).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "customer_first_name", "customer_last_name", "customer_phone"}).
+			AddRow(reservationId, "Jon", "Smith", "317-555-1234"),
+	)
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM customers WHERE id = ANY ($1)`)).WithArgs([]pgtype.UUID{customerId}).WillReturnRows(
+		customerRows().AddRow(customerId, "John", "Smith", "(317) 5551234", nil, "3175551234"),
+	)
+
+	// exercise
+	matches, err := FindUnlinkedReservationMatches(context.Background(), mockConn, DefaultDuplicateMinScore, 50)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(matches) != 1 {
+		t.Fatal("expected one match, got", matches)
+	}
+
+	match := matches[0]
+	if match.Reservation.CustomerFirstName != "Jon" || match.Customer.FirstName != "John" || !match.SamePhone || match.Score != 0.6 {
+		t.Fatal("expected Jon Smith's booking to match John Smith by phone, got", match)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_MergeCustomers_Itself(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	customerId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	// exercise
+	result, err := MergeCustomers(context.Background(), mockConn, customerId.String(), models.CustomerMergeRequest{DuplicateId: customerId})
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Fields["duplicate_id"] == "" {
+		t.Fatal("expected a duplicate_id error, got", err)
+	}
+
+	if result != nil {
+		t.Fatal("expected no merge, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_MergeCustomers_UnknownDuplicate(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	survivorId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	duplicateId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta(lockCustomersQuery)).WithArgs([]string{survivorId.String(), duplicateId.String()}).WillReturnRows(
+		customerRows().AddRow(survivorId, "John", "Smith", "(317) 5551234", nil, "3175551234"),
+	)
+	mockConn.ExpectRollback()
+
+	// exercise
+	_, err := MergeCustomers(context.Background(), mockConn, survivorId.String(), models.CustomerMergeRequest{DuplicateId: duplicateId})
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Fields["duplicate_id"] == "" {
+		t.Fatal("expected a duplicate_id error, got", err)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_MergeCustomers(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	survivorId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	duplicateId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	mergeId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	email := "jsmith@example.com"
+	mergedBy, reason := "front desk", "same phone, name typo"
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta(lockCustomersQuery)).WithArgs([]string{survivorId.String(), duplicateId.String()}).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "first_name", "last_name", "phone", "email", "phone_normalized"}).
+			AddRow(survivorId, "John", "Smith", "(317) 5551234", nil, "3175551234").
+			AddRow(duplicateId, "Jon", "Smith", "317-555-1234", &email, "3175551234"),
+	)
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*)::int FROM reservations WHERE customer_id = @duplicate_id`)).WithArgs(duplicateId).WillReturnRows(
+		pgxmock.NewRows([]string{"count"}).AddRow(int32(5)),
+	)
+	mockConn.ExpectExec(regexp.QuoteMeta("UPDATE athletes SET customer_id = @survivor_id")).WithArgs(survivorId, duplicateId).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+	mockConn.ExpectExec(regexp.QuoteMeta("UPDATE reservations SET customer_id = @survivor_id")).WithArgs(survivorId, duplicateId).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+	mockConn.ExpectExec(regexp.QuoteMeta("UPDATE customer_merges SET surviving_customer_id = @survivor_id")).WithArgs(survivorId, duplicateId).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO customer_merges")).
+		WithArgs(survivorId, int32(5), int32(2), &mergedBy, &reason, duplicateId).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"id", "surviving_customer_id", "merged_customer_id", "merged_customer", "reservations_moved", "athletes_moved"}).
+				AddRow(mergeId, survivorId, duplicateId, []byte(`{"first_name": "Jon"}`), int32(5), int32(2)),
+		)
+	mockConn.ExpectExec(regexp.QuoteMeta(`DELETE FROM customers WHERE id=$1`)).WithArgs(duplicateId).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mockConn.ExpectQuery(regexp.QuoteMeta("UPDATE customers SET email = @email")).WithArgs(&email, (*string)(nil), survivorId).WillReturnRows(
+		customerRows().AddRow(survivorId, "John", "Smith", "(317) 5551234", &email, "3175551234"),
+	)
+	mockConn.ExpectCommit()
+	mockConn.ExpectRollback()
+
+	// exercise
+	result, err := MergeCustomers(context.Background(), mockConn, survivorId.String(), models.CustomerMergeRequest{
+		DuplicateId: duplicateId,
+		MergedBy:    &mergedBy,
+		Reason:      &reason,
+	})
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if result.Customer.Email == nil || *result.Customer.Email != email {
+		t.Fatal("expected the survivor to pick up the duplicate's email, got", result.Customer.Email)
+	}
+
+	if result.Merge.Id != mergeId || result.Merge.ReservationsMoved != 5 || result.Merge.AthletesMoved != 2 {
+		t.Fatal("expected the merge to be recorded, got", result.Merge)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_MergedNotes(t *testing.T) {
+	survivor, duplicate := "Prefers tunnel 3.", " Pays by check. "
+
+	if got := mergedNotes(&survivor, &duplicate); got == nil || *got != "Prefers tunnel 3.\nPays by check." {
+		t.Fatal("expected both notes, got", got)
+	}
+
+	if got := mergedNotes(nil, &duplicate); got == nil || *got != "Pays by check." {
+		t.Fatal("expected the duplicate's notes, got", got)
+	}
+
+	if got := mergedNotes(nil, nil); got != nil {
+		t.Fatal("expected no notes, got", *got)
+	}
+}
@@ -0,0 +1,449 @@
+package db_utils
+
+import (
+	"context"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// LoadCustomerData lists the customers by name, a non-empty search keeps
+// those whose name, phone number or email contains it.
+func LoadCustomerData(ctx context.Context, conn IDBConn, search string) ([]models.Customer, error) {
+	customers := make([]models.Customer, 0)
+
+	query := `
+		SELECT * FROM customers
+		WHERE @search = ''
+			OR strpos(lower(first_name || ' ' || last_name), lower(@search)) > 0
+			OR strpos(phone_normalized, normalize_phone(@search)) > 0
+			OR strpos(email_normalized, normalize_email(@search)) > 0
+		ORDER BY last_name ASC, first_name ASC
+	`
+
+	err := pgxscan.Select(ctx, conn, &customers, query, pgx.NamedArgs{"search": strings.TrimSpace(search)})
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return customers, nil
+}
+
+func LoadCustomerById(ctx context.Context, conn IDBConn, id string) (*models.Customer, error) {
+	var customer models.Customer
+
+	query := `SELECT * FROM customers WHERE id=$1`
+
+	err := pgxscan.Get(ctx, conn, &customer, query, id)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] No customer found with id:", id)
+		return nil, nil
+	} else if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &customer, nil
+}
+
+func InsertCustomerData(ctx context.Context, conn IDBConn, input models.CustomerUpdates) (*models.Customer, error) {
+	var customer models.Customer
+
+	validationErr := &ValidationError{}
+	if input.FirstName == nil {
+		validationErr.Add("first_name", "is required")
+	}
+
+	if input.LastName == nil {
+		validationErr.Add("last_name", "is required")
+	}
+
+	if input.Phone == nil {
+		validationErr.Add("phone", "is required")
+	}
+
+	applyCustomerUpdates(validationErr, &customer, input)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	args := pgx.NamedArgs{
+		"first_name": customer.FirstName,
+		"last_name":  customer.LastName,
+		"phone":      customer.Phone,
+		"email":      customer.Email,
+		"notes":      customer.Notes,
+		"tier":       customer.Tier,
+	}
+
+	const query = `
+		INSERT INTO customers (
+			first_name,
+			last_name,
+			phone,
+			email,
+			notes,
+			tier
+		)
+
+		VALUES (
+			@first_name,
+			@last_name,
+			@phone,
+			@email,
+			@notes,
+			@tier
+		)
+
+		RETURNING *;
+	`
+
+	var out models.Customer
+	if err := pgxscan.Get(ctx, conn, &out, query, args); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+func UpdateCustomerData(ctx context.Context, conn IDBConn, id string, updates models.CustomerUpdates) (*models.Customer, error) {
+	existing, err := LoadCustomerById(ctx, conn, id)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	validationErr := &ValidationError{}
+	applyCustomerUpdates(validationErr, existing, updates)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	var updatedCustomer models.Customer
+
+	args := pgx.NamedArgs{
+		"id":         id,
+		"first_name": existing.FirstName,
+		"last_name":  existing.LastName,
+		"phone":      existing.Phone,
+		"email":      existing.Email,
+		"notes":      existing.Notes,
+		"tier":       existing.Tier,
+	}
+
+	query := `
+			UPDATE customers
+			SET
+				first_name = @first_name,
+				last_name = @last_name,
+				phone = @phone,
+				email = @email,
+				notes = @notes,
+				tier = @tier,
+				updated_at = now()
+			WHERE id = @id
+			RETURNING *
+	`
+
+	err = pgxscan.Get(ctx, conn, &updatedCustomer, query, args)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find customer with id:", id)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating customer:", err)
+		return nil, err
+	}
+
+	return &updatedCustomer, nil
+}
+
+// DeleteCustomerData removes a customer without reservations, the
+// reservations' ON DELETE RESTRICT keeps anyone with history.
+func DeleteCustomerData(ctx context.Context, conn IDBConn, id string) (int64, error) {
+	cmdTag, err := conn.Exec(
+		ctx,
+		"DELETE FROM customers WHERE id=$1",
+		id,
+	)
+
+	if err != nil {
+		log.Println("[API] Error deleting customer:", err)
+		return 0, err
+	}
+
+	return cmdTag.RowsAffected(), err
+}
+
+// LoadCustomerReservations is the customer's booking history, newest first.
+func LoadCustomerReservations(ctx context.Context, conn IDBConn, id string) ([]models.Reservation, error) {
+	reservations := make([]models.Reservation, 0)
+
+	query := `SELECT * FROM reservations WHERE customer_id = $1 ORDER BY start_time DESC`
+
+	err := pgxscan.Select(ctx, conn, &reservations, query, id)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// ResolveReservationCustomer links a new reservation to its customer. An
+// athlete_id must be an active athlete of the customer and brings their
+// customer along when customer_id is left out. A given customer_id fills in
+// the contact fields left empty, otherwise the reservation is linked to the
+// one customer with the same phone number or email. Several matches, or
+// none, leave it unlinked for the backfill. A linked reservation is booked
+// under its customer's tier unless staff already picked one.
+func ResolveReservationCustomer(ctx context.Context, conn IDBConn, r *models.Reservation) error {
+	if r.AthleteId != nil {
+		var athlete models.Athlete
+
+		err := pgxscan.Get(ctx, conn, &athlete, `SELECT * FROM athletes WHERE id=$1`, r.AthleteId.String())
+		if err != nil && !pgxscan.NotFound(err) {
+			log.Println("[API] Error querying database:", err)
+			return err
+		}
+
+		validationErr := &ValidationError{}
+		switch {
+		case pgxscan.NotFound(err):
+			validationErr.Add("athlete_id", "does not exist")
+		case r.CustomerId != nil && *r.CustomerId != athlete.CustomerId:
+			validationErr.Add("athlete_id", "is not one of the customer's athletes")
+		case !athlete.IsActive:
+			validationErr.Add("athlete_id", "is no longer active")
+		}
+
+		if err := validationErr.Err(); err != nil {
+			return err
+		}
+
+		r.CustomerId = &athlete.CustomerId
+	}
+
+	if r.CustomerId != nil {
+		customer, err := LoadCustomerById(ctx, conn, r.CustomerId.String())
+		if err != nil {
+			return err
+		}
+
+		if customer == nil {
+			validationErr := &ValidationError{}
+			validationErr.Add("customer_id", "does not exist")
+			return validationErr
+		}
+
+		if r.CustomerFirstName == "" && r.CustomerLastName == "" {
+			r.CustomerFirstName = customer.FirstName
+			r.CustomerLastName = customer.LastName
+		}
+
+		if r.CustomerPhone == "" {
+			r.CustomerPhone = customer.Phone
+		}
+
+		if r.CustomerEmail == nil {
+			r.CustomerEmail = customer.Email
+		}
+
+		if r.CustomerTier == nil {
+			r.CustomerTier = customer.Tier
+		}
+
+		return nil
+	}
+
+	matches := make([]models.Customer, 0)
+
+	args := pgx.NamedArgs{
+		"phone": r.CustomerPhone,
+		"email": r.CustomerEmail,
+	}
+
+	query := `
+		SELECT * FROM customers
+		WHERE phone_normalized = normalize_phone(@phone)
+			OR email_normalized = normalize_email(@email)
+		LIMIT 2
+	`
+
+	err := pgxscan.Select(ctx, conn, &matches, query, args)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return err
+	}
+
+	if len(matches) == 1 {
+		r.CustomerId = &matches[0].Id
+
+		if r.CustomerTier == nil {
+			r.CustomerTier = matches[0].Tier
+		}
+	}
+
+	return nil
+}
+
+// LoadCustomerBackfillReport is the dry run of RunCustomerBackfill: the
+// groups of unlinked reservations it would link, with the customer they
+// would go to when one exists, and the ambiguous ones it would leave.
+func LoadCustomerBackfillReport(ctx context.Context, conn IDBConn) (*models.CustomerBackfillReport, error) {
+	groups := make([]models.CustomerBackfillGroup, 0)
+
+	query := `
+		SELECT
+			*,
+			CASE WHEN action = 'link' THEN matching_customer_ids[1] END AS customer_id
+		FROM customer_backfill_report
+		ORDER BY phone_key ASC NULLS LAST
+	`
+
+	err := pgxscan.Select(ctx, conn, &groups, query)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	report := &models.CustomerBackfillReport{
+		DryRun:    true,
+		Linked:    make([]models.CustomerBackfillGroup, 0),
+		Ambiguous: make([]models.CustomerBackfillGroup, 0),
+	}
+
+	for _, group := range groups {
+		if group.Action == "ambiguous" {
+			report.Ambiguous = append(report.Ambiguous, group)
+		} else {
+			report.Linked = append(report.Linked, group)
+		}
+	}
+
+	return report, nil
+}
+
+// RunCustomerBackfill links the unambiguous groups of unlinked reservations
+// to their customers, creating customers as needed, and reports the groups
+// still left. Customers are locked against a second run creating the same
+// customer twice.
+func RunCustomerBackfill(ctx context.Context, conn IDBTxConn) (*models.CustomerBackfillReport, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `LOCK TABLE customers IN EXCLUSIVE MODE`); err != nil {
+		log.Println("[API] Error locking customers:", err)
+		return nil, err
+	}
+
+	report := &models.CustomerBackfillReport{
+		Linked:    make([]models.CustomerBackfillGroup, 0),
+		Ambiguous: make([]models.CustomerBackfillGroup, 0),
+	}
+
+	err = pgxscan.Select(ctx, tx, &report.Linked, `SELECT * FROM backfill_customers() ORDER BY phone_key ASC`)
+	if err != nil {
+		log.Println("[API] Error backfilling customers:", err)
+		return nil, err
+	}
+
+	query := `
+		SELECT * FROM customer_backfill_report
+		WHERE action = 'ambiguous'
+		ORDER BY phone_key ASC NULLS LAST
+	`
+
+	err = pgxscan.Select(ctx, tx, &report.Ambiguous, query)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing customer backfill:", err)
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// NormalizePhone keeps the digits of phone, dropping a leading US country
+// code, like the normalize_phone() the customers table matches on.
+func NormalizePhone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+
+		return -1
+	}, phone)
+
+	if len(digits) == 11 && digits[0] == '1' {
+		return digits[1:]
+	}
+
+	return digits
+}
+
+func applyCustomerUpdates(validationErr *ValidationError, customer *models.Customer, updates models.CustomerUpdates) {
+	if updates.FirstName != nil {
+		if strings.TrimSpace(*updates.FirstName) == "" {
+			validationErr.Add("first_name", "cannot be empty")
+		} else {
+			customer.FirstName = strings.TrimSpace(*updates.FirstName)
+		}
+	}
+
+	if updates.LastName != nil {
+		if strings.TrimSpace(*updates.LastName) == "" {
+			validationErr.Add("last_name", "cannot be empty")
+		} else {
+			customer.LastName = strings.TrimSpace(*updates.LastName)
+		}
+	}
+
+	if updates.Phone != nil {
+		if len(NormalizePhone(*updates.Phone)) < 7 {
+			validationErr.Add("phone", "must be a phone number")
+		} else {
+			customer.Phone = strings.TrimSpace(*updates.Phone)
+		}
+	}
+
+	// an empty email clears it
+	if updates.Email != nil {
+		email := strings.TrimSpace(*updates.Email)
+		switch {
+		case email == "":
+			customer.Email = nil
+		case !strings.Contains(email, "@") || strings.ContainsFunc(email, unicode.IsSpace):
+			validationErr.Add("email", "must be an email address")
+		default:
+			customer.Email = &email
+		}
+	}
+
+	if updates.Notes != nil {
+		customer.Notes = updates.Notes
+	}
+
+	if updates.Tier != nil {
+		if tier := strings.TrimSpace(*updates.Tier); tier == "" {
+			customer.Tier = nil
+		} else {
+			customer.Tier = &tier
+		}
+	}
+}
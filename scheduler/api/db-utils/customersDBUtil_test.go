@@ -0,0 +1,378 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const matchCustomerQuery = `
+		SELECT * FROM customers
+		WHERE phone_normalized = normalize_phone(@phone)
+			OR email_normalized = normalize_email(@email)
+		LIMIT 2
+	`
+
+func customerRows() *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"id", "first_name", "last_name", "phone", "email", "phone_normalized"})
+}
+
+func Test_NormalizePhone(t *testing.T) {
+	cases := map[string]string{
+		"(812) 555-0100":  "8125550100",
+		"812.555.0100":    "8125550100",
+		"+1 812 555 0100": "8125550100",
+		"555-0100":        "5550100",
+		"call me":         "",
+	}
+
+	for phone, expected := range cases {
+		if got := NormalizePhone(phone); got != expected {
+			t.Errorf("NormalizePhone(%q) = %q, expected %q", phone, got, expected)
+		}
+	}
+}
+
+func Test_InsertCustomerData_Invalid(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	firstName := " "
+	phone := "ext. 12"
+	email := "jane at example.com"
+
+	// exercise
+	result, err := InsertCustomerData(context.Background(), mockConn, models.CustomerUpdates{
+		FirstName: &firstName,
+		Phone:     &phone,
+		Email:     &email,
+	})
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	for _, field := range []string{"first_name", "last_name", "phone", "email"} {
+		if validationErr.Fields[field] == "" {
+			t.Error("expected an error for", field, "got", validationErr.Fields)
+		}
+	}
+
+	if result != nil {
+		t.Fatal("expected no customer, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ResolveReservationCustomer_ById(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	customerId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	email := "jane@example.com"
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM customers WHERE id=$1`)).WithArgs(customerId.String()).WillReturnRows(
+		customerRows().AddRow(customerId, "Jane", "Doe", "(812) 555-0100", &email, "8125550100"),
+	)
+
+	reservation := models.Reservation{CustomerId: &customerId}
+
+	// exercise
+	err := ResolveReservationCustomer(context.Background(), mockConn, &reservation)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if reservation.CustomerFirstName != "Jane" ||
+		reservation.CustomerLastName != "Doe" ||
+		reservation.CustomerPhone != "(812) 555-0100" ||
+		reservation.CustomerEmail == nil || *reservation.CustomerEmail != email {
+		t.Fatal("expected the customer's contact details, got", reservation)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ResolveReservationCustomer_Tier(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	customerId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	tier := "member"
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM customers WHERE id=$1`)).WithArgs(customerId.String()).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "first_name", "last_name", "phone", "phone_normalized", "tier"}).
+			AddRow(customerId, "Jane", "Doe", "(812) 555-0100", "8125550100", &tier),
+	)
+
+	reservation := models.Reservation{CustomerId: &customerId}
+
+	// exercise
+	err := ResolveReservationCustomer(context.Background(), mockConn, &reservation)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if reservation.CustomerTier == nil || *reservation.CustomerTier != tier {
+		t.Fatal("expected the customer's tier, got", reservation.CustomerTier)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ResolveReservationCustomer_UnknownId(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	customerId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM customers WHERE id=$1`)).WithArgs(customerId.String()).WillReturnRows(
+		customerRows(),
+	)
+
+	reservation := models.Reservation{CustomerId: &customerId}
+
+	// exercise
+	err := ResolveReservationCustomer(context.Background(), mockConn, &reservation)
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Fields["customer_id"] == "" {
+		t.Fatal("expected a customer_id error, got", err)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ResolveReservationCustomer_MatchesPhone(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	customerId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	reservation := models.Reservation{
+		CustomerFirstName: "Jane",
+		CustomerLastName:  "Doe",
+		CustomerPhone:     "812.555.0100",
+	}
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(matchCustomerQuery)).WithArgs(reservation.CustomerPhone, reservation.CustomerEmail).WillReturnRows(
+		customerRows().AddRow(customerId, "Jane", "Doe", "(812) 555-0100", nil, "8125550100"),
+	)
+
+	// exercise
+	err := ResolveReservationCustomer(context.Background(), mockConn, &reservation)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if reservation.CustomerId == nil || *reservation.CustomerId != customerId {
+		t.Fatal("expected the reservation to be linked to", customerId, "got", reservation.CustomerId)
+	}
+
+	if reservation.CustomerPhone != "812.555.0100" {
+		t.Fatal("expected the phone number as entered, got", reservation.CustomerPhone)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ResolveReservationCustomer_SeveralMatches(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	reservation := models.Reservation{CustomerFirstName: "Jane", CustomerPhone: "812-555-0100"}
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(matchCustomerQuery)).WithArgs(reservation.CustomerPhone, reservation.CustomerEmail).WillReturnRows(
+		customerRows().
+			AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, "Jane", "Doe", "812-555-0100", nil, "8125550100").
+			AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, "John", "Doe", "812-555-0100", nil, "8125550100"),
+	)
+
+	// exercise
+	err := ResolveReservationCustomer(context.Background(), mockConn, &reservation)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if reservation.CustomerId != nil {
+		t.Fatal("expected the reservation to stay unlinked, got", reservation.CustomerId)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_LoadCustomerBackfillReport(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	customerId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	linkedPhone, ambiguousPhone := "8125550100", "8125550199"
+	reason := "The phone number was booked with several emails."
+
+	mockConn.ExpectQuery(regexp.QuoteMeta("FROM customer_backfill_report")).WillReturnRows(
+		pgxmock.NewRows([]string{"phone_key", "reservation_count", "emails", "names", "matching_customer_ids", "action", "reason", "customer_id"}).
+			AddRow(&linkedPhone, int32(3), []string{}, []string{"Jane Doe", "Jane Doe "}, []pgtype.UUID{customerId}, "link", nil, &customerId).
+			AddRow(&ambiguousPhone, int32(2), []string{"a@example.com", "b@example.com"}, []string{"Sam Lee"}, []pgtype.UUID{}, "ambiguous", &reason, nil),
+	)
+
+	// exercise
+	report, err := LoadCustomerBackfillReport(context.Background(), mockConn)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if !report.DryRun || len(report.Linked) != 1 || len(report.Ambiguous) != 1 {
+		t.Fatal("expected one linked and one ambiguous group in a dry run, got", report)
+	}
+
+	if report.Ambiguous[0].Reason == nil || *report.Ambiguous[0].Reason != reason {
+		t.Fatal("expected the ambiguous group to say why, got", report.Ambiguous[0].Reason)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_RunCustomerBackfill(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	customerId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	phone := "8125550100"
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectExec(regexp.QuoteMeta(`LOCK TABLE customers IN EXCLUSIVE MODE`)).WillReturnResult(pgxmock.NewResult("LOCK", 0))
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM backfill_customers() ORDER BY phone_key ASC`)).WillReturnRows(
+		pgxmock.NewRows([]string{"phone_key", "reservation_count", "action", "customer_id"}).
+			AddRow(&phone, int32(4), "create", &customerId),
+	)
+	mockConn.ExpectQuery(regexp.QuoteMeta("WHERE action = 'ambiguous'")).WillReturnRows(
+		pgxmock.NewRows([]string{"phone_key", "reservation_count", "action"}),
+	)
+	mockConn.ExpectCommit()
+	mockConn.ExpectRollback()
+
+	// exercise
+	report, err := RunCustomerBackfill(context.Background(), mockConn)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if report.DryRun || len(report.Linked) != 1 || len(report.Ambiguous) != 0 {
+		t.Fatal("expected one group linked, got", report)
+	}
+
+	if report.Linked[0].CustomerId == nil || *report.Linked[0].CustomerId != customerId {
+		t.Fatal("expected the group to go to the new customer, got", report.Linked[0].CustomerId)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ResolveReservationCustomer_ByAthlete(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	customerId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	athleteId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM athletes WHERE id=$1`)).WithArgs(athleteId.String()).WillReturnRows(
+		athleteRows().AddRow(athleteId, customerId, "Sam", "Doe", int32(2013), models.SportBaseball, true),
+	)
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM customers WHERE id=$1`)).WithArgs(customerId.String()).WillReturnRows(
+		customerRows().AddRow(customerId, "Jane", "Doe", "812-555-0100", nil, "8125550100"),
+	)
+
+	reservation := models.Reservation{AthleteId: &athleteId}
+
+	// exercise
+	err := ResolveReservationCustomer(context.Background(), mockConn, &reservation)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if reservation.CustomerId == nil || *reservation.CustomerId != customerId || reservation.CustomerFirstName != "Jane" {
+		t.Fatal("expected the athlete's guardian as the customer, got", reservation)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ResolveReservationCustomer_OtherCustomersAthlete(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	customerId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	otherCustomerId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	athleteId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM athletes WHERE id=$1`)).WithArgs(athleteId.String()).WillReturnRows(
+		athleteRows().AddRow(athleteId, otherCustomerId, "Sam", "Doe", int32(2013), models.SportBaseball, true),
+	)
+
+	reservation := models.Reservation{CustomerId: &customerId, AthleteId: &athleteId}
+
+	// exercise
+	err := ResolveReservationCustomer(context.Background(), mockConn, &reservation)
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Fields["athlete_id"] == "" {
+		t.Fatal("expected an athlete_id error, got", err)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,129 @@
+package db_utils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DBErrorKind is the machine-readable reason a statement was rejected.
+type DBErrorKind string
+
+const (
+	DBErrorInvalidInput     DBErrorKind = "invalid_input"     // 22P02, e.g. a malformed uuid
+	DBErrorMissingReference DBErrorKind = "missing_reference" // 23503 on insert/update, the referenced row doesn't exist
+	DBErrorStillReferenced  DBErrorKind = "still_referenced"  // 23503 on delete, an ON DELETE RESTRICT row points here
+	DBErrorCheckViolation   DBErrorKind = "check_violation"   // 23514
+	DBErrorOverlap          DBErrorKind = "overlap"           // 23P01
+)
+
+// DBError is a postgres failure caused by the request rather than the
+// server, with the column it is about when that can be worked out.
+type DBError struct {
+	Kind       DBErrorKind
+	Field      string
+	Constraint string
+	Value      string // the rejected input for invalid_input
+	Message    string
+	PgErr      *pgconn.PgError
+}
+
+func (e *DBError) Error() string {
+	return e.Message
+}
+
+func (e *DBError) Unwrap() error {
+	return e.PgErr
+}
+
+type constraintInfo struct {
+	field   string
+	message string
+}
+
+// constraintMessages explains the named constraints of the schema, unnamed
+// CHECKs use the names postgres generates for them.
+var constraintMessages = map[string]constraintInfo{
+	"tunnel_no_overlap":                           {"tunnel_id", "The tunnel is already booked for that time."},
+	"coach_no_overlap":                            {"coach_id", "The coach is already booked for that time."},
+	"reservations_check":                          {"reservation_kind", "Tunnel rentals need a tunnel_id and no coach_id."},
+	"reservations_check1":                         {"reservation_kind", "Lessons need a tunnel_id and a coach_id."},
+	"reservations_duration_minutes_check":         {"duration_minutes", "Must be greater than 0."},
+	"reservations_end_time_matches_duration":      {"end_time", "Must equal start_time plus duration_minutes."},
+	"reservations_tunnel_id_fkey":                 {"tunnel_id", "The tunnel does not exist."},
+	"reservations_coach_id_fkey":                  {"coach_id", "The coach does not exist."},
+	"reservations_lesson_type_id_fkey":            {"lesson_type_id", "The lesson type does not exist."},
+	"reservations_lesson_type_only_lessons":       {"lesson_type_id", "Only lessons have a lesson type."},
+	"reservation_series_lesson_type_id_fkey":      {"lesson_type_id", "The lesson type does not exist."},
+	"reservation_series_lesson_type_only_lessons": {"lesson_type_id", "Only lessons have a lesson type."},
+	"waitlist_entries_lesson_type_id_fkey":        {"lesson_type_id", "The lesson type does not exist."},
+	"waitlist_entries_lesson_type_only_lessons":   {"lesson_type_id", "Only lessons have a lesson type."},
+	"reservations_session_blocks":                 {"reservation_kind", "Session blocks are created through /api/sessions."},
+	"reservations_session_needs_tunnel":           {"tunnel_id", "Session blocks need a tunnel_id."},
+	"reservations_session_id_fkey":                {"session_id", "The session does not exist."},
+	"reservations_customer_id_fkey":               {"customer_id", "The customer does not exist."},
+	"customers_phone_has_digits":                  {"phone", "Must be a phone number."},
+	"reservations_athlete_customer_fkey":          {"athlete_id", "The athlete does not exist or is not one of the customer's athletes."},
+	"reservations_athlete_needs_customer":         {"athlete_id", "Only reservations linked to a customer can name an athlete."},
+	"athletes_birth_year_check":                   {"birth_year", "Must be 1900 or later."},
+	"athletes_throws_check":                       {"throws", "Must be right or left."},
+}
+
+var (
+	detailKeyPattern    = regexp.MustCompile(`^Key \(([a-z_]+)[,)]`)
+	invalidInputPattern = regexp.MustCompile(`: "(.*)"$`)
+)
+
+// TranslateDBError turns the postgres errors a client can cause into a
+// *DBError, anything else (including nil) is returned unchanged.
+func TranslateDBError(err error) error {
+	var dbErr *DBError
+	if errors.As(err, &dbErr) {
+		return dbErr
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	out := &DBError{
+		Constraint: pgErr.ConstraintName,
+		Message:    pgErr.Message,
+		PgErr:      pgErr,
+	}
+
+	switch pgErr.Code {
+	case "22P02":
+		out.Kind = DBErrorInvalidInput
+		if match := invalidInputPattern.FindStringSubmatch(pgErr.Message); match != nil {
+			out.Value = match[1]
+		}
+	case "23503":
+		out.Kind = DBErrorMissingReference
+		if strings.Contains(pgErr.Detail, "is still referenced") {
+			out.Kind = DBErrorStillReferenced
+			out.Message = "The record is still in use by other records and cannot be deleted."
+		}
+	case "23514":
+		out.Kind = DBErrorCheckViolation
+	case "23P01":
+		out.Kind = DBErrorOverlap
+	default:
+		return err
+	}
+
+	out.Field = pgErr.ColumnName
+	if match := detailKeyPattern.FindStringSubmatch(pgErr.Detail); out.Field == "" && match != nil {
+		out.Field = match[1]
+	}
+
+	if info, ok := constraintMessages[pgErr.ConstraintName]; ok && out.Kind != DBErrorStillReferenced {
+		out.Field = info.field
+		out.Message = info.message
+	}
+
+	return out
+}
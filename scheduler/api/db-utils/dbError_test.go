@@ -0,0 +1,83 @@
+package db_utils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func Test_TranslateDBError(t *testing.T) {
+	// setup
+	cases := []struct {
+		pgErr *pgconn.PgError
+		kind  DBErrorKind
+		field string
+	}{
+		{
+			pgErr: &pgconn.PgError{Code: "22P02", Message: `invalid input syntax for type uuid: "abc"`},
+			kind:  DBErrorInvalidInput,
+		},
+		{
+			pgErr: &pgconn.PgError{
+				Code:           "23503",
+				ConstraintName: "reservations_coach_id_fkey",
+				Detail:         `Key (coach_id)=(0b7c7e4c-5f3a-4c1e-9d2b-8a6f1e3d2c10) is not present in table "coaches".`,
+			},
+			kind:  DBErrorMissingReference,
+			field: "coach_id",
+		},
+		{
+			pgErr: &pgconn.PgError{
+				Code:           "23503",
+				ConstraintName: "reservations_tunnel_id_fkey",
+				Detail:         `Key (id)=(1) is still referenced from table "reservations".`,
+			},
+			kind:  DBErrorStillReferenced,
+			field: "id",
+		},
+		{
+			pgErr: &pgconn.PgError{Code: "23514", ConstraintName: "reservations_check1"},
+			kind:  DBErrorCheckViolation,
+			field: "reservation_kind",
+		},
+		{
+			pgErr: &pgconn.PgError{Code: "23P01", ConstraintName: "tunnel_no_overlap"},
+			kind:  DBErrorOverlap,
+			field: "tunnel_id",
+		},
+	}
+
+	for _, tc := range cases {
+		// exercise
+		err := TranslateDBError(fmt.Errorf("wrapped: %w", tc.pgErr))
+
+		// verify
+		var dbErr *DBError
+		if !errors.As(err, &dbErr) {
+			t.Fatal("expected a *DBError for", tc.pgErr.Code, "got", err)
+		}
+
+		if dbErr.Kind != tc.kind || dbErr.Field != tc.field {
+			t.Fatal("expected", tc.kind, tc.field, "for", tc.pgErr.Code, "got", dbErr.Kind, dbErr.Field)
+		}
+	}
+}
+
+func Test_TranslateDBError_Passthrough(t *testing.T) {
+	// setup
+	serverErr := &pgconn.PgError{Code: "53300"}
+
+	// exercise
+	err := TranslateDBError(serverErr)
+
+	// verify
+	if err != serverErr {
+		t.Fatal("expected a server side error to pass through, got", err)
+	}
+
+	if TranslateDBError(nil) != nil {
+		t.Fatal("expected nil to stay nil")
+	}
+}
@@ -0,0 +1,225 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrAcquireTimeout is returned when no pooled connection frees up within the
+// configured acquire timeout.
+var ErrAcquireTimeout = errors.New("timed out waiting for a database connection")
+
+type PoolConfig struct {
+	MaxConns          int32
+	MinConns          int32
+	AcquireTimeout    time.Duration
+	HealthCheckPeriod time.Duration
+	MaxConnIdleTime   time.Duration
+	MaxConnLifetime   time.Duration
+}
+
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxConns:          10,
+		MinConns:          2,
+		AcquireTimeout:    5 * time.Second,
+		HealthCheckPeriod: 30 * time.Second,
+		MaxConnIdleTime:   5 * time.Minute,
+		MaxConnLifetime:   time.Hour,
+	}
+}
+
+// DBPool wraps a pgxpool.Pool so that waiting for a free connection is bounded
+// by AcquireTimeout, independently of how long the statement itself runs.
+type DBPool struct {
+	*pgxpool.Pool
+	acquireTimeout time.Duration
+}
+
+func NewDBPool(ctx context.Context, dbUrl string, cfg PoolConfig) (*DBPool, error) {
+	poolCfg, err := pgxpool.ParseConfig(dbUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxConns > 0 {
+		poolCfg.MaxConns = cfg.MaxConns
+	}
+
+	if cfg.MinConns > 0 {
+		poolCfg.MinConns = cfg.MinConns
+	}
+
+	if poolCfg.MinConns > poolCfg.MaxConns {
+		return nil, fmt.Errorf("min conns (%d) cannot exceed max conns (%d)", poolCfg.MinConns, poolCfg.MaxConns)
+	}
+
+	if cfg.HealthCheckPeriod > 0 {
+		poolCfg.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+
+	if cfg.MaxConnIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+
+	if cfg.MaxConnLifetime > 0 {
+		poolCfg.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DBPool{Pool: pool, acquireTimeout: cfg.AcquireTimeout}, nil
+}
+
+func (p *DBPool) acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	if p.acquireTimeout <= 0 {
+		return p.Pool.Acquire(ctx)
+	}
+
+	acquireCtx, cancel := context.WithTimeout(ctx, p.acquireTimeout)
+	defer cancel()
+
+	c, err := p.Pool.Acquire(acquireCtx)
+
+	// only report a timeout when it was ours and not the caller's context
+	if err != nil && ctx.Err() == nil && errors.Is(acquireCtx.Err(), context.DeadlineExceeded) {
+		return nil, ErrAcquireTimeout
+	}
+
+	return c, err
+}
+
+func (p *DBPool) Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	c, err := p.acquire(ctx)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	defer c.Release()
+
+	return c.Exec(ctx, query, args...)
+}
+
+func (p *DBPool) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	c, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.Query(ctx, query, args...)
+	if err != nil {
+		c.Release()
+		return nil, err
+	}
+
+	return &pooledRows{Rows: rows, conn: c}, nil
+}
+
+func (p *DBPool) QueryRow(ctx context.Context, query string, args ...any) pgx.Row {
+	c, err := p.acquire(ctx)
+	if err != nil {
+		return errRow{err: err}
+	}
+
+	return &pooledRow{row: c.QueryRow(ctx, query, args...), conn: c}
+}
+
+func (p *DBPool) Begin(ctx context.Context) (pgx.Tx, error) {
+	c, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		c.Release()
+		return nil, err
+	}
+
+	return &pooledTx{Tx: tx, conn: c}, nil
+}
+
+func (p *DBPool) Ping(ctx context.Context) error {
+	c, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Release()
+
+	return c.Ping(ctx)
+}
+
+// pooledRows hands its connection back to the pool once the rows are drained or closed.
+type pooledRows struct {
+	pgx.Rows
+	conn *pgxpool.Conn
+}
+
+func (r *pooledRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+
+	r.Close()
+	return false
+}
+
+func (r *pooledRows) Close() {
+	r.Rows.Close()
+	if r.conn != nil {
+		r.conn.Release()
+		r.conn = nil
+	}
+}
+
+type pooledRow struct {
+	row  pgx.Row
+	conn *pgxpool.Conn
+}
+
+func (r *pooledRow) Scan(dest ...any) error {
+	defer r.conn.Release()
+	return r.row.Scan(dest...)
+}
+
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...any) error {
+	return r.err
+}
+
+// pooledTx releases its connection once the transaction is finished either way.
+type pooledTx struct {
+	pgx.Tx
+	conn *pgxpool.Conn
+}
+
+func (t *pooledTx) Commit(ctx context.Context) error {
+	err := t.Tx.Commit(ctx)
+	t.release()
+	return err
+}
+
+func (t *pooledTx) Rollback(ctx context.Context) error {
+	// safe to defer after a commit, release is a no-op the second time
+	err := t.Tx.Rollback(ctx)
+	t.release()
+	return err
+}
+
+func (t *pooledTx) release() {
+	if t.conn != nil {
+		t.conn.Release()
+		t.conn = nil
+	}
+}
@@ -0,0 +1,67 @@
+package db_utils
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// HoldExpiryActor is recorded as changed_by when the sweeper cancels a hold.
+const HoldExpiryActor = "hold-expiry"
+
+type HoldConfig struct {
+	WebTTL        time.Duration
+	PhoneTTL      time.Duration
+	OfferTTL      time.Duration // holds offered to waitlisted customers
+	SweepInterval time.Duration
+}
+
+func DefaultHoldConfig() HoldConfig {
+	return HoldConfig{
+		WebTTL:        15 * time.Minute,
+		PhoneTTL:      24 * time.Hour,
+		OfferTTL:      30 * time.Minute,
+		SweepInterval: time.Minute,
+	}
+}
+
+// ExpiresAt returns when a hold made now through the given channel lapses.
+func (c HoldConfig) ExpiresAt(channel models.BookingChannel, now time.Time) pgtype.Timestamptz {
+	ttl := c.WebTTL
+	if channel == models.BookingChannelPhone {
+		ttl = c.PhoneTTL
+	}
+
+	return pgtype.Timestamptz{Time: now.Add(ttl), Valid: true}
+}
+
+// ExpireStaleHolds cancels every held reservation whose hold has lapsed and
+// records the change, all in one statement so a concurrent confirm either
+// wins or sees the reservation already cancelled.
+func ExpireStaleHolds(ctx context.Context, conn IDBConn) ([]models.Reservation, error) {
+	expired := make([]models.Reservation, 0)
+
+	query := `
+		WITH expired AS (
+			UPDATE reservations
+			SET status = 'cancelled', updated_at = now()
+			WHERE status = 'held' AND hold_expires_at <= now()
+			RETURNING *
+		), history AS (
+			INSERT INTO reservation_status_changes (reservation_id, from_status, to_status, changed_by, reason)
+			SELECT id, 'held', 'cancelled', $1, 'hold expired' FROM expired
+		)
+		SELECT * FROM expired
+	`
+
+	if err := pgxscan.Select(ctx, conn, &expired, query, HoldExpiryActor); err != nil {
+		log.Println("[API] Error expiring stale holds:", err)
+		return nil, err
+	}
+
+	return expired, nil
+}
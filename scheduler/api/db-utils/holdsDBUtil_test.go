@@ -0,0 +1,75 @@
+package db_utils
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func Test_HoldConfig_ExpiresAt(t *testing.T) {
+	// setup
+	config := DefaultHoldConfig()
+	now := time.Date(2025, 6, 7, 14, 0, 0, 0, time.UTC)
+
+	// exercise
+	web := config.ExpiresAt(models.BookingChannelWeb, now)
+	phone := config.ExpiresAt(models.BookingChannelPhone, now)
+
+	// verify
+	if !web.Time.Equal(now.Add(15 * time.Minute)) {
+		t.Fatal("expected web hold to expire in 15 minutes, got", web.Time)
+	}
+
+	if !phone.Time.Equal(now.Add(24 * time.Hour)) {
+		t.Fatal("expected phone hold to expire in 24 hours, got", phone.Time)
+	}
+}
+
+func Test_ExpireStaleHolds(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	query := `
+		WITH expired AS (
+			UPDATE reservations
+			SET status = 'cancelled', updated_at = now()
+			WHERE status = 'held' AND hold_expires_at <= now()
+			RETURNING *
+		), history AS (
+			INSERT INTO reservation_status_changes (reservation_id, from_status, to_status, changed_by, reason)
+			SELECT id, 'held', 'cancelled', $1, 'hold expired' FROM expired
+		)
+		SELECT * FROM expired
+	`
+
+	pgU := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	heldUntil := pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true}
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(HoldExpiryActor).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "status", "hold_expires_at"}).
+			AddRow(pgU, models.ReservationStatusCancelled, heldUntil),
+	)
+
+	// exercise
+	expired, err := ExpireStaleHolds(context.Background(), mockConn)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(expired) != 1 || expired[0].Status != models.ReservationStatusCancelled {
+		t.Fatal("expected 1 cancelled reservation, got", expired)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -12,3 +12,17 @@ type IDBConn interface {
 	QueryRow(ctx context.Context, query string, args ...any) pgx.Row
 	Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error)
 }
+
+// IDBTxConn is a connection that can start a transaction. A pgx.Tx satisfies
+// it too, Begin on a transaction creates a savepoint.
+type IDBTxConn interface {
+	IDBConn
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// IDBPool is the connection source shared by the api handlers. It is safe for
+// concurrent use and can hand out transactions (pgx.Tx also satisfies IDBConn).
+type IDBPool interface {
+	IDBTxConn
+	Ping(ctx context.Context) error
+}
@@ -0,0 +1,306 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const (
+	RuleCoachInactive         = "coach_inactive"
+	RuleCoachMissingSpecialty = "coach_missing_specialty"
+)
+
+// LoadLessonTypeData lists the lesson catalog, only the bookable types when
+// activeOnly is set.
+func LoadLessonTypeData(ctx context.Context, conn IDBConn, activeOnly bool) ([]models.LessonType, error) {
+	lessonTypes := make([]models.LessonType, 0)
+
+	query := `
+		SELECT * FROM lesson_types
+		WHERE (NOT @active_only OR is_active)
+		ORDER BY name ASC
+	`
+
+	err := pgxscan.Select(ctx, conn, &lessonTypes, query, pgx.NamedArgs{"active_only": activeOnly})
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return lessonTypes, nil
+}
+
+func LoadLessonTypeById(ctx context.Context, conn IDBConn, id string) (*models.LessonType, error) {
+	var lessonType models.LessonType
+
+	query := `SELECT * FROM lesson_types WHERE id=$1`
+
+	err := pgxscan.Get(ctx, conn, &lessonType, query, id)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] No lesson type found with id:", id)
+		return nil, nil
+	} else if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &lessonType, nil
+}
+
+func InsertLessonTypeData(ctx context.Context, conn IDBConn, input models.LessonTypeUpdates) (*models.LessonType, error) {
+	lessonType := models.LessonType{IsActive: true}
+
+	validationErr := &ValidationError{}
+	if input.Name == nil {
+		validationErr.Add("name", "is required")
+	}
+
+	if input.Specialty == nil {
+		validationErr.Add("specialty", "is required")
+	}
+
+	if input.DefaultDuration == nil {
+		validationErr.Add("default_duration_minutes", "is required")
+	}
+
+	if input.PriceCents == nil {
+		validationErr.Add("price_cents", "is required")
+	}
+
+	applyLessonTypeUpdates(validationErr, &lessonType, input)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	args := pgx.NamedArgs{
+		"name":                     lessonType.Name,
+		"specialty":                lessonType.Specialty,
+		"default_duration_minutes": lessonType.DefaultDuration,
+		"price_cents":              lessonType.PriceCents,
+		"is_active":                lessonType.IsActive,
+	}
+
+	const query = `
+		INSERT INTO lesson_types (
+			name,
+			specialty,
+			default_duration_minutes,
+			price_cents,
+			is_active
+		)
+
+		VALUES (
+			@name,
+			@specialty::coach_specialty,
+			@default_duration_minutes,
+			@price_cents,
+			@is_active
+		)
+
+		RETURNING *;
+	`
+
+	var out models.LessonType
+	if err := pgxscan.Get(ctx, conn, &out, query, args); err != nil {
+		return nil, lessonTypeNameTaken(err)
+	}
+
+	return &out, nil
+}
+
+func UpdateLessonTypeData(ctx context.Context, conn IDBConn, id string, updates models.LessonTypeUpdates) (*models.LessonType, error) {
+	existing, err := LoadLessonTypeById(ctx, conn, id)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	validationErr := &ValidationError{}
+	applyLessonTypeUpdates(validationErr, existing, updates)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	var updatedLessonType models.LessonType
+
+	args := pgx.NamedArgs{
+		"id":                       id,
+		"name":                     existing.Name,
+		"specialty":                existing.Specialty,
+		"default_duration_minutes": existing.DefaultDuration,
+		"price_cents":              existing.PriceCents,
+		"is_active":                existing.IsActive,
+	}
+
+	query := `
+			UPDATE lesson_types
+			SET
+				name = @name,
+				specialty = @specialty::coach_specialty,
+				default_duration_minutes = @default_duration_minutes,
+				price_cents = @price_cents,
+				is_active = @is_active,
+				updated_at = now()
+			WHERE id = @id
+			RETURNING *
+	`
+
+	err = pgxscan.Get(ctx, conn, &updatedLessonType, query, args)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find lesson type with id:", id)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating lesson type:", err)
+		return nil, lessonTypeNameTaken(err)
+	}
+
+	return &updatedLessonType, nil
+}
+
+// DeleteLessonTypeData removes a lesson type no reservation uses yet, booked
+// types are kept and deactivated instead.
+func DeleteLessonTypeData(ctx context.Context, conn IDBConn, id string) (int64, error) {
+	cmdTag, err := conn.Exec(
+		ctx,
+		"DELETE FROM lesson_types WHERE id=$1",
+		id,
+	)
+
+	if err != nil {
+		log.Println("[API] Error deleting lesson type:", err)
+		return 0, err
+	}
+
+	return cmdTag.RowsAffected(), err
+}
+
+// CheckLessonAssignment makes sure a lesson names a bookable lesson type and
+// that its coach is active and teaches the type's specialty, and that other
+// kinds of reservation don't carry a lesson type. The lesson type is
+// returned so the caller can use its default duration.
+func CheckLessonAssignment(ctx context.Context, conn IDBConn, kind models.ReservationKind, lessonTypeId, coachId *pgtype.UUID) (*models.LessonType, error) {
+	validationErr := &ValidationError{}
+
+	if kind != models.ReservationKindLesson {
+		if lessonTypeId != nil {
+			validationErr.Add("lesson_type_id", "only lessons have a lesson type")
+		}
+
+		return nil, validationErr.Err()
+	}
+
+	if lessonTypeId == nil {
+		validationErr.Add("lesson_type_id", "is required for lessons")
+		return nil, validationErr
+	}
+
+	lessonType, err := LoadLessonTypeById(ctx, conn, lessonTypeId.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if lessonType == nil {
+		validationErr.Add("lesson_type_id", "does not exist")
+	} else if !lessonType.IsActive {
+		validationErr.Add("lesson_type_id", "is no longer offered")
+	}
+
+	// without a coach the server assigns one teaching the specialty, nothing to match against
+	if coachId == nil {
+		return lessonType, validationErr.Err()
+	}
+
+	coach, err := LoadCoachById(ctx, conn, *coachId)
+	if err != nil {
+		return nil, err
+	}
+
+	if coach == nil {
+		validationErr.Add("coach_id", "does not exist")
+	}
+
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	violations := &RuleViolationError{}
+	coachName := coachName(*coach)
+
+	if !coach.IsActive {
+		violations.Add(RuleCoachInactive, fmt.Sprintf("%s is not currently coaching.", coachName))
+	}
+
+	if !slices.Contains(coach.Specialties, lessonType.Specialty) {
+		violations.Add(RuleCoachMissingSpecialty, fmt.Sprintf(
+			"%s doesn't teach %s, which %s lessons need.",
+			coachName,
+			lessonType.Specialty,
+			lessonType.Name,
+		))
+	}
+
+	return lessonType, violations.Err()
+}
+
+func applyLessonTypeUpdates(validationErr *ValidationError, lessonType *models.LessonType, updates models.LessonTypeUpdates) {
+	if updates.Name != nil {
+		if strings.TrimSpace(*updates.Name) == "" {
+			validationErr.Add("name", "cannot be empty")
+		} else {
+			lessonType.Name = strings.TrimSpace(*updates.Name)
+		}
+	}
+
+	if updates.Specialty != nil {
+		if !models.IsSpecialty(*updates.Specialty) {
+			validationErr.Add("specialty", "must be pitching, hitting, fielding or catching")
+		} else {
+			lessonType.Specialty = *updates.Specialty
+		}
+	}
+
+	if updates.DefaultDuration != nil {
+		if *updates.DefaultDuration <= 0 {
+			validationErr.Add("default_duration_minutes", "must be greater than 0")
+		} else {
+			lessonType.DefaultDuration = *updates.DefaultDuration
+		}
+	}
+
+	if updates.PriceCents != nil {
+		if *updates.PriceCents < 0 {
+			validationErr.Add("price_cents", "cannot be negative")
+		} else {
+			lessonType.PriceCents = *updates.PriceCents
+		}
+	}
+
+	if updates.IsActive != nil {
+		lessonType.IsActive = *updates.IsActive
+	}
+}
+
+// lessonTypeNameTaken turns the UNIQUE (name) violation into a field error.
+func lessonTypeNameTaken(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		validationErr := &ValidationError{}
+		validationErr.Add("name", "a lesson type with this name already exists")
+		return validationErr
+	}
+
+	return err
+}
@@ -0,0 +1,134 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func Test_CheckLessonAssignment_RequiresLessonType(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	coachId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	// exercise
+	lessonType, err := CheckLessonAssignment(context.Background(), mockConn, models.ReservationKindLesson, nil, &coachId)
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	if _, ok := validationErr.Fields["lesson_type_id"]; !ok || lessonType != nil {
+		t.Fatal("expected a lesson_type_id error, got", validationErr.Fields)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_CheckLessonAssignment_CoachCannotTeach(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	lessonTypeId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	coachId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM lesson_types WHERE id=$1`)).WithArgs(lessonTypeId.String()).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "name", "specialty", "default_duration_minutes", "price_cents", "is_active"}).
+			AddRow(lessonTypeId, "Bullpen session", models.SpecialtyPitching, int32(45), int32(5000), true),
+	)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM coaches WHERE id=$1`)).WithArgs(coachId).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "first_name", "last_name", "is_active", "specialties"}).
+			AddRow(coachId, "Casey", "Stengel", false, []string{models.SpecialtyHitting}),
+	)
+
+	// exercise
+	_, err := CheckLessonAssignment(context.Background(), mockConn, models.ReservationKindLesson, &lessonTypeId, &coachId)
+
+	// verify
+	var violationErr *RuleViolationError
+	if !errors.As(err, &violationErr) {
+		t.Fatal("expected rule violations, got", err)
+	}
+
+	if len(violationErr.Violations) != 2 ||
+		violationErr.Violations[0].Rule != RuleCoachInactive ||
+		violationErr.Violations[1].Rule != RuleCoachMissingSpecialty {
+		t.Fatal("expected the coach to be inactive and missing the specialty, got", violationErr.Violations)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_CheckLessonAssignment_TunnelWithLessonType(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	lessonTypeId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	// exercise
+	_, err := CheckLessonAssignment(context.Background(), mockConn, models.ReservationKindTunnel, &lessonTypeId, nil)
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	if _, ok := validationErr.Fields["lesson_type_id"]; !ok {
+		t.Fatal("expected a lesson_type_id error, got", validationErr.Fields)
+	}
+}
+
+func Test_InsertLessonTypeData_Invalid(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	name := "Bunting"
+	specialty := "bunting"
+	var price int32 = -100
+
+	// exercise
+	result, err := InsertLessonTypeData(context.Background(), mockConn, models.LessonTypeUpdates{
+		Name:       &name,
+		Specialty:  &specialty,
+		PriceCents: &price,
+	})
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	for _, field := range []string{"specialty", "default_duration_minutes", "price_cents"} {
+		if _, ok := validationErr.Fields[field]; !ok {
+			t.Fatal("expected a", field, "error, got", validationErr.Fields)
+		}
+	}
+
+	if result != nil {
+		t.Fatal("expected no result, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
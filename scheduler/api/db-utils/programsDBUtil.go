@@ -0,0 +1,680 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const (
+	RuleProgramCancelled = "program_cancelled"
+	RuleAthleteTooYoung  = "athlete_too_young"
+	RuleAthleteTooOld    = "athlete_too_old"
+)
+
+// ProgramFullError is returned when enrolling in a program that already has
+// max_enrollment athletes.
+type ProgramFullError struct {
+	Program models.Program
+}
+
+func (e *ProgramFullError) Error() string {
+	return fmt.Sprintf("%s is full (%d of %d enrolled)", e.Program.Name, e.Program.EnrolledCount, e.Program.MaxEnrollment)
+}
+
+// ProgramConflictError is returned when one of a program's holds runs into
+// another booking, nothing of the program was written.
+type ProgramConflictError struct {
+	DayNumber int // 1-based
+	Day       models.ProgramDay
+	TunnelId  int32
+	Message   string
+}
+
+func (e *ProgramConflictError) Error() string {
+	return fmt.Sprintf("day %d, tunnel %d: %s", e.DayNumber, e.TunnelId, e.Message)
+}
+
+// programColumns adds the first/last day and enrollment counts to a programs row.
+const programColumns = `
+		SELECT
+			p.*,
+			d.starts_at,
+			d.ends_at,
+			e.enrolled_count,
+			GREATEST(p.max_enrollment - e.enrolled_count, 0) AS spots_left
+		FROM programs p
+		CROSS JOIN LATERAL (
+			SELECT MIN(start_time) AS starts_at, MAX(end_time) AS ends_at FROM sessions
+			WHERE program_id = p.id
+		) d
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*)::int AS enrolled_count FROM program_enrollments
+			WHERE program_id = p.id AND status = 'enrolled'
+		) e
+	`
+
+func LoadProgramData(ctx context.Context, conn IDBConn) ([]models.Program, error) {
+	programs := make([]models.Program, 0)
+
+	err := pgxscan.Select(ctx, conn, &programs, programColumns+` ORDER BY d.starts_at ASC NULLS LAST, p.name ASC`)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return programs, nil
+}
+
+// LoadProgramById loads a program with its days and their holds.
+func LoadProgramById(ctx context.Context, conn IDBConn, id string) (*models.ProgramResult, error) {
+	var result models.ProgramResult
+
+	err := pgxscan.Get(ctx, conn, &result.Program, programColumns+` WHERE p.id = $1`, id)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find program with id:", id)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	result.Sessions = make([]models.Session, 0)
+
+	if err := pgxscan.Select(ctx, conn, &result.Sessions, sessionColumns+` WHERE s.program_id = $1 ORDER BY s.start_time ASC`, id); err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	result.Reservations = make([]models.Reservation, 0)
+
+	query := `
+		SELECT * FROM reservations
+		WHERE session_id IN (SELECT id FROM sessions WHERE program_id = $1)
+		ORDER BY start_time ASC, tunnel_id ASC
+	`
+
+	if err := pgxscan.Select(ctx, conn, &result.Reservations, query, id); err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// CheckProgramSchedule runs the schedule checks for every day of the program:
+// business hours and blackouts once per day and the availability of each
+// coach. Overriding the hours skips all of them, as for single reservations.
+func CheckProgramSchedule(ctx context.Context, conn IDBConn, loc *time.Location, request models.ProgramRequest) error {
+	if err := validateProgram(request); err != nil {
+		return err
+	}
+
+	violations := &RuleViolationError{}
+
+	for _, day := range request.Days {
+		err := EnforceReservationSchedule(ctx, conn, loc, ScheduleCheck{
+			StartTime:      day.StartTime,
+			EndTime:        day.EndTime,
+			Kind:           models.ReservationKindSession,
+			Override:       request.OverrideHours,
+			OverrideReason: request.OverrideReason,
+			OverrideBy:     request.OverrideBy,
+			BookingRules:   true,
+			Now:            time.Now(),
+		})
+
+		if !collectViolations(violations, err) {
+			return err
+		}
+
+		if request.OverrideHours {
+			continue
+		}
+
+		for _, staff := range request.Staff {
+			if staff.CoachId == nil {
+				continue
+			}
+
+			err := CheckCoachAvailability(ctx, conn, loc, *staff.CoachId, day.StartTime, day.EndTime)
+			if !collectViolations(violations, err) {
+				return err
+			}
+		}
+	}
+
+	return violations.Err()
+}
+
+// InsertProgram creates the program, a camp session for each day and a
+// confirmed hold on every staffed tunnel (with its coach) for each day, all
+// in one transaction. A hold running into another booking is returned as a
+// *ProgramConflictError naming the day.
+func InsertProgram(ctx context.Context, conn IDBTxConn, request models.ProgramRequest) (*models.ProgramResult, error) {
+	if err := validateProgram(request); err != nil {
+		return nil, err
+	}
+
+	for i, staff := range request.Staff {
+		if staff.CoachId == nil {
+			continue
+		}
+
+		if err := checkCoachActive(ctx, conn, *staff.CoachId, fmt.Sprintf("staff[%d].coach_id", i)); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	args := pgx.NamedArgs{
+		"name":           request.Name,
+		"description":    request.Description,
+		"price_cents":    request.PriceCents,
+		"max_enrollment": request.MaxEnrollment,
+		"min_age":        request.MinAge,
+		"max_age":        request.MaxAge,
+	}
+
+	const query = `
+		INSERT INTO programs (
+			name,
+			description,
+			price_cents,
+			max_enrollment,
+			min_age,
+			max_age
+		)
+
+		VALUES (
+			@name,
+			@description,
+			@price_cents,
+			@max_enrollment,
+			@min_age,
+			@max_age
+		)
+
+		RETURNING *;
+	`
+
+	result := &models.ProgramResult{
+		Sessions:     make([]models.Session, 0, len(request.Days)),
+		Reservations: make([]models.Reservation, 0, len(request.Days)*len(request.Staff)),
+	}
+
+	if err := pgxscan.Get(ctx, tx, &result.Program, query, args); err != nil {
+		log.Println("[API] Error inserting program:", err)
+		return nil, err
+	}
+
+	var overrideReason, overrideBy *string
+	if request.OverrideHours {
+		overrideReason, overrideBy = request.OverrideReason, request.OverrideBy
+	}
+
+	days := slices.Clone(request.Days)
+	slices.SortFunc(days, func(a, b models.ProgramDay) int {
+		return a.StartTime.Time.Compare(b.StartTime.Time)
+	})
+
+	for i, day := range days {
+		session, err := insertSessionRow(ctx, tx, models.Session{
+			Kind:            models.SessionKindCamp,
+			Name:            fmt.Sprintf("%s (day %d of %d)", request.Name, i+1, len(days)),
+			StartTime:       day.StartTime,
+			EndTime:         day.EndTime,
+			MaxParticipants: request.MaxEnrollment,
+			ProgramId:       &result.Program.Id,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		result.Sessions = append(result.Sessions, *session)
+
+		for _, staff := range request.Staff {
+			hold := models.Reservation{
+				Kind:              models.ReservationKindSession,
+				TunnelId:          &staff.TunnelId,
+				CoachId:           staff.CoachId,
+				CustomerFirstName: session.Name,
+				StartTime:         day.StartTime,
+				Duration:          int32(day.EndTime.Time.Sub(day.StartTime.Time).Minutes()),
+				EndTime:           day.EndTime,
+				Status:            models.ReservationStatusConfirmed,
+				OverrideReason:    overrideReason,
+				OverrideBy:        overrideBy,
+				BookingChannel:    models.BookingChannelWeb,
+				SessionId:         &session.Id,
+			}
+
+			reservation, err := InsertReservationData(ctx, tx, hold)
+			if err != nil {
+				log.Println("[API] Error inserting program hold:", err)
+				return nil, programConflict(err, i+1, day, staff.TunnelId)
+			}
+
+			result.Reservations = append(result.Reservations, *reservation)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing program:", err)
+		return nil, err
+	}
+
+	result.Program.StartsAt = days[0].StartTime
+	result.Program.EndsAt = days[len(days)-1].EndTime
+	result.Program.SpotsLeft = result.Program.MaxEnrollment
+
+	return result, nil
+}
+
+// CancelProgram cancels the program as a unit: every day, every active hold
+// (recording each change) and every enrollment, which is refunded the full
+// program price.
+func CancelProgram(ctx context.Context, conn IDBTxConn, id string, change models.ReservationStatusChangeRequest) (*models.ProgramCancelResult, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	args := pgx.NamedArgs{
+		"program_id": id,
+		"changed_by": change.ChangedBy,
+		"reason":     change.Reason,
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE programs
+		SET cancelled_at = now(), cancel_reason = @reason, updated_at = now()
+		WHERE id = @program_id AND cancelled_at IS NULL
+	`, args)
+	if err != nil {
+		log.Println("[API] Error cancelling program:", err)
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE sessions
+		SET cancelled_at = now(), updated_at = now()
+		WHERE program_id = @program_id AND cancelled_at IS NULL
+	`, args)
+	if err != nil {
+		log.Println("[API] Error cancelling program days:", err)
+		return nil, err
+	}
+
+	result := &models.ProgramCancelResult{
+		Reservations: make([]models.Reservation, 0),
+		Refunds:      make([]models.ProgramEnrollment, 0),
+	}
+
+	query := `
+		WITH targets AS (
+			SELECT id, status FROM reservations
+			WHERE session_id IN (SELECT id FROM sessions WHERE program_id = @program_id)
+				AND status IN ('held', 'confirmed')
+			FOR UPDATE
+		), cancelled AS (
+			UPDATE reservations
+			SET status = 'cancelled', hold_expires_at = NULL, updated_at = now()
+			WHERE id IN (SELECT id FROM targets)
+			RETURNING *
+		), history AS (
+			INSERT INTO reservation_status_changes (reservation_id, from_status, to_status, changed_by, reason)
+			SELECT id, status, 'cancelled', @changed_by, @reason FROM targets
+		)
+		SELECT * FROM cancelled ORDER BY start_time ASC, tunnel_id ASC
+	`
+
+	if err := pgxscan.Select(ctx, tx, &result.Reservations, query, args); err != nil {
+		log.Println("[API] Error cancelling program holds:", err)
+		return nil, err
+	}
+
+	query = `
+		UPDATE program_enrollments e
+		SET status = 'refunded', refund_cents = p.price_cents, withdrawn_at = now(), updated_at = now()
+		FROM programs p
+		WHERE p.id = e.program_id AND e.program_id = @program_id AND e.status = 'enrolled'
+		RETURNING e.*
+	`
+
+	if err := pgxscan.Select(ctx, tx, &result.Refunds, query, args); err != nil {
+		log.Println("[API] Error refunding program enrollments:", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing program cancellation:", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func LoadProgramEnrollments(ctx context.Context, conn IDBConn, programId string) ([]models.ProgramEnrollment, error) {
+	enrollments := make([]models.ProgramEnrollment, 0)
+
+	query := `
+		SELECT * FROM program_enrollments
+		WHERE program_id = $1
+		ORDER BY enrolled_at ASC
+	`
+
+	err := pgxscan.Select(ctx, conn, &enrollments, query, programId)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return enrollments, nil
+}
+
+// EnrollInProgram enrolls an athlete in every day of a program. The program
+// row is locked while counting so the cap can't be overshot. Age limits are
+// checked against the athlete's age on the first day (local date). Returns
+// nil, nil when the program doesn't exist.
+func EnrollInProgram(ctx context.Context, conn IDBTxConn, loc *time.Location, programId string, enrollment models.ProgramEnrollment) (*models.ProgramEnrollment, error) {
+	if err := validateProgramEnrollment(enrollment); err != nil {
+		return nil, err
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var program models.Program
+	err = pgxscan.Get(ctx, tx, &program, programColumns+` WHERE p.id = $1 FOR UPDATE OF p`, programId)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find program with id:", programId)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error locking program:", err)
+		return nil, err
+	}
+
+	violations := &RuleViolationError{}
+
+	if program.CancelledAt.Valid {
+		violations.Add(RuleProgramCancelled, fmt.Sprintf("%s has been cancelled.", program.Name))
+	}
+
+	if program.StartsAt.Valid {
+		age := AgeOn(enrollment.AthleteBirthDate, models.LocalDate(program.StartsAt.Time, loc))
+
+		if program.MinAge != nil && age < *program.MinAge {
+			violations.Add(RuleAthleteTooYoung, fmt.Sprintf("%s is for ages %s, the athlete will be %d.", program.Name, describeAgeRange(program), age))
+		}
+
+		if program.MaxAge != nil && age > *program.MaxAge {
+			violations.Add(RuleAthleteTooOld, fmt.Sprintf("%s is for ages %s, the athlete will be %d.", program.Name, describeAgeRange(program), age))
+		}
+	}
+
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	if program.EnrolledCount >= program.MaxEnrollment {
+		return nil, &ProgramFullError{Program: program}
+	}
+
+	args := pgx.NamedArgs{
+		"program_id":         program.Id,
+		"athlete_first_name": enrollment.AthleteFirstName,
+		"athlete_last_name":  enrollment.AthleteLastName,
+		"athlete_birth_date": enrollment.AthleteBirthDate,
+		"contact_phone":      enrollment.ContactPhone,
+		"contact_email":      enrollment.ContactEmail,
+		"notes":              enrollment.Notes,
+	}
+
+	const query = `
+		INSERT INTO program_enrollments (
+			program_id,
+			athlete_first_name,
+			athlete_last_name,
+			athlete_birth_date,
+			contact_phone,
+			contact_email,
+			notes
+		)
+
+		VALUES (
+			@program_id,
+			@athlete_first_name,
+			@athlete_last_name,
+			@athlete_birth_date,
+			@contact_phone,
+			@contact_email,
+			@notes
+		)
+
+		RETURNING *;
+	`
+
+	var out models.ProgramEnrollment
+	if err := pgxscan.Get(ctx, tx, &out, query, args); err != nil {
+		log.Println("[API] Error inserting program enrollment:", err)
+		return nil, athleteEnrolled(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing program enrollment:", err)
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// WithdrawFromProgram takes an athlete out of the whole program, refunding
+// the full program price when refund is set. Returns nil, nil when there is
+// no such active enrollment.
+func WithdrawFromProgram(ctx context.Context, conn IDBConn, programId, enrollmentId string, refund bool) (*models.ProgramEnrollment, error) {
+	var enrollment models.ProgramEnrollment
+
+	args := pgx.NamedArgs{
+		"id":         enrollmentId,
+		"program_id": programId,
+		"refund":     refund,
+	}
+
+	query := `
+		UPDATE program_enrollments e
+		SET
+			status = CASE WHEN @refund THEN 'refunded' ELSE 'withdrawn' END::program_enrollment_status,
+			refund_cents = CASE WHEN @refund THEN p.price_cents END,
+			withdrawn_at = now(),
+			updated_at = now()
+		FROM programs p
+		WHERE p.id = e.program_id AND e.id = @id AND e.program_id = @program_id AND e.status = 'enrolled'
+		RETURNING e.*
+	`
+
+	err := pgxscan.Get(ctx, conn, &enrollment, query, args)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] No active program enrollment with id:", enrollmentId)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error withdrawing from program:", err)
+		return nil, err
+	}
+
+	return &enrollment, nil
+}
+
+// AgeOn is how old someone born on birthDate is on the date on.
+func AgeOn(birthDate, on pgtype.Date) int32 {
+	born, day := birthDate.Time, on.Time
+
+	age := int32(day.Year() - born.Year())
+	if day.Month() < born.Month() || (day.Month() == born.Month() && day.Day() < born.Day()) {
+		age--
+	}
+
+	return age
+}
+
+// programConflict swaps an exclusion violation for a *ProgramConflictError.
+func programConflict(err error, dayNumber int, day models.ProgramDay, tunnelId int32) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23P01" {
+		return err
+	}
+
+	message := "The tunnel is already booked for that time."
+	if info, ok := constraintMessages[pgErr.ConstraintName]; ok {
+		message = info.message
+	}
+
+	return &ProgramConflictError{DayNumber: dayNumber, Day: day, TunnelId: tunnelId, Message: message}
+}
+
+// athleteEnrolled turns the one-active-enrollment-per-athlete violation into
+// a field error.
+func athleteEnrolled(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		validationErr := &ValidationError{}
+		validationErr.Add("athlete_first_name", "this athlete is already enrolled")
+		return validationErr
+	}
+
+	return err
+}
+
+func describeAgeRange(program models.Program) string {
+	switch {
+	case program.MinAge != nil && program.MaxAge != nil:
+		return fmt.Sprintf("%d-%d", *program.MinAge, *program.MaxAge)
+	case program.MinAge != nil:
+		return fmt.Sprintf("%d and up", *program.MinAge)
+	default:
+		return fmt.Sprintf("%d and under", *program.MaxAge)
+	}
+}
+
+func validateProgram(request models.ProgramRequest) error {
+	validationErr := &ValidationError{}
+
+	if strings.TrimSpace(request.Name) == "" {
+		validationErr.Add("name", "is required")
+	}
+
+	if request.PriceCents < 0 {
+		validationErr.Add("price_cents", "must be 0 or more")
+	}
+
+	if request.MaxEnrollment <= 0 {
+		validationErr.Add("max_enrollment", "must be greater than 0")
+	}
+
+	if request.MinAge != nil && *request.MinAge < 0 {
+		validationErr.Add("min_age", "must be 0 or more")
+	}
+
+	if request.MaxAge != nil && *request.MaxAge < 0 {
+		validationErr.Add("max_age", "must be 0 or more")
+	}
+
+	if request.MinAge != nil && request.MaxAge != nil && *request.MaxAge < *request.MinAge {
+		validationErr.Add("max_age", "must be at least min_age")
+	}
+
+	if len(request.Days) == 0 {
+		validationErr.Add("days", "must list at least one day")
+	}
+
+	for i, day := range request.Days {
+		if !day.StartTime.Valid {
+			validationErr.Add(fmt.Sprintf("days[%d].start_time", i), "is required")
+		}
+
+		if !day.EndTime.Valid {
+			validationErr.Add(fmt.Sprintf("days[%d].end_time", i), "is required")
+		}
+
+		if day.StartTime.Valid && day.EndTime.Valid && !day.StartTime.Time.Before(day.EndTime.Time) {
+			validationErr.Add(fmt.Sprintf("days[%d].end_time", i), "must be after start_time")
+		}
+	}
+
+	if len(request.Staff) == 0 {
+		validationErr.Add("staff", "must list at least one tunnel")
+	}
+
+	tunnels := make(map[int32]bool, len(request.Staff))
+	coaches := make(map[pgtype.UUID]bool, len(request.Staff))
+	for i, staff := range request.Staff {
+		if tunnels[staff.TunnelId] {
+			validationErr.Add(fmt.Sprintf("staff[%d].tunnel_id", i), fmt.Sprintf("tunnel %d is listed twice", staff.TunnelId))
+		}
+		tunnels[staff.TunnelId] = true
+
+		if staff.CoachId == nil {
+			continue
+		}
+
+		if coaches[*staff.CoachId] {
+			validationErr.Add(fmt.Sprintf("staff[%d].coach_id", i), "a coach can only run one tunnel at a time")
+		}
+		coaches[*staff.CoachId] = true
+	}
+
+	return validationErr.Err()
+}
+
+func validateProgramEnrollment(enrollment models.ProgramEnrollment) error {
+	validationErr := &ValidationError{}
+
+	if strings.TrimSpace(enrollment.AthleteFirstName) == "" {
+		validationErr.Add("athlete_first_name", "is required")
+	}
+
+	if strings.TrimSpace(enrollment.AthleteLastName) == "" {
+		validationErr.Add("athlete_last_name", "is required")
+	}
+
+	if !enrollment.AthleteBirthDate.Valid {
+		validationErr.Add("athlete_birth_date", "is required")
+	}
+
+	if strings.TrimSpace(enrollment.ContactPhone) == "" {
+		validationErr.Add("contact_phone", "is required")
+	}
+
+	return validationErr.Err()
+}
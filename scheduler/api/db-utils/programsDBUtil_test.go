@@ -0,0 +1,208 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const lockProgramQuery = `WHERE p.id = $1 FOR UPDATE OF p`
+
+// testProgramRequest is a 10-13 camp on consecutive saturdays at 09:00-12:00
+// on tunnels 1 and 2, tunnel 1 run by coachId.
+func testProgramRequest(coachId pgtype.UUID, saturdays int) models.ProgramRequest {
+	minAge, maxAge := int32(10), int32(13)
+
+	request := models.ProgramRequest{
+		Name:          "Winter Hitting Camp",
+		PriceCents:    20000,
+		MaxEnrollment: 12,
+		MinAge:        &minAge,
+		MaxAge:        &maxAge,
+		Staff: []models.ProgramStaff{
+			{TunnelId: 1, CoachId: &coachId},
+			{TunnelId: 2},
+		},
+	}
+
+	for i := range saturdays {
+		start := time.Date(2025, 12, 6+7*i, 9, 0, 0, 0, time.UTC)
+		request.Days = append(request.Days, models.ProgramDay{
+			StartTime: pgtype.Timestamptz{Time: start, Valid: true},
+			EndTime:   pgtype.Timestamptz{Time: start.Add(3 * time.Hour), Valid: true},
+		})
+	}
+
+	return request
+}
+
+func expectActiveCoach(mockConn pgxmock.PgxConnIface, coachId pgtype.UUID) {
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM coaches WHERE id=$1`)).WithArgs(coachId).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "first_name", "last_name", "is_active"}).AddRow(coachId, "Ann", "Able", true),
+	)
+}
+
+func Test_InsertProgram(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	coachId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	request := testProgramRequest(coachId, 2)
+	programId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	expectActiveCoach(mockConn, coachId)
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO programs")).WithArgs(anyArgs(6)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "name", "max_enrollment"}).AddRow(programId, request.Name, int32(12)),
+	)
+
+	for range request.Days {
+		sessionId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+		mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO sessions")).WithArgs(anyArgs(9)...).WillReturnRows(
+			pgxmock.NewRows([]string{"id", "session_kind", "program_id"}).AddRow(sessionId, models.SessionKindCamp, &programId),
+		)
+
+		for range request.Staff {
+			mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO reservations")).WithArgs(anyArgs(23)...).WillReturnRows(
+				pgxmock.NewRows([]string{"id", "session_id"}).AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, &sessionId),
+			)
+		}
+	}
+
+	mockConn.ExpectCommit()
+	mockConn.ExpectRollback()
+
+	// exercise
+	result, err := InsertProgram(context.Background(), mockConn, request)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(result.Sessions) != 2 || len(result.Reservations) != 4 {
+		t.Fatal("expected 2 days with 2 holds each, got", len(result.Sessions), "days and", len(result.Reservations), "holds")
+	}
+
+	if result.Program.StartsAt != request.Days[0].StartTime || result.Program.EndsAt != request.Days[1].EndTime {
+		t.Fatal("expected the program to span both saturdays, got", result.Program.StartsAt, result.Program.EndsAt)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_InsertProgram_Conflict(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	coachId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	request := testProgramRequest(coachId, 1)
+
+	expectActiveCoach(mockConn, coachId)
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO programs")).WithArgs(anyArgs(6)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id"}).AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}),
+	)
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO sessions")).WithArgs(anyArgs(9)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id"}).AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}),
+	)
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO reservations")).WithArgs(anyArgs(23)...).
+		WillReturnError(&pgconn.PgError{Code: "23P01", ConstraintName: "tunnel_no_overlap"})
+	mockConn.ExpectRollback()
+
+	// exercise
+	result, err := InsertProgram(context.Background(), mockConn, request)
+
+	// verify
+	var conflictErr *ProgramConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatal("expected a program conflict, got", err)
+	}
+
+	if conflictErr.DayNumber != 1 || conflictErr.TunnelId != 1 {
+		t.Fatal("expected day 1 on tunnel 1 to conflict, got", conflictErr)
+	}
+
+	if result != nil {
+		t.Fatal("expected nothing to be written, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_EnrollInProgram_AgeAndCap(t *testing.T) {
+	cases := []struct {
+		name      string
+		birthDate time.Time
+		enrolled  int32
+		rule      string
+	}{
+		// turns 10 the day after the first day
+		{"too young", time.Date(2015, 12, 7, 0, 0, 0, 0, time.UTC), 0, RuleAthleteTooYoung},
+		{"too old", time.Date(2011, 1, 1, 0, 0, 0, 0, time.UTC), 0, RuleAthleteTooOld},
+		{"full", time.Date(2014, 5, 1, 0, 0, 0, 0, time.UTC), 12, ""},
+	}
+
+	for _, tc := range cases {
+		// setup
+		mockConn, _ := pgxmock.NewConn()
+
+		programId := uuid.New().String()
+		minAge, maxAge := int32(10), int32(13)
+		startsAt := pgtype.Timestamptz{Time: time.Date(2025, 12, 6, 9, 0, 0, 0, time.UTC), Valid: true}
+
+		mockConn.ExpectBegin()
+		mockConn.ExpectQuery(regexp.QuoteMeta(lockProgramQuery)).WithArgs(programId).WillReturnRows(
+			pgxmock.NewRows([]string{"name", "max_enrollment", "min_age", "max_age", "starts_at", "enrolled_count"}).
+				AddRow("Winter Hitting Camp", int32(12), &minAge, &maxAge, startsAt, tc.enrolled),
+		)
+		mockConn.ExpectRollback()
+
+		enrollment := models.ProgramEnrollment{
+			AthleteFirstName: "Timmy",
+			AthleteLastName:  "Doe",
+			AthleteBirthDate: pgtype.Date{Time: tc.birthDate, Valid: true},
+			ContactPhone:     "5551234567",
+		}
+
+		// exercise
+		result, err := EnrollInProgram(context.Background(), mockConn, time.UTC, programId, enrollment)
+
+		// verify
+		if result != nil {
+			t.Fatal(tc.name, "expected nobody to be enrolled, got", result)
+		}
+
+		var violationErr *RuleViolationError
+		var fullErr *ProgramFullError
+
+		switch {
+		case tc.rule == "" && !errors.As(err, &fullErr):
+			t.Fatal(tc.name, "expected the program to be full, got", err)
+		case tc.rule != "" && (!errors.As(err, &violationErr) || violationErr.Violations[0].Rule != tc.rule):
+			t.Fatal(tc.name, "expected a", tc.rule, "violation, got", err)
+		}
+
+		if err = mockConn.ExpectationsWereMet(); err != nil {
+			t.Fatal(tc.name, err)
+		}
+
+		mockConn.Close(context.Background())
+	}
+}
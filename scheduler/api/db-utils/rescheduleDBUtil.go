@@ -0,0 +1,362 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// MaxRescheduleAlternatives caps how many alternative slots a conflicting
+// reschedule suggests.
+const MaxRescheduleAlternatives = 10
+
+// RescheduleConflictError is returned when the new time is already taken,
+// the reservation was left where it was.
+type RescheduleConflictError struct {
+	Blocking []models.Reservation
+}
+
+func (e *RescheduleConflictError) Error() string {
+	return fmt.Sprintf("%d reservation(s) are in the way of the new time", len(e.Blocking))
+}
+
+// PlanReschedule applies a reschedule request to a copy of the reservation
+// and checks that the result is a sensible booking.
+func PlanReschedule(existing models.Reservation, request models.RescheduleRequest) (models.Reservation, error) {
+	moved := existing
+	validationErr := &ValidationError{}
+
+	if !request.StartTime.Valid {
+		validationErr.Add("start_time", "is required")
+		return moved, validationErr.Err()
+	}
+
+	moved.StartTime = request.StartTime
+	moved.EndTime = pgtype.Timestamptz{
+		Time:  request.StartTime.Time.Add(existing.EndTime.Time.Sub(existing.StartTime.Time)),
+		Valid: true,
+	}
+
+	if request.EndTime != nil {
+		moved.EndTime = *request.EndTime
+	}
+
+	if !moved.EndTime.Valid || !moved.StartTime.Time.Before(moved.EndTime.Time) {
+		validationErr.Add("end_time", "must be after start_time")
+	}
+
+	moved.Duration = int32(moved.EndTime.Time.Sub(moved.StartTime.Time).Minutes())
+
+	if request.TunnelId != nil {
+		moved.TunnelId = request.TunnelId
+	}
+
+	if request.CoachId != nil {
+		if existing.Kind != models.ReservationKindLesson {
+			validationErr.Add("coach_id", "must be empty for tunnel rentals")
+		}
+
+		moved.CoachId = request.CoachId
+	}
+
+	moved.OverrideReason, moved.OverrideBy = nil, nil
+	if request.OverrideHours {
+		moved.OverrideReason, moved.OverrideBy = request.OverrideReason, request.OverrideBy
+	}
+
+	return moved, validationErr.Err()
+}
+
+// RescheduleReservation moves an active reservation to the time, tunnel and
+// coach of moved in a single statement, so the booking either moves whole or
+// stays put. It returns nil when the reservation is no longer held or
+// confirmed.
+func RescheduleReservation(ctx context.Context, conn IDBConn, moved models.Reservation) (*models.Reservation, error) {
+	var out models.Reservation
+
+	args := pgx.NamedArgs{
+		"id":               moved.Id,
+		"tunnel_id":        moved.TunnelId,
+		"coach_id":         moved.CoachId,
+		"start_time":       moved.StartTime,
+		"end_time":         moved.EndTime,
+		"duration_minutes": moved.Duration,
+		"override_reason":  moved.OverrideReason,
+		"override_by":      moved.OverrideBy,
+	}
+
+	query := `
+		UPDATE reservations
+		SET
+			tunnel_id = @tunnel_id,
+			coach_id = @coach_id,
+			start_time = @start_time,
+			end_time = @end_time,
+			duration_minutes = @duration_minutes,
+			override_reason = COALESCE(@override_reason, override_reason),
+			override_by = COALESCE(@override_by, override_by),
+			updated_at = now()
+		WHERE id = @id AND status IN ('held', 'confirmed')
+		RETURNING *
+	`
+
+	err := pgxscan.Get(ctx, conn, &out, query, args)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find an active reservation to reschedule with id:", moved.Id.String())
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error rescheduling reservation:", err)
+		return nil, rescheduleConflict(ctx, conn, err, moved)
+	}
+
+	return &out, nil
+}
+
+// rescheduleConflict swaps an exclusion violation for a
+// *RescheduleConflictError listing the bookings on the new tunnel or with the
+// new coach that are in the way, turnover included.
+func rescheduleConflict(ctx context.Context, conn IDBConn, err error, moved models.Reservation) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23P01" {
+		return err
+	}
+
+	tunnelBuffer, coachBuffer, loadErr := LoadTurnoverBuffers(ctx, conn, moved.TunnelId, moved.CoachId)
+	if loadErr != nil {
+		return err
+	}
+
+	nearby, loadErr := LoadActiveReservationsWithTurnover(ctx, conn, moved.StartTime,
+		pgtype.Timestamptz{Time: moved.EndTime.Time.Add(max(tunnelBuffer, coachBuffer)), Valid: true},
+	)
+	if loadErr != nil {
+		return err
+	}
+
+	slot := reservationInterval(moved)
+
+	blocking := make([]models.Reservation, 0)
+	for _, reservation := range nearby {
+		if reservation.Id == moved.Id {
+			continue
+		}
+
+		sameTunnel := moved.TunnelId != nil && reservation.TunnelId != nil && *reservation.TunnelId == *moved.TunnelId &&
+			tunnelBusyInterval(reservation, tunnelBuffer).Overlaps(slot)
+		sameCoach := moved.CoachId != nil && reservation.CoachId != nil && *reservation.CoachId == *moved.CoachId &&
+			coachBusyInterval(reservation, coachBuffer).Overlaps(slot)
+
+		if sameTunnel || sameCoach {
+			blocking = append(blocking, reservation)
+		}
+	}
+
+	return &RescheduleConflictError{Blocking: blocking}
+}
+
+// SuggestRescheduleAlternatives looks for open slots near a reschedule that
+// conflicted: the same tunnel at other times that day, other tunnels at the
+// requested time and, for lessons, other active coaches at the requested time
+// who teach the lesson type's specialty (or share one with the coach when the
+// lesson has no type). The reservation's own current slot counts as free.
+func SuggestRescheduleAlternatives(ctx context.Context, conn IDBConn, loc *time.Location, moved models.Reservation) ([]models.AlternativeSlot, error) {
+	alternatives := make([]models.AlternativeSlot, 0)
+
+	requested := moved.StartTime.Time
+	localStart := requested.In(loc)
+	at := models.NewTimeOfDay(localStart.Hour(), localStart.Minute())
+	duration := moved.EndTime.Time.Sub(requested)
+
+	base := AvailabilityQuery{
+		Date:      models.LocalDate(requested, loc),
+		Duration:  duration,
+		Kind:      moved.Kind,
+		ExcludeId: &moved.Id,
+	}
+
+	if moved.Kind == models.ReservationKindLesson {
+		base.CoachId = moved.CoachId
+	}
+
+	// starting the day at the requested time in steps of the booking's length
+	// makes each tunnel's first slot the requested one when it is free
+	atRequested := base
+	atRequested.From = &at
+	atRequested.Granularity = duration
+
+	if moved.TunnelId != nil {
+		query := base
+		query.TunnelId = moved.TunnelId
+
+		availability, err := LoadAvailability(ctx, conn, loc, query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tunnel := range availability.Tunnels {
+			for _, slot := range tunnel.Slots {
+				if !slot.StartTime.Equal(requested) {
+					alternatives = append(alternatives, alternativeSlot(models.AlternativeSameTunnel, tunnel.TunnelId, base.CoachId, slot, requested))
+				}
+			}
+		}
+	}
+
+	availability, err := LoadAvailability(ctx, conn, loc, atRequested)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tunnel := range availability.Tunnels {
+		if moved.TunnelId != nil && tunnel.TunnelId == *moved.TunnelId {
+			continue
+		}
+
+		if len(tunnel.Slots) > 0 && tunnel.Slots[0].StartTime.Equal(requested) {
+			alternatives = append(alternatives, alternativeSlot(models.AlternativeOtherTunnel, tunnel.TunnelId, base.CoachId, tunnel.Slots[0], requested))
+		}
+	}
+
+	if moved.Kind != models.ReservationKindLesson || moved.CoachId == nil {
+		return RankAlternatives(alternatives), nil
+	}
+
+	coaches, err := LoadCoachesData(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	// a typed lesson needs its specialty, older lessons any the coach shares
+	specialties := make(map[string]bool)
+	if moved.LessonTypeId != nil {
+		lessonType, err := LoadLessonTypeById(ctx, conn, moved.LessonTypeId.String())
+		if err != nil {
+			return nil, err
+		}
+
+		if lessonType != nil {
+			specialties[lessonType.Specialty] = true
+		}
+	}
+
+	for _, coach := range coaches {
+		if coach.Id == *moved.CoachId && moved.LessonTypeId == nil {
+			for _, specialty := range coach.Specialties {
+				specialties[specialty] = true
+			}
+		}
+	}
+
+	for _, coach := range coaches {
+		if coach.Id == *moved.CoachId || !coach.IsActive || !sharesSpecialty(coach, specialties) {
+			continue
+		}
+
+		query := atRequested
+		query.CoachId = &coach.Id
+
+		availability, err := LoadAvailability(ctx, conn, loc, query)
+		if err != nil {
+			return nil, err
+		}
+
+		var best *models.TunnelAvailability
+		for i, tunnel := range availability.Tunnels {
+			if len(tunnel.Slots) == 0 || !tunnel.Slots[0].StartTime.Equal(requested) {
+				continue
+			}
+
+			// keep the lesson in its tunnel when that one is free
+			if best == nil || (moved.TunnelId != nil && tunnel.TunnelId == *moved.TunnelId) {
+				best = &availability.Tunnels[i]
+			}
+		}
+
+		if best != nil {
+			alternatives = append(alternatives, alternativeSlot(models.AlternativeOtherCoach, best.TunnelId, &coach.Id, best.Slots[0], requested))
+		}
+	}
+
+	return RankAlternatives(alternatives), nil
+}
+
+var alternativeKindOrder = map[models.AlternativeKind]int{
+	models.AlternativeSameTunnel:  0,
+	models.AlternativeOtherTunnel: 1,
+	models.AlternativeOtherCoach:  2,
+}
+
+// RankAlternatives orders alternatives closest to the requested start first,
+// then by how little else changes (same tunnel, other tunnel, other coach),
+// keeps the best MaxRescheduleAlternatives and numbers them from 1.
+func RankAlternatives(alternatives []models.AlternativeSlot) []models.AlternativeSlot {
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		a, b := alternatives[i], alternatives[j]
+
+		if distanceA, distanceB := absMinutes(a.OffsetMinutes), absMinutes(b.OffsetMinutes); distanceA != distanceB {
+			return distanceA < distanceB
+		}
+
+		if alternativeKindOrder[a.Kind] != alternativeKindOrder[b.Kind] {
+			return alternativeKindOrder[a.Kind] < alternativeKindOrder[b.Kind]
+		}
+
+		// earlier rather than later for the same distance
+		if a.OffsetMinutes != b.OffsetMinutes {
+			return a.OffsetMinutes < b.OffsetMinutes
+		}
+
+		return a.TunnelId < b.TunnelId
+	})
+
+	if len(alternatives) > MaxRescheduleAlternatives {
+		alternatives = alternatives[:MaxRescheduleAlternatives]
+	}
+
+	for i := range alternatives {
+		alternatives[i].Rank = i + 1
+	}
+
+	return alternatives
+}
+
+func alternativeSlot(kind models.AlternativeKind, tunnelId int32, coachId *pgtype.UUID, slot models.TimeSlot, requested time.Time) models.AlternativeSlot {
+	return models.AlternativeSlot{
+		Kind:          kind,
+		TunnelId:      tunnelId,
+		CoachId:       coachId,
+		StartTime:     slot.StartTime,
+		EndTime:       slot.EndTime,
+		OffsetMinutes: int32(slot.StartTime.Sub(requested).Minutes()),
+	}
+}
+
+func sharesSpecialty(coach models.Coach, specialties map[string]bool) bool {
+	for _, specialty := range coach.Specialties {
+		if specialties[specialty] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func absMinutes(minutes int32) int32 {
+	if minutes < 0 {
+		return -minutes
+	}
+
+	return minutes
+}
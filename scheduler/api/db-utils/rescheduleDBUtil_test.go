@@ -0,0 +1,182 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func testRescheduleReservation() models.Reservation {
+	start := time.Date(2025, 9, 5, 22, 0, 0, 0, time.UTC)
+	var tunnelId int32 = 1
+
+	return models.Reservation{
+		Id:        pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true},
+		Kind:      models.ReservationKindTunnel,
+		TunnelId:  &tunnelId,
+		StartTime: pgtype.Timestamptz{Time: start, Valid: true},
+		Duration:  60,
+		EndTime:   pgtype.Timestamptz{Time: start.Add(time.Hour), Valid: true},
+		Status:    models.ReservationStatusConfirmed,
+	}
+}
+
+func Test_PlanReschedule_KeepsDuration(t *testing.T) {
+	// setup
+	existing := testRescheduleReservation()
+	newStart := existing.StartTime.Time.Add(2 * time.Hour)
+	var tunnelId int32 = 2
+
+	// exercise
+	moved, err := PlanReschedule(existing, models.RescheduleRequest{
+		StartTime: pgtype.Timestamptz{Time: newStart, Valid: true},
+		TunnelId:  &tunnelId,
+	})
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if !moved.EndTime.Time.Equal(newStart.Add(time.Hour)) {
+		t.Fatal("expected the hour long booking to end at", newStart.Add(time.Hour), "got", moved.EndTime.Time)
+	}
+
+	if *moved.TunnelId != 2 || *existing.TunnelId != 1 {
+		t.Fatal("expected only the moved copy to change tunnel")
+	}
+}
+
+func Test_PlanReschedule_Invalid(t *testing.T) {
+	// setup
+	existing := testRescheduleReservation()
+	coachId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	endTime := existing.StartTime
+
+	// exercise
+	_, err := PlanReschedule(existing, models.RescheduleRequest{
+		StartTime: existing.StartTime,
+		EndTime:   &endTime,
+		CoachId:   &coachId,
+	})
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected a validation error, got", err)
+	}
+
+	if len(validationErr.Fields) != 2 {
+		t.Fatal("expected end_time and coach_id to be rejected, got", validationErr.Fields)
+	}
+}
+
+func Test_RescheduleReservation(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	moved := testRescheduleReservation()
+
+	mockConn.ExpectQuery(regexp.QuoteMeta("UPDATE reservations")).WithArgs(anyArgs(8)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "tunnel_id", "start_time", "end_time"}).
+			AddRow(moved.Id, moved.TunnelId, moved.StartTime, moved.EndTime),
+	)
+
+	// exercise
+	result, err := RescheduleReservation(context.Background(), mockConn, moved)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if result == nil || !result.StartTime.Time.Equal(moved.StartTime.Time) {
+		t.Fatal("expected the moved reservation back, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_RescheduleReservation_Conflict(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	moved := testRescheduleReservation()
+	blockerId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	var otherTunnel int32 = 3
+
+	mockConn.ExpectQuery(regexp.QuoteMeta("UPDATE reservations")).WithArgs(anyArgs(8)...).
+		WillReturnError(&pgconn.PgError{Code: "23P01"})
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM tunnels WHERE id=$1`)).WithArgs(*moved.TunnelId).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "name", "is_active", "turnover_buffer_minutes"}).
+			AddRow(*moved.TunnelId, "Tunnel 1", true, int32(5)),
+	)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(turnoverReservationsQuery)).WithArgs(anyArgs(2)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "tunnel_id", "start_time", "end_time"}).
+			AddRow(moved.Id, moved.TunnelId, moved.StartTime, moved.EndTime).
+			AddRow(blockerId, moved.TunnelId, moved.StartTime, moved.EndTime).
+			AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, &otherTunnel, moved.StartTime, moved.EndTime),
+	)
+
+	// exercise
+	_, err := RescheduleReservation(context.Background(), mockConn, moved)
+
+	// verify
+	var conflictErr *RescheduleConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatal("expected a reschedule conflict, got", err)
+	}
+
+	if len(conflictErr.Blocking) != 1 || conflictErr.Blocking[0].Id != blockerId {
+		t.Fatal("expected only the booking on the same tunnel to block, got", conflictErr.Blocking)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_RankAlternatives(t *testing.T) {
+	// setup
+	alternatives := []models.AlternativeSlot{
+		{Kind: models.AlternativeSameTunnel, TunnelId: 1, OffsetMinutes: 60},
+		{Kind: models.AlternativeOtherCoach, TunnelId: 1, OffsetMinutes: 0},
+		{Kind: models.AlternativeSameTunnel, TunnelId: 1, OffsetMinutes: -60},
+		{Kind: models.AlternativeOtherTunnel, TunnelId: 2, OffsetMinutes: 0},
+	}
+
+	// exercise
+	result := RankAlternatives(alternatives)
+
+	// verify
+	expected := []models.AlternativeKind{
+		models.AlternativeOtherTunnel,
+		models.AlternativeOtherCoach,
+		models.AlternativeSameTunnel,
+		models.AlternativeSameTunnel,
+	}
+
+	for i, alternative := range result {
+		if alternative.Kind != expected[i] || alternative.Rank != i+1 {
+			t.Fatal("unexpected ranking:", result)
+		}
+	}
+
+	if result[2].OffsetMinutes != -60 {
+		t.Fatal("expected the earlier slot first when equally near, got", result[2])
+	}
+}
@@ -0,0 +1,346 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// GroupConflictError is returned when one or more tunnels of a group are
+// already booked, nothing of the group was written.
+type GroupConflictError struct {
+	Conflicts []models.Reservation
+}
+
+func (e *GroupConflictError) Error() string {
+	return fmt.Sprintf("%d tunnel(s) in the group are already booked", len(e.Conflicts))
+}
+
+// InsertReservationGroup books every tunnel of the request in one
+// transaction, either all member reservations are created or none are.
+func InsertReservationGroup(ctx context.Context, conn IDBTxConn, request models.ReservationGroupRequest, holdExpiresAt pgtype.Timestamptz) (*models.ReservationGroupResult, error) {
+	if err := validateReservationGroup(request); err != nil {
+		return nil, err
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	args := pgx.NamedArgs{
+		"name":                request.Name,
+		"customer_first_name": request.CustomerFirstName,
+		"customer_last_name":  request.CustomerLastName,
+		"customer_phone":      request.CustomerPhone,
+		"customer_email":      request.CustomerEmail,
+		"notes":               request.Notes,
+	}
+
+	const query = `
+		INSERT INTO reservation_groups (
+			name,
+			customer_first_name,
+			customer_last_name,
+			customer_phone,
+			customer_email,
+			notes
+		)
+
+		VALUES (
+			@name,
+			@customer_first_name,
+			@customer_last_name,
+			@customer_phone,
+			@customer_email,
+			@notes
+		)
+
+		RETURNING *;
+	`
+
+	result := &models.ReservationGroupResult{Reservations: make([]models.Reservation, 0, len(request.TunnelIds))}
+	if err := pgxscan.Get(ctx, tx, &result.Group, query, args); err != nil {
+		log.Println("[API] Error inserting reservation group:", err)
+		return nil, err
+	}
+
+	var overrideReason, overrideBy *string
+	if request.OverrideHours {
+		overrideReason, overrideBy = request.OverrideReason, request.OverrideBy
+	}
+
+	duration := int32(request.EndTime.Time.Sub(request.StartTime.Time).Minutes())
+
+	for _, tunnelId := range request.TunnelIds {
+		reservation := models.Reservation{
+			Kind:              models.ReservationKindTunnel,
+			TunnelId:          &tunnelId,
+			CustomerId:        request.CustomerId,
+			CustomerFirstName: request.CustomerFirstName,
+			CustomerLastName:  request.CustomerLastName,
+			CustomerPhone:     request.CustomerPhone,
+			CustomerEmail:     request.CustomerEmail,
+			CustomerTier:      request.CustomerTier,
+			StartTime:         request.StartTime,
+			Duration:          duration,
+			EndTime:           request.EndTime,
+			Status:            request.Status,
+			Notes:             request.Notes,
+			OverrideReason:    overrideReason,
+			OverrideBy:        overrideBy,
+			BookingChannel:    request.BookingChannel,
+			GroupId:           &result.Group.Id,
+		}
+
+		if request.Status == models.ReservationStatusHeld {
+			reservation.HoldExpiresAt = holdExpiresAt
+		}
+
+		member, err := InsertReservationData(ctx, tx, reservation)
+		if err != nil {
+			log.Println("[API] Error inserting group reservation:", err)
+			return nil, groupConflict(ctx, conn, err, request.TunnelIds, request.StartTime, request.EndTime, nil)
+		}
+
+		result.Reservations = append(result.Reservations, *member)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing reservation group:", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func LoadReservationGroupById(ctx context.Context, conn IDBConn, id string) (*models.ReservationGroupResult, error) {
+	var result models.ReservationGroupResult
+
+	err := pgxscan.Get(ctx, conn, &result.Group, `SELECT * FROM reservation_groups WHERE id=$1`, id)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find reservation group with id:", id)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	result.Reservations = make([]models.Reservation, 0)
+
+	query := `
+		SELECT * FROM reservations
+		WHERE group_id = $1
+		ORDER BY tunnel_id ASC
+	`
+
+	if err := pgxscan.Select(ctx, conn, &result.Reservations, query, id); err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// CancelReservationGroup cancels every active member of the group and
+// records each change.
+func CancelReservationGroup(ctx context.Context, conn IDBConn, id string, change models.ReservationStatusChangeRequest) ([]models.Reservation, error) {
+	cancelled := make([]models.Reservation, 0)
+
+	args := pgx.NamedArgs{
+		"group_id":   id,
+		"changed_by": change.ChangedBy,
+		"reason":     change.Reason,
+	}
+
+	query := `
+		WITH targets AS (
+			SELECT id, status FROM reservations
+			WHERE group_id = @group_id AND status IN ('held', 'confirmed')
+			FOR UPDATE
+		), cancelled AS (
+			UPDATE reservations
+			SET status = 'cancelled', hold_expires_at = NULL, updated_at = now()
+			WHERE id IN (SELECT id FROM targets)
+			RETURNING *
+		), history AS (
+			INSERT INTO reservation_status_changes (reservation_id, from_status, to_status, changed_by, reason)
+			SELECT id, status, 'cancelled', @changed_by, @reason FROM targets
+		)
+		SELECT * FROM cancelled ORDER BY tunnel_id ASC
+	`
+
+	if err := pgxscan.Select(ctx, conn, &cancelled, query, args); err != nil {
+		log.Println("[API] Error cancelling reservation group:", err)
+		return nil, err
+	}
+
+	return cancelled, nil
+}
+
+// MoveReservationGroup moves every active member of a loaded group to the
+// new time in one statement, so either the whole group moves or none of it
+// does.
+func MoveReservationGroup(ctx context.Context, conn IDBConn, group models.ReservationGroupResult, move models.ReservationGroupMove) ([]models.Reservation, error) {
+	moved := make([]models.Reservation, 0)
+
+	var overrideReason, overrideBy *string
+	if move.OverrideHours {
+		overrideReason, overrideBy = move.OverrideReason, move.OverrideBy
+	}
+
+	args := pgx.NamedArgs{
+		"group_id":         group.Group.Id,
+		"start_time":       move.StartTime,
+		"end_time":         move.EndTime,
+		"duration_minutes": int32(move.EndTime.Time.Sub(move.StartTime.Time).Minutes()),
+		"override_reason":  overrideReason,
+		"override_by":      overrideBy,
+	}
+
+	query := `
+		UPDATE reservations
+		SET
+			start_time = @start_time,
+			end_time = @end_time,
+			duration_minutes = @duration_minutes,
+			override_reason = COALESCE(@override_reason, override_reason),
+			override_by = COALESCE(@override_by, override_by),
+			updated_at = now()
+		WHERE group_id = @group_id AND status IN ('held', 'confirmed')
+		RETURNING *
+	`
+
+	if err := pgxscan.Select(ctx, conn, &moved, query, args); err != nil {
+		log.Println("[API] Error moving reservation group:", err)
+
+		tunnelIds := make([]int32, 0, len(group.Reservations))
+		for _, member := range group.Reservations {
+			if member.TunnelId != nil && BlocksSchedule(member.Status) {
+				tunnelIds = append(tunnelIds, *member.TunnelId)
+			}
+		}
+
+		return nil, groupConflict(ctx, conn, err, tunnelIds, move.StartTime, move.EndTime, &group.Group.Id)
+	}
+
+	return moved, nil
+}
+
+// groupConflict swaps an exclusion violation for a *GroupConflictError
+// listing the bookings on the group's tunnels that are in the way, turnover
+// included.
+func groupConflict(ctx context.Context, conn IDBConn, err error, tunnelIds []int32, from, to pgtype.Timestamptz, groupId *pgtype.UUID) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23P01" {
+		return err
+	}
+
+	tunnels, loadErr := LoadTunnelData(ctx, conn)
+	if loadErr != nil {
+		return err
+	}
+
+	wanted := make(map[int32]bool, len(tunnelIds))
+	for _, tunnelId := range tunnelIds {
+		wanted[tunnelId] = true
+	}
+
+	buffers := make(map[int32]time.Duration, len(tunnels))
+	var lookahead time.Duration
+	for _, tunnel := range tunnels {
+		buffers[tunnel.Id] = tunnel.TurnoverBuffer()
+		if wanted[tunnel.Id] && tunnel.TurnoverBuffer() > lookahead {
+			lookahead = tunnel.TurnoverBuffer()
+		}
+	}
+
+	nearby, loadErr := LoadActiveReservationsWithTurnover(ctx, conn, from, pgtype.Timestamptz{Time: to.Time.Add(lookahead), Valid: true})
+	if loadErr != nil {
+		return err
+	}
+
+	slot := Interval{Start: from.Time, End: to.Time}
+
+	conflicts := make([]models.Reservation, 0)
+	for _, reservation := range nearby {
+		if reservation.TunnelId == nil || !wanted[*reservation.TunnelId] {
+			continue
+		}
+
+		if !tunnelBusyInterval(reservation, buffers[*reservation.TunnelId]).Overlaps(slot) {
+			continue
+		}
+
+		if groupId != nil && reservation.GroupId != nil && *reservation.GroupId == *groupId {
+			continue
+		}
+
+		conflicts = append(conflicts, reservation)
+	}
+
+	return &GroupConflictError{Conflicts: conflicts}
+}
+
+func validateReservationGroup(request models.ReservationGroupRequest) error {
+	validationErr := &ValidationError{}
+
+	if request.Name == "" {
+		validationErr.Add("name", "is required")
+	}
+
+	if len(request.TunnelIds) == 0 {
+		validationErr.Add("tunnel_ids", "must list at least one tunnel")
+	}
+
+	seen := make(map[int32]bool, len(request.TunnelIds))
+	for _, tunnelId := range request.TunnelIds {
+		if seen[tunnelId] {
+			validationErr.Add("tunnel_ids", fmt.Sprintf("tunnel %d is listed twice", tunnelId))
+		}
+		seen[tunnelId] = true
+	}
+
+	if request.Status != models.ReservationStatusHeld && request.Status != models.ReservationStatusConfirmed {
+		validationErr.Add("status", "must be held or confirmed")
+	}
+
+	validateGroupTimes(validationErr, request.StartTime, request.EndTime)
+
+	return validationErr.Err()
+}
+
+// ValidateGroupMove checks the new times of a group move.
+func ValidateGroupMove(move models.ReservationGroupMove) error {
+	validationErr := &ValidationError{}
+	validateGroupTimes(validationErr, move.StartTime, move.EndTime)
+
+	return validationErr.Err()
+}
+
+func validateGroupTimes(validationErr *ValidationError, start, end pgtype.Timestamptz) {
+	if !start.Valid {
+		validationErr.Add("start_time", "is required")
+	}
+
+	if !end.Valid {
+		validationErr.Add("end_time", "is required")
+	}
+
+	if start.Valid && end.Valid && !start.Time.Before(end.Time) {
+		validationErr.Add("end_time", "must be after start_time")
+	}
+}
@@ -0,0 +1,173 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// anyArgs matches a statement with n positional (or expanded named) arguments.
+func anyArgs(n int) []any {
+	args := make([]any, n)
+	for i := range args {
+		args[i] = pgxmock.AnyArg()
+	}
+
+	return args
+}
+
+func testGroupRequest(tunnelIds ...int32) models.ReservationGroupRequest {
+	start := time.Date(2025, 9, 5, 22, 0, 0, 0, time.UTC)
+
+	return models.ReservationGroupRequest{
+		Name:              "Indy Hawks 14U",
+		TunnelIds:         tunnelIds,
+		CustomerFirstName: "Coach",
+		CustomerLastName:  "Carter",
+		CustomerPhone:     "1112223333",
+		StartTime:         pgtype.Timestamptz{Time: start, Valid: true},
+		EndTime:           pgtype.Timestamptz{Time: start.Add(2 * time.Hour), Valid: true},
+		Status:            models.ReservationStatusConfirmed,
+		BookingChannel:    models.BookingChannelPhone,
+	}
+}
+
+func Test_InsertReservationGroup(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	request := testGroupRequest(1, 2)
+	groupId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO reservation_groups")).WithArgs(anyArgs(6)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "name"}).AddRow(groupId, request.Name),
+	)
+
+	for _, tunnelId := range request.TunnelIds {
+		mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO reservations")).WithArgs(anyArgs(23)...).WillReturnRows(
+			pgxmock.NewRows([]string{"id", "tunnel_id", "group_id"}).
+				AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, &tunnelId, &groupId),
+		)
+	}
+
+	mockConn.ExpectCommit()
+	mockConn.ExpectRollback()
+
+	// exercise
+	result, err := InsertReservationGroup(context.Background(), mockConn, request, pgtype.Timestamptz{})
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if result.Group.Id != groupId {
+		t.Fatal("expected group id", groupId.String(), "got", result.Group.Id.String())
+	}
+
+	if len(result.Reservations) != 2 {
+		t.Fatal("expected 2 member reservations, got", len(result.Reservations))
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_InsertReservationGroup_Conflict(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	request := testGroupRequest(1, 2, 3)
+	groupId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	takenId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	var takenTunnel, otherTunnel int32 = 2, 5
+
+	// tunnel 1 books, tunnel 2 is taken, so the whole group is rolled back
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO reservation_groups")).WithArgs(anyArgs(6)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "name"}).AddRow(groupId, request.Name),
+	)
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO reservations")).WithArgs(anyArgs(23)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id"}).AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}),
+	)
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO reservations")).WithArgs(anyArgs(23)...).
+		WillReturnError(&pgconn.PgError{Code: "23P01"})
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM tunnels`)).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "name", "is_active", "turnover_buffer_minutes"}).
+			AddRow(int32(1), "Tunnel 1", true, int32(5)).
+			AddRow(int32(2), "Tunnel 2", true, int32(5)).
+			AddRow(int32(3), "Tunnel 3", true, int32(5)),
+	)
+
+	// the booking on tunnel 2 ends as the group starts but is still turning over
+	mockConn.ExpectQuery(regexp.QuoteMeta(turnoverReservationsQuery)).WithArgs(pgx.NamedArgs{
+		"from_time": request.StartTime,
+		"to_time":   pgtype.Timestamptz{Time: request.EndTime.Time.Add(5 * time.Minute), Valid: true},
+	}).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "tunnel_id", "start_time", "end_time", "tunnel_turnover_end"}).
+			AddRow(takenId, &takenTunnel,
+				pgtype.Timestamptz{Time: request.StartTime.Time.Add(-time.Hour), Valid: true},
+				request.StartTime,
+				pgtype.Timestamptz{Time: request.StartTime.Time.Add(5 * time.Minute), Valid: true}).
+			AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, &otherTunnel,
+				request.StartTime, request.EndTime, request.EndTime),
+	)
+	mockConn.ExpectRollback()
+
+	// exercise
+	result, err := InsertReservationGroup(context.Background(), mockConn, request, pgtype.Timestamptz{})
+
+	// verify
+	var conflictErr *GroupConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatal("expected group conflict error, got", err)
+	}
+
+	if len(conflictErr.Conflicts) != 1 || conflictErr.Conflicts[0].Id != takenId {
+		t.Fatal("expected only the booking on tunnel 2 to be reported, got", conflictErr.Conflicts)
+	}
+
+	if result != nil {
+		t.Fatal("expected no result, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_InsertReservationGroup_DuplicateTunnels(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	// exercise
+	_, err := InsertReservationGroup(context.Background(), mockConn, testGroupRequest(1, 1), pgtype.Timestamptz{})
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	if _, ok := validationErr.Fields["tunnel_ids"]; !ok {
+		t.Fatal("expected a tunnel_ids field error, got", validationErr.Fields)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
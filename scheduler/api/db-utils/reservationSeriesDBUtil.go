@@ -0,0 +1,545 @@
+package db_utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// MaxSeriesOccurrences caps how many reservations one series may create.
+const MaxSeriesOccurrences = 200
+
+const (
+	RuleTunnelBooked = "tunnel_booked"
+	RuleCoachBooked  = "coach_booked"
+)
+
+// PlanReservationSeries expands the series rule in loc and reports, per
+// occurrence, every existing booking and scheduling rule it conflicts with.
+// Nothing is written.
+func PlanReservationSeries(ctx context.Context, conn IDBConn, loc *time.Location, series models.ReservationSeries) ([]models.SeriesOccurrence, error) {
+	validationErr := &ValidationError{}
+
+	if !series.StartTime.Valid {
+		validationErr.Add("start_time", "is required")
+	}
+
+	if series.Duration <= 0 {
+		validationErr.Add("duration_minutes", "must be greater than 0")
+	}
+
+	if series.TunnelId == nil {
+		validationErr.Add("tunnel_id", "is required")
+	}
+
+	if series.Kind == models.ReservationKindLesson && series.CoachId == nil {
+		validationErr.Add("coach_id", "is required for lessons")
+	}
+
+	if series.Status != models.ReservationStatusHeld && series.Status != models.ReservationStatusConfirmed {
+		validationErr.Add("status", "must be held or confirmed")
+	}
+
+	if series.OverrideHours {
+		validateOverride(validationErr, series.OverrideReason, series.OverrideBy)
+	}
+
+	rule, err := models.ParseRecurrenceRule(series.RRule, loc)
+	if err != nil {
+		validationErr.Add("rrule", err.Error())
+	}
+
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	starts, err := rule.Occurrences(series.StartTime.Time, loc, series.ExDates, MaxSeriesOccurrences)
+	if err != nil {
+		validationErr.Add("rrule", err.Error())
+		return nil, validationErr
+	}
+
+	occurrences := make([]models.SeriesOccurrence, 0, len(starts))
+	if len(starts) == 0 {
+		return occurrences, nil
+	}
+
+	duration := time.Duration(series.Duration) * time.Minute
+
+	tunnelBuffer, coachBuffer, err := LoadTurnoverBuffers(ctx, conn, series.TunnelId, series.CoachId)
+	if err != nil {
+		return nil, err
+	}
+
+	// one query for every booking the series could run into
+	existing, err := LoadActiveReservationsWithTurnover(ctx, conn,
+		pgtype.Timestamptz{Time: starts[0], Valid: true},
+		pgtype.Timestamptz{Time: starts[len(starts)-1].Add(duration + max(tunnelBuffer, coachBuffer)), Valid: true},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, start := range starts {
+		occurrence := models.SeriesOccurrence{
+			StartTime: pgtype.Timestamptz{Time: start, Valid: true},
+			EndTime:   pgtype.Timestamptz{Time: start.Add(duration), Valid: true},
+			Conflicts: make([]models.SeriesConflict, 0),
+		}
+
+		slot := Interval{Start: occurrence.StartTime.Time, End: occurrence.EndTime.Time}
+
+		for _, reservation := range existing {
+			id := reservation.Id
+
+			sameTunnel := reservation.TunnelId != nil && *reservation.TunnelId == *series.TunnelId
+			sameCoach := series.CoachId != nil && reservation.CoachId != nil && *reservation.CoachId == *series.CoachId
+
+			if sameTunnel && tunnelBusyInterval(reservation, tunnelBuffer).Overlaps(slot) {
+				occurrence.Conflicts = append(occurrence.Conflicts, models.SeriesConflict{
+					Rule:          RuleTunnelBooked,
+					Message:       fmt.Sprintf("Tunnel %d is already booked at %s.", *series.TunnelId, start.In(loc).Format("Jan 2 15:04")),
+					ReservationId: &id,
+				})
+			} else if sameCoach && coachBusyInterval(reservation, coachBuffer).Overlaps(slot) {
+				occurrence.Conflicts = append(occurrence.Conflicts, models.SeriesConflict{
+					Rule:          RuleCoachBooked,
+					Message:       fmt.Sprintf("The coach is already booked at %s.", start.In(loc).Format("Jan 2 15:04")),
+					ReservationId: &id,
+				})
+			}
+		}
+
+		if !series.OverrideHours {
+			err := EnforceReservationSchedule(ctx, conn, loc, ScheduleCheck{
+				StartTime:    occurrence.StartTime,
+				EndTime:      occurrence.EndTime,
+				Kind:         series.Kind,
+				CoachId:      series.CoachId,
+				BookingRules: true,
+				CustomerTier: series.CustomerTier,
+				Now:          time.Now(),
+			})
+
+			violations := &RuleViolationError{}
+			if !collectViolations(violations, err) {
+				return nil, err
+			}
+
+			for _, violation := range violations.Violations {
+				occurrence.Conflicts = append(occurrence.Conflicts, models.SeriesConflict{
+					Rule:    violation.Rule,
+					Message: violation.Message,
+				})
+			}
+		}
+
+		occurrences = append(occurrences, occurrence)
+	}
+
+	return occurrences, nil
+}
+
+// InsertReservationSeries stores the series and books every occurrence that
+// has no conflicts, all in one transaction. holdExpiresAt applies to held
+// occurrences.
+func InsertReservationSeries(ctx context.Context, conn IDBTxConn, series models.ReservationSeries, occurrences []models.SeriesOccurrence, holdExpiresAt pgtype.Timestamptz) (*models.ReservationSeriesResult, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	exdates := series.ExDates
+	if exdates == nil {
+		exdates = make([]pgtype.Date, 0)
+	}
+
+	args := pgx.NamedArgs{
+		"reservation_kind":    series.Kind,
+		"tunnel_id":           series.TunnelId,
+		"coach_id":            series.CoachId,
+		"lesson_type_id":      series.LessonTypeId,
+		"customer_first_name": series.CustomerFirstName,
+		"customer_last_name":  series.CustomerLastName,
+		"customer_phone":      series.CustomerPhone,
+		"customer_email":      series.CustomerEmail,
+		"start_time":          series.StartTime,
+		"duration_minutes":    series.Duration,
+		"rrule":               series.RRule,
+		"exdates":             exdates,
+		"notes":               series.Notes,
+	}
+
+	const query = `
+		INSERT INTO reservation_series (
+			reservation_kind,
+			tunnel_id,
+			coach_id,
+			lesson_type_id,
+			customer_first_name,
+			customer_last_name,
+			customer_phone,
+			customer_email,
+			start_time,
+			duration_minutes,
+			rrule,
+			exdates,
+			notes
+		)
+
+		VALUES (
+			@reservation_kind,
+			@tunnel_id,
+			@coach_id,
+			@lesson_type_id,
+			@customer_first_name,
+			@customer_last_name,
+			@customer_phone,
+			@customer_email,
+			@start_time,
+			@duration_minutes,
+			@rrule,
+			@exdates,
+			@notes
+		)
+
+		RETURNING *;
+	`
+
+	var inserted models.ReservationSeries
+	if err := pgxscan.Get(ctx, tx, &inserted, query, args); err != nil {
+		log.Println("[API] Error inserting reservation series:", err)
+		return nil, err
+	}
+
+	result := &models.ReservationSeriesResult{
+		Series:       &inserted,
+		Occurrences:  occurrences,
+		Reservations: make([]models.Reservation, 0, len(occurrences)),
+	}
+
+	var overrideReason, overrideBy *string
+	if series.OverrideHours {
+		overrideReason, overrideBy = series.OverrideReason, series.OverrideBy
+	}
+
+	for i, occurrence := range occurrences {
+		if len(occurrence.Conflicts) > 0 {
+			continue
+		}
+
+		reservation := models.Reservation{
+			Kind:              series.Kind,
+			TunnelId:          series.TunnelId,
+			CoachId:           series.CoachId,
+			LessonTypeId:      series.LessonTypeId,
+			CustomerId:        series.CustomerId,
+			AthleteId:         series.AthleteId,
+			CustomerFirstName: series.CustomerFirstName,
+			CustomerLastName:  series.CustomerLastName,
+			CustomerPhone:     series.CustomerPhone,
+			CustomerEmail:     series.CustomerEmail,
+			CustomerTier:      series.CustomerTier,
+			StartTime:         occurrence.StartTime,
+			Duration:          series.Duration,
+			EndTime:           occurrence.EndTime,
+			Status:            series.Status,
+			Notes:             series.Notes,
+			OverrideReason:    overrideReason,
+			OverrideBy:        overrideBy,
+			BookingChannel:    series.BookingChannel,
+			SeriesId:          &inserted.Id,
+		}
+
+		if series.Status == models.ReservationStatusHeld {
+			reservation.HoldExpiresAt = holdExpiresAt
+		}
+
+		booked, err := InsertReservationData(ctx, tx, reservation)
+		if err != nil {
+			log.Println("[API] Error inserting series occurrence:", err)
+			return nil, err
+		}
+
+		result.Occurrences[i].ReservationId = &booked.Id
+		result.Reservations = append(result.Reservations, *booked)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing reservation series:", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func LoadReservationSeriesById(ctx context.Context, conn IDBConn, id string) (*models.ReservationSeries, error) {
+	var series models.ReservationSeries
+
+	err := pgxscan.Get(ctx, conn, &series, `SELECT * FROM reservation_series WHERE id=$1`, id)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find reservation series with id:", id)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &series, nil
+}
+
+func LoadSeriesReservations(ctx context.Context, conn IDBConn, seriesId string) ([]models.Reservation, error) {
+	reservations := make([]models.Reservation, 0)
+
+	query := `
+		SELECT * FROM reservations
+		WHERE series_id = $1
+		ORDER BY start_time ASC
+	`
+
+	if err := pgxscan.Select(ctx, conn, &reservations, query, seriesId); err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+const scopedSeriesReservationsQuery = `
+		SELECT * FROM reservations
+		WHERE series_id = @series_id
+			AND status IN ('held', 'confirmed')
+			AND (@scope = 'all' OR start_time >= @from_time)
+		ORDER BY start_time ASC
+		FOR UPDATE
+	`
+
+// UpdateSeriesReservations applies updates to the anchor occurrence and,
+// depending on scope, the active occurrences after it or all of them. Every
+// moved occurrence is re-checked and all broken rules are reported together.
+func UpdateSeriesReservations(ctx context.Context, conn IDBTxConn, loc *time.Location, anchor models.Reservation, scope models.SeriesScope, updates models.SeriesUpdates) ([]models.Reservation, error) {
+	if updates.OverrideHours {
+		validationErr := &ValidationError{}
+		validateOverride(validationErr, updates.OverrideReason, updates.OverrideBy)
+		if err := validationErr.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if updates.Duration != nil && *updates.Duration <= 0 {
+		validationErr := &ValidationError{}
+		validationErr.Add("duration_minutes", "must be greater than 0")
+		return nil, validationErr
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	targets := []models.Reservation{anchor}
+	if scope != models.SeriesScopeThis {
+		targets = make([]models.Reservation, 0)
+
+		args := pgx.NamedArgs{
+			"series_id": anchor.SeriesId,
+			"scope":     string(scope),
+			"from_time": anchor.StartTime,
+		}
+
+		if err := pgxscan.Select(ctx, tx, &targets, scopedSeriesReservationsQuery, args); err != nil {
+			log.Println("[API] Error loading series reservations:", err)
+			return nil, err
+		}
+	}
+
+	var overrideReason, overrideBy *string
+	if updates.OverrideHours {
+		overrideReason, overrideBy = updates.OverrideReason, updates.OverrideBy
+	}
+
+	violations := &RuleViolationError{}
+	changes := make([]models.ReservationUpdates, 0, len(targets))
+
+	for _, target := range targets {
+		change := models.ReservationUpdates{
+			TunnelId:          updates.TunnelId,
+			CoachId:           updates.CoachId,
+			CustomerFirstName: updates.CustomerFirstName,
+			CustomerLastName:  updates.CustomerLastName,
+			CustomerPhone:     updates.CustomerPhone,
+			CustomerEmail:     updates.CustomerEmail,
+			Duration:          updates.Duration,
+			Notes:             updates.Notes,
+			OverrideReason:    overrideReason,
+			OverrideBy:        overrideBy,
+		}
+
+		start, duration := target.StartTime.Time, target.Duration
+		if updates.StartTime != nil {
+			start = shiftOccurrence(target.StartTime.Time, anchor.StartTime.Time, updates.StartTime.Time, loc)
+		}
+
+		if updates.Duration != nil {
+			duration = *updates.Duration
+		}
+
+		if updates.StartTime != nil || updates.Duration != nil {
+			startTime := pgtype.Timestamptz{Time: start, Valid: true}
+			endTime := pgtype.Timestamptz{Time: start.Add(time.Duration(duration) * time.Minute), Valid: true}
+			change.StartTime, change.EndTime = &startTime, &endTime
+		}
+
+		coachId := target.CoachId
+		if updates.CoachId != nil {
+			coachId = updates.CoachId
+		}
+
+		if (change.StartTime != nil || updates.CoachId != nil) && BlocksSchedule(target.Status) && !updates.OverrideHours {
+			// lead time and horizon only apply to a moved occurrence
+			startTime, endTime, bookedAt := target.StartTime, target.EndTime, time.Time{}
+			if change.StartTime != nil {
+				startTime, endTime, bookedAt = *change.StartTime, *change.EndTime, time.Now()
+			}
+
+			err := EnforceReservationSchedule(ctx, tx, loc, ScheduleCheck{
+				StartTime:    startTime,
+				EndTime:      endTime,
+				Kind:         target.Kind,
+				CoachId:      coachId,
+				BookingRules: true,
+				CustomerTier: target.CustomerTier,
+				Now:          bookedAt,
+			})
+
+			if !collectViolations(violations, err) {
+				return nil, err
+			}
+		}
+
+		changes = append(changes, change)
+	}
+
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	updated := make([]models.Reservation, 0, len(targets))
+	for i, target := range targets {
+		reservation, err := UpdateReservationData(ctx, tx, target.Id.String(), changes[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if reservation != nil {
+			updated = append(updated, *reservation)
+		}
+	}
+
+	// the series itself only follows edits made to every occurrence
+	if scope == models.SeriesScopeAll {
+		args := pgx.NamedArgs{
+			"id":                  anchor.SeriesId,
+			"tunnel_id":           updates.TunnelId,
+			"coach_id":            updates.CoachId,
+			"customer_first_name": updates.CustomerFirstName,
+			"customer_last_name":  updates.CustomerLastName,
+			"customer_phone":      updates.CustomerPhone,
+			"customer_email":      updates.CustomerEmail,
+			"duration_minutes":    updates.Duration,
+			"notes":               updates.Notes,
+		}
+
+		query := `
+			UPDATE reservation_series
+			SET
+				tunnel_id = COALESCE(@tunnel_id, tunnel_id),
+				coach_id = COALESCE(@coach_id, coach_id),
+				customer_first_name = COALESCE(@customer_first_name, customer_first_name),
+				customer_last_name = COALESCE(@customer_last_name, customer_last_name),
+				customer_phone = COALESCE(@customer_phone, customer_phone),
+				customer_email = COALESCE(@customer_email, customer_email),
+				duration_minutes = COALESCE(@duration_minutes, duration_minutes),
+				notes = COALESCE(@notes, notes),
+				updated_at = now()
+			WHERE id = @id
+		`
+
+		if _, err := tx.Exec(ctx, query, args); err != nil {
+			log.Println("[API] Error updating reservation series:", err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing series update:", err)
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// shiftOccurrence moves target by the same number of calendar days the
+// anchor moved and onto the anchor's new wall-clock time in loc.
+func shiftOccurrence(target, anchor, newAnchor time.Time, loc *time.Location) time.Time {
+	dayShift := int(models.LocalDate(newAnchor, loc).Time.Sub(models.LocalDate(anchor, loc).Time).Hours() / 24)
+
+	year, month, day := target.In(loc).Date()
+	hour, minute, second := newAnchor.In(loc).Clock()
+
+	return time.Date(year, month, day+dayShift, hour, minute, second, 0, loc)
+}
+
+// CancelSeriesReservations cancels the active occurrences from the anchor on
+// (following) or all of them, recording each change. Cancelling a single
+// occurrence goes through TransitionReservationStatus instead.
+func CancelSeriesReservations(ctx context.Context, conn IDBConn, anchor models.Reservation, scope models.SeriesScope, change models.ReservationStatusChangeRequest) ([]models.Reservation, error) {
+	cancelled := make([]models.Reservation, 0)
+
+	args := pgx.NamedArgs{
+		"series_id":  anchor.SeriesId,
+		"scope":      string(scope),
+		"from_time":  anchor.StartTime,
+		"changed_by": change.ChangedBy,
+		"reason":     change.Reason,
+	}
+
+	query := `
+		WITH targets AS (
+			SELECT id, status FROM reservations
+			WHERE series_id = @series_id
+				AND status IN ('held', 'confirmed')
+				AND (@scope = 'all' OR start_time >= @from_time)
+			FOR UPDATE
+		), cancelled AS (
+			UPDATE reservations
+			SET status = 'cancelled', hold_expires_at = NULL, updated_at = now()
+			WHERE id IN (SELECT id FROM targets)
+			RETURNING *
+		), history AS (
+			INSERT INTO reservation_status_changes (reservation_id, from_status, to_status, changed_by, reason)
+			SELECT id, status, 'cancelled', @changed_by, @reason FROM targets
+		)
+		SELECT * FROM cancelled ORDER BY start_time ASC
+	`
+
+	if err := pgxscan.Select(ctx, conn, &cancelled, query, args); err != nil {
+		log.Println("[API] Error cancelling series reservations:", err)
+		return nil, err
+	}
+
+	return cancelled, nil
+}
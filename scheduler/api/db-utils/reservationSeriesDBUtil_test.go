@@ -0,0 +1,197 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func testReservationSeries(rrule string) models.ReservationSeries {
+	var tunnelId int32 = 1
+	reason := "Team practice agreed with the owner"
+	by := "front desk"
+
+	return models.ReservationSeries{
+		Kind:              models.ReservationKindTunnel,
+		TunnelId:          &tunnelId,
+		CustomerFirstName: "Coach",
+		CustomerLastName:  "Carter",
+		CustomerPhone:     "1112223333",
+		StartTime:         pgtype.Timestamptz{Time: time.Date(2025, 9, 2, 18, 0, 0, 0, time.UTC), Valid: true},
+		Duration:          60,
+		RRule:             rrule,
+		Status:            models.ReservationStatusConfirmed,
+		OverrideHours:     true,
+		OverrideReason:    &reason,
+		OverrideBy:        &by,
+	}
+}
+
+func Test_PlanReservationSeries_ReportsConflicts(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	series := testReservationSeries("FREQ=WEEKLY;BYDAY=TU;COUNT=3")
+	takenId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	var otherTunnel int32 = 2
+
+	// the second Tuesday is already booked on the same tunnel, another tunnel is busy on the third
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM tunnels WHERE id=$1`)).WithArgs(*series.TunnelId).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "name", "is_active", "turnover_buffer_minutes"}).
+			AddRow(*series.TunnelId, "Tunnel 1", true, int32(5)),
+	)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(turnoverReservationsQuery)).WithArgs(pgx.NamedArgs{
+		"from_time": pgtype.Timestamptz{Time: time.Date(2025, 9, 2, 18, 0, 0, 0, time.UTC), Valid: true},
+		"to_time":   pgtype.Timestamptz{Time: time.Date(2025, 9, 16, 19, 5, 0, 0, time.UTC), Valid: true},
+	}).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "tunnel_id", "start_time", "end_time"}).
+			AddRow(takenId, series.TunnelId,
+				pgtype.Timestamptz{Time: time.Date(2025, 9, 9, 18, 30, 0, 0, time.UTC), Valid: true},
+				pgtype.Timestamptz{Time: time.Date(2025, 9, 9, 19, 30, 0, 0, time.UTC), Valid: true}).
+			AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, &otherTunnel,
+				pgtype.Timestamptz{Time: time.Date(2025, 9, 16, 18, 0, 0, 0, time.UTC), Valid: true},
+				pgtype.Timestamptz{Time: time.Date(2025, 9, 16, 19, 0, 0, 0, time.UTC), Valid: true}),
+	)
+
+	// exercise
+	occurrences, err := PlanReservationSeries(context.Background(), mockConn, time.UTC, series)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(occurrences) != 3 {
+		t.Fatal("expected 3 occurrences, got", len(occurrences))
+	}
+
+	if len(occurrences[0].Conflicts) != 0 || len(occurrences[2].Conflicts) != 0 {
+		t.Fatal("expected the first and third occurrences to be free, got", occurrences)
+	}
+
+	conflicts := occurrences[1].Conflicts
+	if len(conflicts) != 1 || conflicts[0].Rule != RuleTunnelBooked || *conflicts[0].ReservationId != takenId {
+		t.Fatal("expected the second occurrence to conflict with", takenId.String(), "got", conflicts)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_PlanReservationSeries_InvalidRule(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	series := testReservationSeries("FREQ=WEEKLY;BYDAY=TU")
+
+	// exercise
+	_, err := PlanReservationSeries(context.Background(), mockConn, time.UTC, series)
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	if _, ok := validationErr.Fields["rrule"]; !ok {
+		t.Fatal("expected an rrule field error, got", validationErr.Fields)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_CancelSeriesReservations_Following(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	query := `
+		WITH targets AS (
+			SELECT id, status FROM reservations
+			WHERE series_id = @series_id
+				AND status IN ('held', 'confirmed')
+				AND (@scope = 'all' OR start_time >= @from_time)
+			FOR UPDATE
+		), cancelled AS (
+			UPDATE reservations
+			SET status = 'cancelled', hold_expires_at = NULL, updated_at = now()
+			WHERE id IN (SELECT id FROM targets)
+			RETURNING *
+		), history AS (
+			INSERT INTO reservation_status_changes (reservation_id, from_status, to_status, changed_by, reason)
+			SELECT id, status, 'cancelled', @changed_by, @reason FROM targets
+		)
+		SELECT * FROM cancelled ORDER BY start_time ASC
+	`
+
+	seriesId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	anchor := models.Reservation{
+		Id:        pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true},
+		SeriesId:  &seriesId,
+		StartTime: pgtype.Timestamptz{Time: time.Date(2025, 9, 9, 18, 0, 0, 0, time.UTC), Valid: true},
+	}
+	changedBy := "front desk"
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(pgx.NamedArgs{
+		"series_id":  &seriesId,
+		"scope":      "following",
+		"from_time":  anchor.StartTime,
+		"changed_by": &changedBy,
+		"reason":     (*string)(nil),
+	}).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "status"}).
+			AddRow(anchor.Id, models.ReservationStatusCancelled).
+			AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, models.ReservationStatusCancelled),
+	)
+
+	// exercise
+	cancelled, err := CancelSeriesReservations(context.Background(), mockConn, anchor, models.SeriesScopeFollowing, models.ReservationStatusChangeRequest{
+		ChangedBy: &changedBy,
+	})
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(cancelled) != 2 {
+		t.Fatal("expected 2 cancelled reservations, got", len(cancelled))
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ShiftOccurrence(t *testing.T) {
+	// setup
+	loc, _ := time.LoadLocation("America/Indiana/Indianapolis")
+	anchor := time.Date(2025, 10, 28, 18, 0, 0, 0, loc)
+	target := time.Date(2025, 11, 4, 18, 0, 0, 0, loc)
+
+	// move Tuesday 6 PM to Wednesday 7 PM, the target is past the DST change
+	newAnchor := time.Date(2025, 10, 29, 19, 0, 0, 0, loc)
+
+	// exercise
+	shifted := shiftOccurrence(target, anchor, newAnchor, loc)
+
+	// verify
+	expected := time.Date(2025, 11, 5, 19, 0, 0, 0, loc)
+	if !shifted.Equal(expected) {
+		t.Fatal("expected", expected, "got", shifted)
+	}
+}
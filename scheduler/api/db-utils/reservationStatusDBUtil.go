@@ -0,0 +1,179 @@
+package db_utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// InvalidTransitionError is returned when a reservation cannot move from its
+// current status to the requested one.
+type InvalidTransitionError struct {
+	From models.ReservationStatus
+	To   models.ReservationStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("reservation cannot move from %s to %s", e.From, e.To)
+}
+
+// HoldExpiredError is returned when confirming a hold whose time ran out
+// before the sweeper got to it, the slot may already be promised elsewhere.
+type HoldExpiredError struct {
+	ExpiredAt pgtype.Timestamptz
+}
+
+func (e *HoldExpiredError) Error() string {
+	return fmt.Sprintf("the hold expired at %s", e.ExpiredAt.Time.Format(time.RFC3339))
+}
+
+// TransitionReservationStatus moves a reservation to the given status and
+// records who changed it and why. The reservation row is locked for the
+// duration so two concurrent transitions cannot both pass the check.
+func TransitionReservationStatus(ctx context.Context, conn IDBTxConn, id string, to models.ReservationStatus, change models.ReservationStatusChangeRequest) (*models.Reservation, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	updated, err := TransitionReservationStatusInTx(ctx, tx, id, to, change)
+	if err != nil || updated == nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing reservation status change:", err)
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// UpdateReservationWithStatus applies a PUT's field updates and, when to is
+// set, its status change in one transaction, so a refused transition leaves
+// the other fields untouched as well.
+func UpdateReservationWithStatus(ctx context.Context, conn IDBTxConn, id string, updates models.ReservationUpdates, to *models.ReservationStatus) (*models.Reservation, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	reservation, err := UpdateReservationData(ctx, tx, id, updates)
+	if err != nil || reservation == nil {
+		return nil, err
+	}
+
+	if to != nil {
+		reservation, err = TransitionReservationStatusInTx(ctx, tx, id, *to, models.ReservationStatusChangeRequest{})
+		if err != nil || reservation == nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing reservation update:", err)
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// TransitionReservationStatusInTx is TransitionReservationStatus inside the
+// caller's transaction, the row lock is held until that ends.
+func TransitionReservationStatusInTx(ctx context.Context, tx IDBConn, id string, to models.ReservationStatus, change models.ReservationStatusChangeRequest) (*models.Reservation, error) {
+	var current models.Reservation
+	err := pgxscan.Get(ctx, tx, &current, `SELECT * FROM reservations WHERE id=$1 FOR UPDATE`, id)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find reservation with id:", id)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	if !current.Status.CanTransitionTo(to) {
+		return nil, &InvalidTransitionError{From: current.Status, To: to}
+	}
+
+	if current.Status == models.ReservationStatusHeld && to == models.ReservationStatusConfirmed &&
+		current.HoldExpiresAt.Valid && !current.HoldExpiresAt.Time.After(time.Now()) {
+		return nil, &HoldExpiredError{ExpiredAt: current.HoldExpiresAt}
+	}
+
+	args := pgx.NamedArgs{
+		"id":         id,
+		"status":     to,
+		"from":       current.Status,
+		"changed_by": change.ChangedBy,
+		"reason":     change.Reason,
+	}
+
+	const updateQuery = `
+		UPDATE reservations
+		SET status = @status, hold_expires_at = NULL, updated_at = now()
+		WHERE id = @id
+		RETURNING *
+	`
+
+	var updated models.Reservation
+	if err := pgxscan.Get(ctx, tx, &updated, updateQuery, args); err != nil {
+		log.Println("[API] Error updating reservation status:", err)
+		return nil, err
+	}
+
+	const historyQuery = `
+		INSERT INTO reservation_status_changes (reservation_id, from_status, to_status, changed_by, reason)
+		VALUES (@id, @from, @status, @changed_by, @reason)
+	`
+
+	if _, err := tx.Exec(ctx, historyQuery, args); err != nil {
+		log.Println("[API] Error recording reservation status change:", err)
+		return nil, err
+	}
+
+	// confirming a hold that came from the waitlist fulfils that entry
+	if to == models.ReservationStatusConfirmed {
+		const waitlistQuery = `
+			UPDATE waitlist_entries
+			SET status = 'fulfilled', updated_at = now()
+			WHERE offered_reservation_id = @id AND status = 'offered'
+		`
+
+		if _, err := tx.Exec(ctx, waitlistQuery, pgx.NamedArgs{"id": id}); err != nil {
+			log.Println("[API] Error fulfilling waitlist entry:", err)
+			return nil, err
+		}
+	}
+
+	return &updated, nil
+}
+
+func LoadReservationStatusChanges(ctx context.Context, conn IDBConn, reservationId string) ([]models.ReservationStatusChange, error) {
+	changes := make([]models.ReservationStatusChange, 0)
+
+	query := `
+		SELECT * FROM reservation_status_changes
+		WHERE reservation_id = $1
+		ORDER BY changed_at ASC
+	`
+
+	if err := pgxscan.Select(ctx, conn, &changes, query, reservationId); err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return changes, nil
+}
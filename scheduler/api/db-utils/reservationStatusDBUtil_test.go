@@ -0,0 +1,248 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const lockReservationQuery = `SELECT * FROM reservations WHERE id=$1 FOR UPDATE`
+
+const transitionReservationQuery = `
+		UPDATE reservations
+		SET status = @status, hold_expires_at = NULL, updated_at = now()
+		WHERE id = @id
+		RETURNING *
+	`
+
+const fulfilWaitlistQuery = `
+			UPDATE waitlist_entries
+			SET status = 'fulfilled', updated_at = now()
+			WHERE offered_reservation_id = @id AND status = 'offered'
+		`
+
+const insertStatusChangeQuery = `
+		INSERT INTO reservation_status_changes (reservation_id, from_status, to_status, changed_by, reason)
+		VALUES (@id, @from, @status, @changed_by, @reason)
+	`
+
+func Test_ReservationStatus_CanTransitionTo(t *testing.T) {
+	cases := []struct {
+		from, to models.ReservationStatus
+		allowed  bool
+	}{
+		{models.ReservationStatusHeld, models.ReservationStatusConfirmed, true},
+		{models.ReservationStatusHeld, models.ReservationStatusCancelled, true},
+		{models.ReservationStatusHeld, models.ReservationStatusCompleted, false},
+		{models.ReservationStatusConfirmed, models.ReservationStatusCompleted, true},
+		{models.ReservationStatusConfirmed, models.ReservationStatusNoShow, true},
+		{models.ReservationStatusConfirmed, models.ReservationStatusCancelled, true},
+		{models.ReservationStatusConfirmed, models.ReservationStatusHeld, false},
+		{models.ReservationStatusCancelled, models.ReservationStatusConfirmed, false},
+		{models.ReservationStatusCancelled, models.ReservationStatusHeld, false},
+		{models.ReservationStatusCompleted, models.ReservationStatusNoShow, false},
+	}
+
+	for _, tc := range cases {
+		// exercise
+		allowed := tc.from.CanTransitionTo(tc.to)
+
+		// verify
+		if allowed != tc.allowed {
+			t.Fatal("expected", tc.from, "->", tc.to, "allowed to be", tc.allowed)
+		}
+	}
+}
+
+func Test_TransitionReservationStatus(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	pgU := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	changedBy := "front desk"
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta(lockReservationQuery)).WithArgs(pgU.String()).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "status"}).AddRow(pgU, models.ReservationStatusHeld),
+	)
+
+	args := pgx.NamedArgs{
+		"id":         pgU.String(),
+		"status":     models.ReservationStatusConfirmed,
+		"from":       models.ReservationStatusHeld,
+		"changed_by": &changedBy,
+		"reason":     (*string)(nil),
+	}
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(transitionReservationQuery)).WithArgs(args).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "status"}).AddRow(pgU, models.ReservationStatusConfirmed),
+	)
+	mockConn.ExpectExec(regexp.QuoteMeta(insertStatusChangeQuery)).WithArgs(args).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockConn.ExpectExec(regexp.QuoteMeta(fulfilWaitlistQuery)).WithArgs(pgx.NamedArgs{"id": pgU.String()}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mockConn.ExpectCommit()
+	mockConn.ExpectRollback()
+
+	// exercise
+	reservation, err := TransitionReservationStatus(context.Background(), mockConn, pgU.String(), models.ReservationStatusConfirmed, models.ReservationStatusChangeRequest{
+		ChangedBy: &changedBy,
+	})
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if reservation.Status != models.ReservationStatusConfirmed {
+		t.Fatal("expected status confirmed, got", reservation.Status)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_TransitionReservationStatus_Illegal(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	pgU := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	// a cancelled reservation cannot be revived into a slot someone else may hold
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta(lockReservationQuery)).WithArgs(pgU.String()).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "status"}).AddRow(pgU, models.ReservationStatusCancelled),
+	)
+	mockConn.ExpectRollback()
+
+	// exercise
+	reservation, err := TransitionReservationStatus(context.Background(), mockConn, pgU.String(), models.ReservationStatusConfirmed, models.ReservationStatusChangeRequest{})
+
+	// verify
+	var transitionErr *InvalidTransitionError
+	if !errors.As(err, &transitionErr) {
+		t.Fatal("expected invalid transition error, got", err)
+	}
+
+	if transitionErr.From != models.ReservationStatusCancelled {
+		t.Fatal("expected from status cancelled, got", transitionErr.From)
+	}
+
+	if reservation != nil {
+		t.Fatal("expected no reservation, got", reservation)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_TransitionReservationStatus_HoldExpired(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	pgU := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	expiredAt := pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true}
+
+	// the sweeper hasn't released the hold yet, it still can't be confirmed
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta(lockReservationQuery)).WithArgs(pgU.String()).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "status", "hold_expires_at"}).AddRow(pgU, models.ReservationStatusHeld, expiredAt),
+	)
+	mockConn.ExpectRollback()
+
+	// exercise
+	reservation, err := TransitionReservationStatus(context.Background(), mockConn, pgU.String(), models.ReservationStatusConfirmed, models.ReservationStatusChangeRequest{})
+
+	// verify
+	var expiredErr *HoldExpiredError
+	if !errors.As(err, &expiredErr) {
+		t.Fatal("expected hold expired error, got", err)
+	}
+
+	if reservation != nil {
+		t.Fatal("expected no reservation, got", reservation)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_TransitionReservationStatus_NotFound(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	pgU := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta(lockReservationQuery)).WithArgs(pgU.String()).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "status"}))
+	mockConn.ExpectRollback()
+
+	// exercise
+	reservation, err := TransitionReservationStatus(context.Background(), mockConn, pgU.String(), models.ReservationStatusCancelled, models.ReservationStatusChangeRequest{})
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if reservation != nil {
+		t.Fatal("expected no reservation, got", reservation)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_UpdateReservationWithStatus_IllegalRollsBack(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	pgU := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	notes := "moved to the back tunnel"
+	completed := models.ReservationStatusCompleted
+
+	// the notes are written, then a concurrent cancel makes the transition illegal
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta("UPDATE reservations")).WithArgs(anyArgs(19)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "status", "notes"}).AddRow(pgU, models.ReservationStatusCancelled, &notes),
+	)
+	mockConn.ExpectQuery(regexp.QuoteMeta(lockReservationQuery)).WithArgs(pgU.String()).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "status"}).AddRow(pgU, models.ReservationStatusCancelled),
+	)
+	mockConn.ExpectRollback()
+
+	// exercise
+	reservation, err := UpdateReservationWithStatus(context.Background(), mockConn, pgU.String(), models.ReservationUpdates{Notes: &notes}, &completed)
+
+	// verify
+	var transitionErr *InvalidTransitionError
+	if !errors.As(err, &transitionErr) {
+		t.Fatal("expected invalid transition error, got", err)
+	}
+
+	if reservation != nil {
+		t.Fatal("expected no reservation, got", reservation)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
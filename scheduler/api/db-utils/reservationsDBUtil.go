@@ -3,9 +3,11 @@ package db_utils
 import (
 	"context"
 	"log"
+	"time"
 	
 	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
 )
 
@@ -14,15 +16,26 @@ func InsertReservationData(ctx context.Context, conn IDBConn, r models.Reservati
 		"reservation_kind":    r.Kind,
 		"tunnel_id":           r.TunnelId,
 		"coach_id":            r.CoachId,
+		"lesson_type_id":      r.LessonTypeId,
+		"customer_id":         r.CustomerId,
+		"athlete_id":          r.AthleteId,
 		"customer_first_name": r.CustomerFirstName,
 		"customer_last_name":  r.CustomerLastName,
 		"customer_phone":      r.CustomerPhone,
 		"customer_email":      r.CustomerEmail,
+		"customer_tier":       r.CustomerTier,
 		"start_time":          r.StartTime,
 		"duration_minutes":    r.Duration,
 		"end_time":            r.EndTime,
 		"status":              r.Status,
 		"notes":               r.Notes,
+		"override_reason":     r.OverrideReason,
+		"override_by":         r.OverrideBy,
+		"booking_channel":     r.BookingChannel,
+		"hold_expires_at":     r.HoldExpiresAt,
+		"series_id":           r.SeriesId,
+		"group_id":            r.GroupId,
+		"session_id":          r.SessionId,
 	}
 	
 	const query = `
@@ -30,30 +43,52 @@ func InsertReservationData(ctx context.Context, conn IDBConn, r models.Reservati
 			reservation_kind,
 			tunnel_id,
 			coach_id,
+			lesson_type_id,
+			customer_id,
+			athlete_id,
 			customer_first_name,
 			customer_last_name,
 			customer_phone,
 			customer_email,
+			customer_tier,
 			start_time,
 			duration_minutes,
 			end_time,
 			status,
-			notes
+			notes,
+			override_reason,
+			override_by,
+			booking_channel,
+			hold_expires_at,
+			series_id,
+			group_id,
+			session_id
 		)
 
 		VALUES (
 			@reservation_kind,
 			@tunnel_id,
 			@coach_id,
+			@lesson_type_id,
+			@customer_id,
+			@athlete_id,
 			@customer_first_name,
 			@customer_last_name,
 			@customer_phone,
 			@customer_email,
+			@customer_tier,
 			@start_time,
 			@duration_minutes,
 			@end_time,
 			@status,
-			@notes
+			@notes,
+			@override_reason,
+			@override_by,
+			@booking_channel,
+			@hold_expires_at,
+			@series_id,
+			@group_id,
+			@session_id
 		)
 
 		RETURNING *;
@@ -76,15 +111,21 @@ func UpdateReservationData(ctx context.Context, conn IDBConn, id string, reserva
 		"reservation_kind":    reservation.Kind,
 		"tunnel_id":           reservation.TunnelId,
 		"coach_id":            reservation.CoachId,
+		"lesson_type_id":      reservation.LessonTypeId,
+		"customer_id":         reservation.CustomerId,
+		"athlete_id":          reservation.AthleteId,
 		"customer_first_name": reservation.CustomerFirstName,
 		"customer_last_name":  reservation.CustomerLastName,
 		"customer_phone":      reservation.CustomerPhone,
 		"customer_email":      reservation.CustomerEmail,
+		"customer_tier":       reservation.CustomerTier,
 		"start_time":          reservation.StartTime,
 		"duration_minutes":    reservation.Duration,
 		"end_time":            reservation.EndTime,
 		"status":              reservation.Status,
 		"notes":               reservation.Notes,
+		"override_reason":     reservation.OverrideReason,
+		"override_by":         reservation.OverrideBy,
 	}
 	
 	query := `
@@ -93,15 +134,21 @@ func UpdateReservationData(ctx context.Context, conn IDBConn, id string, reserva
 				reservation_kind = COALESCE(@reservation_kind, reservation_kind),
 				tunnel_id = COALESCE(@tunnel_id, tunnel_id),
 				coach_id = COALESCE(@coach_id, coach_id),
+				lesson_type_id = COALESCE(@lesson_type_id, lesson_type_id),
+				customer_id = COALESCE(@customer_id, customer_id),
+				athlete_id = COALESCE(@athlete_id, athlete_id),
 				customer_first_name = COALESCE(@customer_first_name, customer_first_name),
 				customer_last_name = COALESCE(@customer_last_name, customer_last_name),
 				customer_phone = COALESCE(@customer_phone, customer_phone),
 				customer_email = COALESCE(@customer_email, customer_email),
+				customer_tier = COALESCE(@customer_tier, customer_tier),
 				start_time = COALESCE(@start_time, start_time),
 				duration_minutes = COALESCE(@duration_minutes, duration_minutes),
 				end_time = COALESCE(@end_time, end_time),
 				status = COALESCE(@status, status),
 				notes = COALESCE(@notes, notes),
+				override_reason = COALESCE(@override_reason, override_reason),
+				override_by = COALESCE(@override_by, override_by),
 				updated_at = now()
 			WHERE id = @id
 			RETURNING *
@@ -225,3 +272,138 @@ func DeleteReservationData(ctx context.Context, conn IDBConn, id string) (int64,
 	
 	return cmdTag.RowsAffected(), err
 }
+
+// LoadActiveReservationsOverlapping returns the held/confirmed reservations
+// whose [start_time, end_time) range overlaps [from, to).
+func LoadActiveReservationsOverlapping(ctx context.Context, conn IDBConn, from, to pgtype.Timestamptz) ([]models.Reservation, error) {
+	reservations := make([]models.Reservation, 0)
+	
+	args := pgx.NamedArgs{
+		"from_time": from,
+		"to_time":   to,
+	}
+	
+	query := `
+		SELECT * FROM reservations
+		WHERE status IN ('held', 'confirmed')
+			AND tstzrange(start_time, end_time, '[)') && tstzrange(@from_time, @to_time, '[)')
+		ORDER BY start_time ASC
+	`
+	
+	err := pgxscan.Select(ctx, conn, &reservations, query, args)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+	
+	return reservations, nil
+}
+
+// LoadActiveReservationsWithTurnover returns the held/confirmed reservations
+// that start before to and whose tunnel or coach is still turning over after
+// from, so bookings that end just before from are included too.
+func LoadActiveReservationsWithTurnover(ctx context.Context, conn IDBConn, from, to pgtype.Timestamptz) ([]models.Reservation, error) {
+	reservations := make([]models.Reservation, 0)
+	
+	args := pgx.NamedArgs{
+		"from_time": from,
+		"to_time":   to,
+	}
+	
+	query := `
+		SELECT * FROM reservations
+		WHERE status IN ('held', 'confirmed')
+			AND start_time < @to_time
+			AND GREATEST(end_time, tunnel_turnover_end, coach_turnover_end) > @from_time
+		ORDER BY start_time ASC
+	`
+	
+	err := pgxscan.Select(ctx, conn, &reservations, query, args)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+	
+	return reservations, nil
+}
+
+// ResolveReservationTimes makes start_time, duration_minutes and end_time of
+// a new reservation agree. Either duration_minutes or end_time may be left
+// out and is derived from the other, sending both requires them to match.
+func ResolveReservationTimes(r *models.Reservation) error {
+	validationErr := &ValidationError{}
+	
+	var duration *int32
+	if r.Duration != 0 {
+		duration = &r.Duration
+	}
+	
+	r.Duration, r.EndTime = resolveTimes(validationErr, r.StartTime, duration, &r.EndTime)
+	
+	return validationErr.Err()
+}
+
+// ResolveReservationTimeUpdates does the same for a partial update against
+// the stored reservation and fills in all three fields of updates. Moving
+// only start_time keeps the booked length.
+func ResolveReservationTimeUpdates(existing models.Reservation, updates *models.ReservationUpdates) error {
+	if updates.StartTime == nil && updates.Duration == nil && updates.EndTime == nil {
+		return nil
+	}
+	
+	validationErr := &ValidationError{}
+	
+	start := existing.StartTime
+	if updates.StartTime != nil {
+		start = *updates.StartTime
+	}
+	
+	duration := updates.Duration
+	if updates.Duration == nil && updates.EndTime == nil {
+		duration = &existing.Duration
+	}
+	
+	resolvedDuration, resolvedEnd := resolveTimes(validationErr, start, duration, updates.EndTime)
+	
+	updates.StartTime = &start
+	updates.Duration = &resolvedDuration
+	updates.EndTime = &resolvedEnd
+	
+	return validationErr.Err()
+}
+
+func resolveTimes(validationErr *ValidationError, start pgtype.Timestamptz, duration *int32, end *pgtype.Timestamptz) (int32, pgtype.Timestamptz) {
+	if !start.Valid {
+		validationErr.Add("start_time", "is required")
+		return 0, pgtype.Timestamptz{}
+	}
+	
+	hasEnd := end != nil && end.Valid
+	
+	switch {
+	case duration != nil && *duration <= 0:
+		validationErr.Add("duration_minutes", "must be greater than 0")
+	case duration != nil:
+		derived := pgtype.Timestamptz{Time: start.Time.Add(time.Duration(*duration) * time.Minute), Valid: true}
+		
+		if hasEnd && !end.Time.Equal(derived.Time) {
+			validationErr.Add("end_time", "must equal start_time plus duration_minutes")
+		}
+		
+		return *duration, derived
+	case hasEnd:
+		span := end.Time.Sub(start.Time)
+		
+		if span <= 0 {
+			validationErr.Add("end_time", "must be after start_time")
+		} else if span%time.Minute != 0 {
+			validationErr.Add("end_time", "must be a whole number of minutes after start_time")
+		}
+		
+		return int32(span / time.Minute), *end
+	default:
+		validationErr.Add("duration_minutes", "or end_time is required")
+	}
+	
+	return 0, pgtype.Timestamptz{}
+}
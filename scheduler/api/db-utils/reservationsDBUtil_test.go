@@ -351,30 +351,52 @@ func Test_InsertReservationData(t *testing.T) {
 			reservation_kind,
 			tunnel_id,
 			coach_id,
+			lesson_type_id,
+			customer_id,
+			athlete_id,
 			customer_first_name,
 			customer_last_name,
 			customer_phone,
 			customer_email,
+			customer_tier,
 			start_time,
 			duration_minutes,
 			end_time,
 			status,
-			notes
+			notes,
+			override_reason,
+			override_by,
+			booking_channel,
+			hold_expires_at,
+			series_id,
+			group_id,
+			session_id
 		)
 
 		VALUES (
 			@reservation_kind,
 			@tunnel_id,
 			@coach_id,
+			@lesson_type_id,
+			@customer_id,
+			@athlete_id,
 			@customer_first_name,
 			@customer_last_name,
 			@customer_phone,
 			@customer_email,
+			@customer_tier,
 			@start_time,
 			@duration_minutes,
 			@end_time,
 			@status,
-			@notes
+			@notes,
+			@override_reason,
+			@override_by,
+			@booking_channel,
+			@hold_expires_at,
+			@series_id,
+			@group_id,
+			@session_id
 		)
 
 		RETURNING *;
@@ -411,15 +433,26 @@ func Test_InsertReservationData(t *testing.T) {
 		"reservation_kind":    testReservation.Kind,
 		"tunnel_id":           testReservation.TunnelId,
 		"coach_id":            testReservation.CoachId,
+		"lesson_type_id":      testReservation.LessonTypeId,
+		"customer_id":         testReservation.CustomerId,
+		"athlete_id":          testReservation.AthleteId,
 		"customer_first_name": testReservation.CustomerFirstName,
 		"customer_last_name":  testReservation.CustomerLastName,
 		"customer_phone":      testReservation.CustomerPhone,
 		"customer_email":      testReservation.CustomerEmail,
+		"customer_tier":       testReservation.CustomerTier,
 		"start_time":          testReservation.StartTime,
 		"duration_minutes":    testReservation.Duration,
 		"end_time":            testReservation.EndTime,
 		"status":              testReservation.Status,
 		"notes":               testReservation.Notes,
+		"override_reason":     testReservation.OverrideReason,
+		"override_by":         testReservation.OverrideBy,
+		"booking_channel":     testReservation.BookingChannel,
+		"hold_expires_at":     testReservation.HoldExpiresAt,
+		"series_id":           testReservation.SeriesId,
+		"group_id":            testReservation.GroupId,
+		"session_id":          testReservation.SessionId,
 	}).WillReturnRows(rows)
 	
 	// exercise
@@ -453,30 +486,52 @@ func Test_InsertReservationData_Error(t *testing.T) {
 			reservation_kind,
 			tunnel_id,
 			coach_id,
+			lesson_type_id,
+			customer_id,
+			athlete_id,
 			customer_first_name,
 			customer_last_name,
 			customer_phone,
 			customer_email,
+			customer_tier,
 			start_time,
 			duration_minutes,
 			end_time,
 			status,
-			notes
+			notes,
+			override_reason,
+			override_by,
+			booking_channel,
+			hold_expires_at,
+			series_id,
+			group_id,
+			session_id
 		)
 
 		VALUES (
 			@reservation_kind,
 			@tunnel_id,
 			@coach_id,
+			@lesson_type_id,
+			@customer_id,
+			@athlete_id,
 			@customer_first_name,
 			@customer_last_name,
 			@customer_phone,
 			@customer_email,
+			@customer_tier,
 			@start_time,
 			@duration_minutes,
 			@end_time,
 			@status,
-			@notes
+			@notes,
+			@override_reason,
+			@override_by,
+			@booking_channel,
+			@hold_expires_at,
+			@series_id,
+			@group_id,
+			@session_id
 		)
 
 		RETURNING *;
@@ -506,15 +561,26 @@ func Test_InsertReservationData_Error(t *testing.T) {
 		"reservation_kind":    testReservation.Kind,
 		"tunnel_id":           testReservation.TunnelId,
 		"coach_id":            testReservation.CoachId,
+		"lesson_type_id":      testReservation.LessonTypeId,
+		"customer_id":         testReservation.CustomerId,
+		"athlete_id":          testReservation.AthleteId,
 		"customer_first_name": testReservation.CustomerFirstName,
 		"customer_last_name":  testReservation.CustomerLastName,
 		"customer_phone":      testReservation.CustomerPhone,
 		"customer_email":      testReservation.CustomerEmail,
+		"customer_tier":       testReservation.CustomerTier,
 		"start_time":          testReservation.StartTime,
 		"duration_minutes":    testReservation.Duration,
 		"end_time":            testReservation.EndTime,
 		"status":              testReservation.Status,
 		"notes":               testReservation.Notes,
+		"override_reason":     testReservation.OverrideReason,
+		"override_by":         testReservation.OverrideBy,
+		"booking_channel":     testReservation.BookingChannel,
+		"hold_expires_at":     testReservation.HoldExpiresAt,
+		"series_id":           testReservation.SeriesId,
+		"group_id":            testReservation.GroupId,
+		"session_id":          testReservation.SessionId,
 	}).WillReturnError(errors.New("test error"))
 	
 	// exercise
@@ -545,15 +611,21 @@ func Test_UpdateReservationData(t *testing.T) {
 				reservation_kind = COALESCE(@reservation_kind, reservation_kind),
 				tunnel_id = COALESCE(@tunnel_id, tunnel_id),
 				coach_id = COALESCE(@coach_id, coach_id),
+				lesson_type_id = COALESCE(@lesson_type_id, lesson_type_id),
+				customer_id = COALESCE(@customer_id, customer_id),
+				athlete_id = COALESCE(@athlete_id, athlete_id),
 				customer_first_name = COALESCE(@customer_first_name, customer_first_name),
 				customer_last_name = COALESCE(@customer_last_name, customer_last_name),
 				customer_phone = COALESCE(@customer_phone, customer_phone),
 				customer_email = COALESCE(@customer_email, customer_email),
+				customer_tier = COALESCE(@customer_tier, customer_tier),
 				start_time = COALESCE(@start_time, start_time),
 				duration_minutes = COALESCE(@duration_minutes, duration_minutes),
 				end_time = COALESCE(@end_time, end_time),
 				status = COALESCE(@status, status),
 				notes = COALESCE(@notes, notes),
+				override_reason = COALESCE(@override_reason, override_reason),
+				override_by = COALESCE(@override_by, override_by),
 				updated_at = now()
 			WHERE id = @id
 			RETURNING *
@@ -584,15 +656,21 @@ func Test_UpdateReservationData(t *testing.T) {
 		"reservation_kind":    testReservationUpdates.Kind,
 		"tunnel_id":           testReservationUpdates.TunnelId,
 		"coach_id":            testReservationUpdates.CoachId,
+		"lesson_type_id":      testReservationUpdates.LessonTypeId,
+		"customer_id":         testReservationUpdates.CustomerId,
+		"athlete_id":          testReservationUpdates.AthleteId,
 		"customer_first_name": testReservationUpdates.CustomerFirstName,
 		"customer_last_name":  testReservationUpdates.CustomerLastName,
 		"customer_phone":      testReservationUpdates.CustomerPhone,
 		"customer_email":      testReservationUpdates.CustomerEmail,
+		"customer_tier":       testReservationUpdates.CustomerTier,
 		"start_time":          testReservationUpdates.StartTime,
 		"duration_minutes":    testReservationUpdates.Duration,
 		"end_time":            testReservationUpdates.EndTime,
 		"status":              testReservationUpdates.Status,
 		"notes":               testReservationUpdates.Notes,
+		"override_reason":     testReservationUpdates.OverrideReason,
+		"override_by":         testReservationUpdates.OverrideBy,
 		"id":                  pgUUID.String(),
 	}).WillReturnRows(rows)
 	
@@ -628,15 +706,21 @@ func Test_UpdateReservationData_Error(t *testing.T) {
 				reservation_kind = COALESCE(@reservation_kind, reservation_kind),
 				tunnel_id = COALESCE(@tunnel_id, tunnel_id),
 				coach_id = COALESCE(@coach_id, coach_id),
+				lesson_type_id = COALESCE(@lesson_type_id, lesson_type_id),
+				customer_id = COALESCE(@customer_id, customer_id),
+				athlete_id = COALESCE(@athlete_id, athlete_id),
 				customer_first_name = COALESCE(@customer_first_name, customer_first_name),
 				customer_last_name = COALESCE(@customer_last_name, customer_last_name),
 				customer_phone = COALESCE(@customer_phone, customer_phone),
 				customer_email = COALESCE(@customer_email, customer_email),
+				customer_tier = COALESCE(@customer_tier, customer_tier),
 				start_time = COALESCE(@start_time, start_time),
 				duration_minutes = COALESCE(@duration_minutes, duration_minutes),
 				end_time = COALESCE(@end_time, end_time),
 				status = COALESCE(@status, status),
 				notes = COALESCE(@notes, notes),
+				override_reason = COALESCE(@override_reason, override_reason),
+				override_by = COALESCE(@override_by, override_by),
 				updated_at = now()
 			WHERE id = @id
 			RETURNING *
@@ -659,15 +743,21 @@ func Test_UpdateReservationData_Error(t *testing.T) {
 		"reservation_kind":    testReservationUpdates.Kind,
 		"tunnel_id":           testReservationUpdates.TunnelId,
 		"coach_id":            testReservationUpdates.CoachId,
+		"lesson_type_id":      testReservationUpdates.LessonTypeId,
+		"customer_id":         testReservationUpdates.CustomerId,
+		"athlete_id":          testReservationUpdates.AthleteId,
 		"customer_first_name": testReservationUpdates.CustomerFirstName,
 		"customer_last_name":  testReservationUpdates.CustomerLastName,
 		"customer_phone":      testReservationUpdates.CustomerPhone,
 		"customer_email":      testReservationUpdates.CustomerEmail,
+		"customer_tier":       testReservationUpdates.CustomerTier,
 		"start_time":          testReservationUpdates.StartTime,
 		"duration_minutes":    testReservationUpdates.Duration,
 		"end_time":            testReservationUpdates.EndTime,
 		"status":              testReservationUpdates.Status,
 		"notes":               testReservationUpdates.Notes,
+		"override_reason":     testReservationUpdates.OverrideReason,
+		"override_by":         testReservationUpdates.OverrideBy,
 		"id":                  pgUUID.String(),
 	}).WillReturnError(errors.New("test error"))
 	
@@ -745,3 +835,76 @@ func Test_DeleteReservationData_Error(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func Test_ResolveReservationTimes_FromDuration(t *testing.T) {
+	// setup
+	start := time.Date(2025, 9, 5, 22, 0, 0, 0, time.UTC)
+	reservation := models.Reservation{
+		StartTime: pgtype.Timestamptz{Time: start, Valid: true},
+		Duration:  90,
+	}
+	
+	// exercise
+	err := ResolveReservationTimes(&reservation)
+	
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	
+	if !reservation.EndTime.Valid || !reservation.EndTime.Time.Equal(start.Add(90*time.Minute)) {
+		t.Fatal("expected end_time 90 minutes after start, got", reservation.EndTime.Time)
+	}
+}
+
+func Test_ResolveReservationTimes_Inconsistent(t *testing.T) {
+	// setup
+	start := time.Date(2025, 9, 5, 22, 0, 0, 0, time.UTC)
+	reservation := models.Reservation{
+		StartTime: pgtype.Timestamptz{Time: start, Valid: true},
+		Duration:  60,
+		EndTime:   pgtype.Timestamptz{Time: start.Add(30 * time.Minute), Valid: true},
+	}
+	
+	// exercise
+	err := ResolveReservationTimes(&reservation)
+	
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Fields["end_time"] == "" {
+		t.Fatal("expected end_time to be rejected, got", err)
+	}
+}
+
+func Test_ResolveReservationTimeUpdates(t *testing.T) {
+	// setup
+	start := time.Date(2025, 9, 5, 22, 0, 0, 0, time.UTC)
+	existing := models.Reservation{
+		StartTime: pgtype.Timestamptz{Time: start, Valid: true},
+		Duration:  60,
+		EndTime:   pgtype.Timestamptz{Time: start.Add(time.Hour), Valid: true},
+	}
+	
+	newStart := pgtype.Timestamptz{Time: start.Add(2 * time.Hour), Valid: true}
+	var longer int32 = 90
+	
+	moveOnly := models.ReservationUpdates{StartTime: &newStart}
+	extendOnly := models.ReservationUpdates{Duration: &longer}
+	
+	// exercise
+	moveErr := ResolveReservationTimeUpdates(existing, &moveOnly)
+	extendErr := ResolveReservationTimeUpdates(existing, &extendOnly)
+	
+	// verify
+	if moveErr != nil || extendErr != nil {
+		t.Fatal("unexpected errors:", moveErr, extendErr)
+	}
+	
+	if !moveOnly.EndTime.Time.Equal(newStart.Time.Add(time.Hour)) || *moveOnly.Duration != 60 {
+		t.Fatal("expected moving the start to keep the hour, got", moveOnly.EndTime.Time, *moveOnly.Duration)
+	}
+	
+	if !extendOnly.EndTime.Time.Equal(start.Add(90 * time.Minute)) {
+		t.Fatal("expected the longer duration to push end_time out, got", extendOnly.EndTime.Time)
+	}
+}
@@ -0,0 +1,36 @@
+package db_utils
+
+import "strings"
+
+type RuleViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// RuleViolationError is returned when a request is well formed but breaks
+// one or more scheduling rules, every broken rule is listed.
+type RuleViolationError struct {
+	Violations []RuleViolation `json:"violations"`
+}
+
+func (e *RuleViolationError) Add(rule, message string) {
+	e.Violations = append(e.Violations, RuleViolation{Rule: rule, Message: message})
+}
+
+func (e *RuleViolationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+
+	return strings.Join(messages, " ")
+}
+
+// Err returns nil when nothing was added, so it can be returned directly.
+func (e *RuleViolationError) Err() error {
+	if len(e.Violations) == 0 {
+		return nil
+	}
+
+	return e
+}
@@ -0,0 +1,194 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const (
+	RuleFacilityClosed = "facility_closed"
+	RuleOutsideHours   = "outside_hours"
+	RuleSpansDays      = "spans_multiple_days"
+	RuleBlackout       = "blackout_window"
+)
+
+const scheduleDateLayout = "Monday, Jan 2 2006"
+
+// CheckReservationSchedule makes sure [start, end) falls inside the effective
+// hours of its local date in loc and doesn't overlap a blackout window.
+func CheckReservationSchedule(ctx context.Context, conn IDBConn, loc *time.Location, start, end pgtype.Timestamptz) error {
+	violations := &RuleViolationError{}
+
+	localStart := start.Time.In(loc)
+	localEnd := end.Time.In(loc)
+	onDate := models.LocalDate(start.Time, loc)
+
+	// a reservation ending exactly at midnight still belongs to the start date
+	if !models.LocalDate(end.Time.Add(-time.Nanosecond), loc).Time.Equal(onDate.Time) {
+		violations.Add(RuleSpansDays, "Reservations must start and end on the same day.")
+	}
+
+	hours, err := ResolveEffectiveHours(ctx, conn, onDate)
+	if err != nil {
+		return err
+	}
+
+	if !hours.IsOpen {
+		message := fmt.Sprintf("The facility is closed on %s.", localStart.Format(scheduleDateLayout))
+		if hours.Notes != nil && *hours.Notes != "" {
+			message = fmt.Sprintf("The facility is closed on %s (%s).", localStart.Format(scheduleDateLayout), *hours.Notes)
+		}
+
+		violations.Add(RuleFacilityClosed, message)
+	} else {
+		openAt := hours.OpenTime.On(onDate, loc)
+		closeAt := hours.CloseTime.On(onDate, loc)
+
+		if localStart.Before(openAt) || localEnd.After(closeAt) {
+			violations.Add(RuleOutsideHours, fmt.Sprintf(
+				"Reservations on %s must be between %s and %s (%s), requested %s-%s.",
+				localStart.Format(scheduleDateLayout),
+				hours.OpenTime.String(),
+				hours.CloseTime.String(),
+				loc.String(),
+				localStart.Format("15:04"),
+				localEnd.Format("15:04"),
+			))
+		}
+	}
+
+	blackouts, err := LoadOverlappingBlackoutWindows(ctx, conn, start, end, "")
+	if err != nil {
+		return err
+	}
+
+	for _, blackout := range blackouts {
+		violations.Add(RuleBlackout, fmt.Sprintf(
+			"Overlaps the blackout %q from %s to %s.",
+			blackout.Reason,
+			blackout.StartsAt.Time.In(loc).Format("Jan 2 15:04"),
+			blackout.EndsAt.Time.In(loc).Format("Jan 2 15:04"),
+		))
+	}
+
+	return violations.Err()
+}
+
+// ScheduleCheck is what EnforceReservationSchedule needs to know about a
+// reservation being written.
+type ScheduleCheck struct {
+	StartTime      pgtype.Timestamptz
+	EndTime        pgtype.Timestamptz
+	Kind           models.ReservationKind
+	CoachId        *pgtype.UUID
+	Override       bool
+	OverrideReason *string
+	OverrideBy     *string
+
+	// BookingRules also applies the booking rules of Kind and CustomerTier,
+	// Now is when it is being booked (zero skips lead time and horizon).
+	BookingRules bool
+	CustomerTier *string
+	Now          time.Time
+}
+
+// EnforceReservationSchedule runs CheckReservationSchedule (plus
+// CheckCoachAvailability for lessons and sessions, CheckBookingRules when
+// asked for) unless staff chose to override it, in which case a reason and
+// who overrode it are required and get logged. Every broken rule is collected into a single
+// *RuleViolationError.
+func EnforceReservationSchedule(ctx context.Context, conn IDBConn, loc *time.Location, check ScheduleCheck) error {
+	start, end := check.StartTime, check.EndTime
+
+	if check.Override {
+		validationErr := &ValidationError{}
+		validateOverride(validationErr, check.OverrideReason, check.OverrideBy)
+		if err := validationErr.Err(); err != nil {
+			return err
+		}
+
+		log.Printf("[API] Schedule checks overridden by %s for %s-%s: %s\n", *check.OverrideBy, start.Time, end.Time, *check.OverrideReason)
+		return nil
+	}
+
+	validationErr := &ValidationError{}
+	if !start.Valid {
+		validationErr.Add("start_time", "is required")
+	}
+
+	if !end.Valid {
+		validationErr.Add("end_time", "is required")
+	}
+
+	if start.Valid && end.Valid && !start.Time.Before(end.Time) {
+		validationErr.Add("end_time", "must be after start_time")
+	}
+
+	if err := validationErr.Err(); err != nil {
+		return err
+	}
+
+	violations := &RuleViolationError{}
+
+	err := CheckReservationSchedule(ctx, conn, loc, start, end)
+	if !collectViolations(violations, err) {
+		return err
+	}
+
+	if check.Kind != models.ReservationKindTunnel && check.CoachId != nil {
+		err = CheckCoachAvailability(ctx, conn, loc, *check.CoachId, start, end)
+		if !collectViolations(violations, err) {
+			return err
+		}
+	}
+
+	if check.BookingRules {
+		err = CheckBookingRules(ctx, conn, loc, check)
+		if !collectViolations(violations, err) {
+			return err
+		}
+	}
+
+	return violations.Err()
+}
+
+// validateOverride requires the reason and the staff member behind an
+// override, so every skipped check can be traced back to someone.
+func validateOverride(validationErr *ValidationError, reason, by *string) {
+	if reason == nil || strings.TrimSpace(*reason) == "" {
+		validationErr.Add("override_reason", "is required when override_hours is set")
+	}
+
+	if by == nil || strings.TrimSpace(*by) == "" {
+		validationErr.Add("override_by", "is required when override_hours is set")
+	}
+}
+
+// collectViolations appends the violations carried by err and reports false
+// when err is some other failure that should be returned as is.
+func collectViolations(violations *RuleViolationError, err error) bool {
+	if err == nil {
+		return true
+	}
+
+	var violationErr *RuleViolationError
+	if !errors.As(err, &violationErr) {
+		return false
+	}
+
+	violations.Violations = append(violations.Violations, violationErr.Violations...)
+	return true
+}
+
+// BlocksSchedule reports whether a reservation in this status holds its slot,
+// matching the status filter on the overlap constraints.
+func BlocksSchedule(status models.ReservationStatus) bool {
+	return status == "" || status == models.ReservationStatusHeld || status == models.ReservationStatusConfirmed
+}
@@ -0,0 +1,223 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func indianapolis(t *testing.T) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation("America/Indiana/Indianapolis")
+	if err != nil {
+		t.Fatal("unexpected error loading location:", err)
+	}
+
+	return loc
+}
+
+// expectSaturdayHours queues the lookups for a saturday with no special hours and the seeded 10-5 schedule.
+func expectSaturdayHours(mockConn pgxmock.PgxConnIface, onDate pgtype.Date) {
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM special_hours WHERE on_date=$1`)).
+		WithArgs(onDate).
+		WillReturnError(pgx.ErrNoRows)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM business_hours WHERE dow=$1`)).
+		WithArgs(int32(6)).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"dow", "open_time", "close_time", "is_open"}).
+				AddRow(int32(6), models.NewTimeOfDay(10, 0), models.NewTimeOfDay(17, 0), true),
+		)
+}
+
+func Test_CheckReservationSchedule(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	loc := indianapolis(t)
+	start := pgtype.Timestamptz{Time: time.Date(2025, 11, 29, 10, 0, 0, 0, loc), Valid: true}
+	end := pgtype.Timestamptz{Time: time.Date(2025, 11, 29, 11, 0, 0, 0, loc), Valid: true}
+	onDate, _ := models.ParseDate("2025-11-29")
+
+	expectSaturdayHours(mockConn, onDate)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(overlappingBlackoutWindowsQuery)).WithArgs(pgx.NamedArgs{
+		"from_time":  start,
+		"to_time":    end,
+		"exclude_id": "",
+	}).WillReturnRows(pgxmock.NewRows([]string{"id"}))
+
+	// exercise
+	err := CheckReservationSchedule(context.Background(), mockConn, loc, start, end)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_CheckReservationSchedule_OutsideHoursAndBlackout(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	loc := indianapolis(t)
+	start := pgtype.Timestamptz{Time: time.Date(2025, 11, 29, 9, 30, 0, 0, loc), Valid: true}
+	end := pgtype.Timestamptz{Time: time.Date(2025, 11, 29, 10, 30, 0, 0, loc), Valid: true}
+	onDate, _ := models.ParseDate("2025-11-29")
+	blackoutId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	expectSaturdayHours(mockConn, onDate)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(overlappingBlackoutWindowsQuery)).WithArgs(pgx.NamedArgs{
+		"from_time":  start,
+		"to_time":    end,
+		"exclude_id": "",
+	}).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "starts_at", "ends_at", "reason"}).
+			AddRow(blackoutId, start, end, "Turf replacement"),
+	)
+
+	// exercise
+	err := CheckReservationSchedule(context.Background(), mockConn, loc, start, end)
+
+	// verify
+	var violationErr *RuleViolationError
+	if !errors.As(err, &violationErr) {
+		t.Fatal("expected rule violation error, got", err)
+	}
+
+	if len(violationErr.Violations) != 2 {
+		t.Fatal("expected 2 violations, got", violationErr.Violations)
+	}
+
+	if violationErr.Violations[0].Rule != RuleOutsideHours || violationErr.Violations[1].Rule != RuleBlackout {
+		t.Fatal("expected outside hours and blackout violations, got", violationErr.Violations)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_CheckReservationSchedule_DaylightSaving(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	// clocks spring forward early on 2026-03-08, 10:00 local is 14:00 UTC instead of 15:00
+	loc := indianapolis(t)
+	start := pgtype.Timestamptz{Time: time.Date(2026, 3, 8, 14, 0, 0, 0, time.UTC), Valid: true}
+	end := pgtype.Timestamptz{Time: time.Date(2026, 3, 8, 15, 0, 0, 0, time.UTC), Valid: true}
+	onDate, _ := models.ParseDate("2026-03-08")
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM special_hours WHERE on_date=$1`)).
+		WithArgs(onDate).
+		WillReturnError(pgx.ErrNoRows)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM business_hours WHERE dow=$1`)).
+		WithArgs(int32(0)).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"dow", "open_time", "close_time", "is_open"}).
+				AddRow(int32(0), models.NewTimeOfDay(10, 0), models.NewTimeOfDay(17, 0), true),
+		)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(overlappingBlackoutWindowsQuery)).WithArgs(pgx.NamedArgs{
+		"from_time":  start,
+		"to_time":    end,
+		"exclude_id": "",
+	}).WillReturnRows(pgxmock.NewRows([]string{"id"}))
+
+	// exercise
+	err := CheckReservationSchedule(context.Background(), mockConn, loc, start, end)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_EnforceReservationSchedule_OverrideWithoutReason(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	loc := indianapolis(t)
+	start := pgtype.Timestamptz{Time: time.Date(2025, 11, 29, 2, 0, 0, 0, loc), Valid: true}
+	end := pgtype.Timestamptz{Time: time.Date(2025, 11, 29, 3, 0, 0, 0, loc), Valid: true}
+
+	// exercise
+	err := EnforceReservationSchedule(context.Background(), mockConn, loc, ScheduleCheck{
+		StartTime: start,
+		EndTime:   end,
+		Kind:      models.ReservationKindTunnel,
+		Override:  true,
+	})
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	if _, ok := validationErr.Fields["override_reason"]; !ok {
+		t.Fatal("expected override_reason field error, got", validationErr.Fields)
+	}
+
+	// an override never touches the database
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_EnforceReservationSchedule_OverrideWithoutActor(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	loc := indianapolis(t)
+	start := pgtype.Timestamptz{Time: time.Date(2025, 11, 29, 2, 0, 0, 0, loc), Valid: true}
+	end := pgtype.Timestamptz{Time: time.Date(2025, 11, 29, 3, 0, 0, 0, loc), Valid: true}
+	reason := "Team practice agreed with the owner"
+
+	// exercise
+	err := EnforceReservationSchedule(context.Background(), mockConn, loc, ScheduleCheck{
+		StartTime:      start,
+		EndTime:        end,
+		Kind:           models.ReservationKindTunnel,
+		Override:       true,
+		OverrideReason: &reason,
+	})
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	if _, ok := validationErr.Fields["override_by"]; !ok {
+		t.Fatal("expected override_by field error, got", validationErr.Fields)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
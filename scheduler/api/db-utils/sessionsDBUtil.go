@@ -0,0 +1,508 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const (
+	RuleSessionCancelled = "session_cancelled"
+	RuleSessionInProgram = "session_in_program"
+)
+
+// SessionFullError is returned when enrolling in a session that has no spots
+// left and staff didn't allow overbooking it.
+type SessionFullError struct {
+	Session models.Session
+}
+
+func (e *SessionFullError) Error() string {
+	return fmt.Sprintf("%s is full (%d of %d enrolled)", e.Session.Name, e.Session.EnrolledCount, e.Session.MaxParticipants)
+}
+
+// sessionColumns adds the enrolled count and spots left to a sessions row.
+const sessionColumns = `
+		SELECT s.*, e.enrolled_count, GREATEST(s.max_participants - e.enrolled_count, 0) AS spots_left
+		FROM sessions s
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*)::int AS enrolled_count FROM session_enrollments
+			WHERE session_id = s.id AND status = 'enrolled'
+		) e
+	`
+
+func LoadSessionData(ctx context.Context, conn IDBConn, from, to pgtype.Timestamptz) ([]models.Session, error) {
+	sessions := make([]models.Session, 0)
+
+	args := pgx.NamedArgs{
+		"from_time": from,
+		"to_time":   to,
+	}
+
+	query := sessionColumns + `
+		WHERE (@from_time::timestamptz IS NULL OR s.end_time > @from_time::timestamptz)
+			AND (@to_time::timestamptz IS NULL OR s.start_time < @to_time::timestamptz)
+		ORDER BY s.start_time ASC
+	`
+
+	err := pgxscan.Select(ctx, conn, &sessions, query, args)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// LoadSessionById loads a session with its tunnel blocks.
+func LoadSessionById(ctx context.Context, conn IDBConn, id string) (*models.SessionResult, error) {
+	var result models.SessionResult
+
+	err := pgxscan.Get(ctx, conn, &result.Session, sessionColumns+` WHERE s.id = $1`, id)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find session with id:", id)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	result.Reservations = make([]models.Reservation, 0)
+
+	query := `
+		SELECT * FROM reservations
+		WHERE session_id = $1
+		ORDER BY tunnel_id ASC
+	`
+
+	if err := pgxscan.Select(ctx, conn, &result.Reservations, query, id); err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// InsertSession creates the session and a confirmed 'session' reservation on
+// each of its tunnels in one transaction. The coach is booked on the first
+// tunnel's block only, since they can't be on two at once as far as the
+// overlap constraints are concerned.
+func InsertSession(ctx context.Context, conn IDBTxConn, session models.Session) (*models.SessionResult, error) {
+	if err := validateSession(session); err != nil {
+		return nil, err
+	}
+
+	if session.CoachId != nil {
+		if err := checkCoachActive(ctx, conn, *session.CoachId, "coach_id"); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	result := &models.SessionResult{Reservations: make([]models.Reservation, 0, len(session.TunnelIds))}
+
+	inserted, err := insertSessionRow(ctx, tx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Session = *inserted
+
+	var overrideReason, overrideBy *string
+	if session.OverrideHours {
+		overrideReason, overrideBy = session.OverrideReason, session.OverrideBy
+	}
+
+	duration := int32(session.EndTime.Time.Sub(session.StartTime.Time).Minutes())
+
+	for i, tunnelId := range session.TunnelIds {
+		// blocks carry the session's name where a rental has the customer's
+		block := models.Reservation{
+			Kind:              models.ReservationKindSession,
+			TunnelId:          &tunnelId,
+			CustomerFirstName: session.Name,
+			StartTime:         session.StartTime,
+			Duration:          duration,
+			EndTime:           session.EndTime,
+			Status:            models.ReservationStatusConfirmed,
+			Notes:             session.Notes,
+			OverrideReason:    overrideReason,
+			OverrideBy:        overrideBy,
+			BookingChannel:    models.BookingChannelWeb,
+			SessionId:         &result.Session.Id,
+		}
+
+		if i == 0 {
+			block.CoachId = session.CoachId
+		}
+
+		reservation, err := InsertReservationData(ctx, tx, block)
+		if err != nil {
+			log.Println("[API] Error inserting session block:", err)
+			return nil, err
+		}
+
+		result.Reservations = append(result.Reservations, *reservation)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing session:", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// insertSessionRow writes the sessions row alone, the blocks are up to the caller.
+func insertSessionRow(ctx context.Context, conn IDBConn, session models.Session) (*models.Session, error) {
+	args := pgx.NamedArgs{
+		"session_kind":     session.Kind,
+		"name":             session.Name,
+		"coach_id":         session.CoachId,
+		"start_time":       session.StartTime,
+		"end_time":         session.EndTime,
+		"max_participants": session.MaxParticipants,
+		"price_cents":      session.PriceCents,
+		"notes":            session.Notes,
+		"program_id":       session.ProgramId,
+	}
+
+	const query = `
+		INSERT INTO sessions (
+			session_kind,
+			name,
+			coach_id,
+			start_time,
+			end_time,
+			max_participants,
+			price_cents,
+			notes,
+			program_id
+		)
+
+		VALUES (
+			@session_kind,
+			@name,
+			@coach_id,
+			@start_time,
+			@end_time,
+			@max_participants,
+			@price_cents,
+			@notes,
+			@program_id
+		)
+
+		RETURNING *, 0 AS enrolled_count, max_participants AS spots_left;
+	`
+
+	var out models.Session
+	if err := pgxscan.Get(ctx, conn, &out, query, args); err != nil {
+		log.Println("[API] Error inserting session:", err)
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// checkCoachActive makes sure the coach given in field exists and still coaches.
+func checkCoachActive(ctx context.Context, conn IDBConn, coachId pgtype.UUID, field string) error {
+	coach, err := LoadCoachById(ctx, conn, coachId)
+	if err != nil {
+		return err
+	}
+
+	if coach == nil {
+		validationErr := &ValidationError{}
+		validationErr.Add(field, "does not exist")
+		return validationErr
+	}
+
+	if !coach.IsActive {
+		violations := &RuleViolationError{}
+		violations.Add(RuleCoachInactive, fmt.Sprintf("%s is not currently coaching.", coachName(*coach)))
+		return violations
+	}
+
+	return nil
+}
+
+// CancelSession marks the session cancelled and cancels its active tunnel
+// blocks, recording each change. The roster is kept as it was.
+func CancelSession(ctx context.Context, conn IDBTxConn, id string, change models.ReservationStatusChangeRequest) ([]models.Reservation, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `UPDATE sessions SET cancelled_at = now(), updated_at = now() WHERE id = $1 AND cancelled_at IS NULL`, id)
+	if err != nil {
+		log.Println("[API] Error cancelling session:", err)
+		return nil, err
+	}
+
+	cancelled := make([]models.Reservation, 0)
+
+	args := pgx.NamedArgs{
+		"session_id": id,
+		"changed_by": change.ChangedBy,
+		"reason":     change.Reason,
+	}
+
+	query := `
+		WITH targets AS (
+			SELECT id, status FROM reservations
+			WHERE session_id = @session_id AND status IN ('held', 'confirmed')
+			FOR UPDATE
+		), cancelled AS (
+			UPDATE reservations
+			SET status = 'cancelled', hold_expires_at = NULL, updated_at = now()
+			WHERE id IN (SELECT id FROM targets)
+			RETURNING *
+		), history AS (
+			INSERT INTO reservation_status_changes (reservation_id, from_status, to_status, changed_by, reason)
+			SELECT id, status, 'cancelled', @changed_by, @reason FROM targets
+		)
+		SELECT * FROM cancelled ORDER BY tunnel_id ASC
+	`
+
+	if err := pgxscan.Select(ctx, tx, &cancelled, query, args); err != nil {
+		log.Println("[API] Error cancelling session blocks:", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing session cancellation:", err)
+		return nil, err
+	}
+
+	return cancelled, nil
+}
+
+// LoadSessionRoster lists a session's participants in the order they
+// enrolled, withdrawn ones only when asked for.
+func LoadSessionRoster(ctx context.Context, conn IDBConn, sessionId string, includeWithdrawn bool) ([]models.SessionEnrollment, error) {
+	roster := make([]models.SessionEnrollment, 0)
+
+	args := pgx.NamedArgs{
+		"session_id":        sessionId,
+		"include_withdrawn": includeWithdrawn,
+	}
+
+	query := `
+		SELECT * FROM session_enrollments
+		WHERE session_id = @session_id
+			AND (@include_withdrawn OR status = 'enrolled')
+		ORDER BY enrolled_at ASC
+	`
+
+	err := pgxscan.Select(ctx, conn, &roster, query, args)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return roster, nil
+}
+
+// EnrollInSession adds a participant to the roster. The session row is
+// locked while counting so two enrollments can't both take the last spot.
+// A full session returns a *SessionFullError unless AllowOverbook is set, in
+// which case the enrollment is flagged as overbooked. Returns nil, nil when
+// the session doesn't exist.
+func EnrollInSession(ctx context.Context, conn IDBTxConn, sessionId string, enrollment models.SessionEnrollment) (*models.SessionEnrollment, error) {
+	if err := validateEnrollment(enrollment); err != nil {
+		return nil, err
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var session models.Session
+	err = pgxscan.Get(ctx, tx, &session, sessionColumns+` WHERE s.id = $1 FOR UPDATE OF s`, sessionId)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find session with id:", sessionId)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error locking session:", err)
+		return nil, err
+	}
+
+	if session.CancelledAt.Valid {
+		violations := &RuleViolationError{}
+		violations.Add(RuleSessionCancelled, fmt.Sprintf("%s has been cancelled.", session.Name))
+		return nil, violations
+	}
+
+	if session.ProgramId != nil {
+		violations := &RuleViolationError{}
+		violations.Add(RuleSessionInProgram, fmt.Sprintf("%s is a day of a program, athletes enroll in the program.", session.Name))
+		return nil, violations
+	}
+
+	full := session.EnrolledCount >= session.MaxParticipants
+	if full && !enrollment.AllowOverbook {
+		return nil, &SessionFullError{Session: session}
+	}
+
+	args := pgx.NamedArgs{
+		"session_id":             session.Id,
+		"participant_first_name": enrollment.ParticipantFirstName,
+		"participant_last_name":  enrollment.ParticipantLastName,
+		"participant_phone":      enrollment.ParticipantPhone,
+		"participant_email":      enrollment.ParticipantEmail,
+		"overbooked":             full,
+		"notes":                  enrollment.Notes,
+	}
+
+	const query = `
+		INSERT INTO session_enrollments (
+			session_id,
+			participant_first_name,
+			participant_last_name,
+			participant_phone,
+			participant_email,
+			overbooked,
+			notes
+		)
+
+		VALUES (
+			@session_id,
+			@participant_first_name,
+			@participant_last_name,
+			@participant_phone,
+			@participant_email,
+			@overbooked,
+			@notes
+		)
+
+		RETURNING *;
+	`
+
+	var out models.SessionEnrollment
+	if err := pgxscan.Get(ctx, tx, &out, query, args); err != nil {
+		log.Println("[API] Error inserting enrollment:", err)
+		return nil, participantEnrolled(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing enrollment:", err)
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// WithdrawFromSession takes an enrolled participant off the roster, the
+// entry is kept as withdrawn.
+func WithdrawFromSession(ctx context.Context, conn IDBConn, sessionId, enrollmentId string) (int64, error) {
+	cmdTag, err := conn.Exec(
+		ctx,
+		`UPDATE session_enrollments SET status = 'withdrawn', withdrawn_at = now(), updated_at = now() WHERE id = $1 AND session_id = $2 AND status = 'enrolled'`,
+		enrollmentId,
+		sessionId,
+	)
+
+	if err != nil {
+		log.Println("[API] Error withdrawing from session:", err)
+		return 0, err
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
+
+// participantEnrolled turns the one-active-enrollment-per-participant
+// violation into a field error.
+func participantEnrolled(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		validationErr := &ValidationError{}
+		validationErr.Add("participant_first_name", "this participant is already enrolled")
+		return validationErr
+	}
+
+	return err
+}
+
+func validateSession(session models.Session) error {
+	validationErr := &ValidationError{}
+
+	if session.Kind != models.SessionKindClinic && session.Kind != models.SessionKindDropIn {
+		validationErr.Add("session_kind", "must be clinic or drop_in")
+	}
+
+	if strings.TrimSpace(session.Name) == "" {
+		validationErr.Add("name", "is required")
+	}
+
+	if session.Kind == models.SessionKindClinic && session.CoachId == nil {
+		validationErr.Add("coach_id", "is required for clinics")
+	}
+
+	if len(session.TunnelIds) == 0 {
+		validationErr.Add("tunnel_ids", "must list at least one tunnel")
+	}
+
+	seen := make(map[int32]bool, len(session.TunnelIds))
+	for _, tunnelId := range session.TunnelIds {
+		if seen[tunnelId] {
+			validationErr.Add("tunnel_ids", fmt.Sprintf("tunnel %d is listed twice", tunnelId))
+		}
+		seen[tunnelId] = true
+	}
+
+	if session.MaxParticipants <= 0 {
+		validationErr.Add("max_participants", "must be greater than 0")
+	}
+
+	if session.PriceCents < 0 {
+		validationErr.Add("price_cents", "must be 0 or more")
+	}
+
+	validateGroupTimes(validationErr, session.StartTime, session.EndTime)
+
+	return validationErr.Err()
+}
+
+func validateEnrollment(enrollment models.SessionEnrollment) error {
+	validationErr := &ValidationError{}
+
+	if strings.TrimSpace(enrollment.ParticipantFirstName) == "" {
+		validationErr.Add("participant_first_name", "is required")
+	}
+
+	if strings.TrimSpace(enrollment.ParticipantLastName) == "" {
+		validationErr.Add("participant_last_name", "is required")
+	}
+
+	if strings.TrimSpace(enrollment.ParticipantPhone) == "" {
+		validationErr.Add("participant_phone", "is required")
+	}
+
+	return validationErr.Err()
+}
@@ -0,0 +1,217 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const lockSessionQuery = `WHERE s.id = $1 FOR UPDATE OF s`
+
+func testSession(tunnelIds ...int32) models.Session {
+	start := time.Date(2025, 9, 6, 9, 0, 0, 0, time.UTC)
+
+	return models.Session{
+		Kind:            models.SessionKindDropIn,
+		Name:            "Open Hitting",
+		TunnelIds:       tunnelIds,
+		StartTime:       pgtype.Timestamptz{Time: start, Valid: true},
+		EndTime:         pgtype.Timestamptz{Time: start.Add(2 * time.Hour), Valid: true},
+		MaxParticipants: 10,
+		PriceCents:      1500,
+	}
+}
+
+func testEnrollment() models.SessionEnrollment {
+	return models.SessionEnrollment{
+		ParticipantFirstName: "Timmy",
+		ParticipantLastName:  "Doe",
+		ParticipantPhone:     "5551234567",
+	}
+}
+
+func Test_InsertSession(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	session := testSession(1, 2)
+	sessionId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO sessions")).WithArgs(anyArgs(9)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "name", "max_participants", "enrolled_count", "spots_left"}).
+			AddRow(sessionId, session.Name, int32(10), int32(0), int32(10)),
+	)
+
+	for _, tunnelId := range session.TunnelIds {
+		mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO reservations")).WithArgs(anyArgs(23)...).WillReturnRows(
+			pgxmock.NewRows([]string{"id", "reservation_kind", "tunnel_id", "session_id"}).
+				AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, models.ReservationKindSession, &tunnelId, &sessionId),
+		)
+	}
+
+	mockConn.ExpectCommit()
+	mockConn.ExpectRollback()
+
+	// exercise
+	result, err := InsertSession(context.Background(), mockConn, session)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if result.Session.Id != sessionId || result.Session.SpotsLeft != 10 {
+		t.Fatal("expected an empty session", sessionId.String(), "got", result.Session)
+	}
+
+	if len(result.Reservations) != 2 {
+		t.Fatal("expected a block on each tunnel, got", len(result.Reservations))
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_InsertSession_ClinicNeedsCoach(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	session := testSession(1, 1)
+	session.Kind = models.SessionKindClinic
+
+	// exercise
+	result, err := InsertSession(context.Background(), mockConn, session)
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected a validation error, got", err)
+	}
+
+	if validationErr.Fields["coach_id"] == "" || validationErr.Fields["tunnel_ids"] == "" {
+		t.Fatal("expected coach_id and tunnel_ids to be rejected, got", validationErr.Fields)
+	}
+
+	if result != nil {
+		t.Fatal("expected nothing to be written, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_EnrollInSession_Full(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	sessionId := uuid.New().String()
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta(lockSessionQuery)).WithArgs(sessionId).WillReturnRows(
+		pgxmock.NewRows([]string{"name", "max_participants", "enrolled_count", "spots_left"}).
+			AddRow("Open Hitting", int32(2), int32(2), int32(0)),
+	)
+	mockConn.ExpectRollback()
+
+	// exercise
+	result, err := EnrollInSession(context.Background(), mockConn, sessionId, testEnrollment())
+
+	// verify
+	var fullErr *SessionFullError
+	if !errors.As(err, &fullErr) {
+		t.Fatal("expected the session to be full, got", err)
+	}
+
+	if result != nil {
+		t.Fatal("expected nobody to be enrolled, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_EnrollInSession_Overbook(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	sessionId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	enrollment := testEnrollment()
+	enrollment.AllowOverbook = true
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta(lockSessionQuery)).WithArgs(sessionId.String()).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "name", "max_participants", "enrolled_count", "spots_left"}).
+			AddRow(sessionId, "Open Hitting", int32(2), int32(2), int32(0)),
+	)
+
+	// overbooked is the 6th arg
+	args := anyArgs(7)
+	args[5] = true
+
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO session_enrollments")).WithArgs(args...).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "session_id", "status", "overbooked"}).
+			AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, sessionId, models.EnrollmentStatusEnrolled, true),
+	)
+
+	mockConn.ExpectCommit()
+	mockConn.ExpectRollback()
+
+	// exercise
+	result, err := EnrollInSession(context.Background(), mockConn, sessionId.String(), enrollment)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if !result.Overbooked {
+		t.Fatal("expected the enrollment to be flagged as overbooked")
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_EnrollInSession_Cancelled(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	sessionId := uuid.New().String()
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta(lockSessionQuery)).WithArgs(sessionId).WillReturnRows(
+		pgxmock.NewRows([]string{"name", "max_participants", "enrolled_count", "spots_left", "cancelled_at"}).
+			AddRow("Open Hitting", int32(10), int32(0), int32(10), pgtype.Timestamptz{Time: time.Now(), Valid: true}),
+	)
+	mockConn.ExpectRollback()
+
+	// exercise
+	_, err := EnrollInSession(context.Background(), mockConn, sessionId, testEnrollment())
+
+	// verify
+	var violationErr *RuleViolationError
+	if !errors.As(err, &violationErr) || violationErr.Violations[0].Rule != RuleSessionCancelled {
+		t.Fatal("expected a session_cancelled violation, got", err)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
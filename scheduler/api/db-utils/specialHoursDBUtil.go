@@ -0,0 +1,280 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// LoadSpecialHoursData lists special hours between two dates (inclusive).
+// Either bound may be left invalid to leave that side of the range open.
+func LoadSpecialHoursData(ctx context.Context, conn IDBConn, fromDate, toDate pgtype.Date) ([]models.SpecialHours, error) {
+	specialHours := make([]models.SpecialHours, 0)
+
+	args := pgx.NamedArgs{
+		"from_date": fromDate,
+		"to_date":   toDate,
+	}
+
+	query := `
+		SELECT * FROM special_hours
+		WHERE (@from_date::date IS NULL OR on_date >= @from_date::date)
+			AND (@to_date::date IS NULL OR on_date <= @to_date::date)
+		ORDER BY on_date ASC
+	`
+
+	err := pgxscan.Select(ctx, conn, &specialHours, query, args)
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return specialHours, nil
+}
+
+func LoadSpecialHoursById(ctx context.Context, conn IDBConn, id string) (*models.SpecialHours, error) {
+	var specialHours models.SpecialHours
+
+	query := `SELECT * FROM special_hours WHERE id=$1`
+
+	err := pgxscan.Get(ctx, conn, &specialHours, query, id)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] No special hours found with id:", id)
+		return nil, nil
+	} else if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &specialHours, nil
+}
+
+func LoadSpecialHoursByDate(ctx context.Context, conn IDBConn, onDate pgtype.Date) (*models.SpecialHours, error) {
+	var specialHours models.SpecialHours
+
+	query := `SELECT * FROM special_hours WHERE on_date=$1`
+
+	err := pgxscan.Get(ctx, conn, &specialHours, query, onDate)
+
+	if pgxscan.NotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &specialHours, nil
+}
+
+func InsertSpecialHoursData(ctx context.Context, conn IDBConn, input models.SpecialHoursUpdates) (*models.SpecialHours, error) {
+	specialHours := models.SpecialHours{IsOpen: true}
+
+	validationErr := &ValidationError{}
+	if input.OnDate == nil {
+		validationErr.Add("on_date", "is required")
+	}
+
+	applySpecialHoursUpdates(validationErr, &specialHours, input)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	args := pgx.NamedArgs{
+		"on_date":    specialHours.OnDate,
+		"open_time":  specialHours.OpenTime,
+		"close_time": specialHours.CloseTime,
+		"is_open":    specialHours.IsOpen,
+		"notes":      specialHours.Notes,
+	}
+
+	const query = `
+		INSERT INTO special_hours (
+			on_date,
+			open_time,
+			close_time,
+			is_open,
+			notes
+		)
+
+		VALUES (
+			@on_date,
+			@open_time,
+			@close_time,
+			@is_open,
+			@notes
+		)
+
+		RETURNING *;
+	`
+
+	var out models.SpecialHours
+	if err := pgxscan.Get(ctx, conn, &out, query, args); err != nil {
+		return nil, specialHoursDateTaken(err)
+	}
+
+	return &out, nil
+}
+
+func UpdateSpecialHoursData(ctx context.Context, conn IDBConn, id string, updates models.SpecialHoursUpdates) (*models.SpecialHours, error) {
+	existing, err := LoadSpecialHoursById(ctx, conn, id)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	validationErr := &ValidationError{}
+	applySpecialHoursUpdates(validationErr, existing, updates)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	var updatedSpecialHours models.SpecialHours
+
+	args := pgx.NamedArgs{
+		"id":         id,
+		"on_date":    existing.OnDate,
+		"open_time":  existing.OpenTime,
+		"close_time": existing.CloseTime,
+		"is_open":    existing.IsOpen,
+		"notes":      existing.Notes,
+	}
+
+	query := `
+			UPDATE special_hours
+			SET
+				on_date = @on_date,
+				open_time = @open_time,
+				close_time = @close_time,
+				is_open = @is_open,
+				notes = @notes
+			WHERE id = @id
+			RETURNING *
+	`
+
+	err = pgxscan.Get(ctx, conn, &updatedSpecialHours, query, args)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find special hours with id:", id)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating special hours:", err)
+		return nil, specialHoursDateTaken(err)
+	}
+
+	return &updatedSpecialHours, nil
+}
+
+func DeleteSpecialHoursData(ctx context.Context, conn IDBConn, id string) (int64, error) {
+	cmdTag, err := conn.Exec(
+		ctx,
+		"DELETE FROM special_hours WHERE id=$1",
+		id,
+	)
+
+	if err != nil {
+		log.Println("[API] Error deleting special hours:", err)
+		return 0, err
+	}
+
+	return cmdTag.RowsAffected(), err
+}
+
+// ResolveEffectiveHours returns the hours that apply on a local calendar
+// date: a special_hours row for that date wins, otherwise the business_hours
+// for its day of week are used. A day with neither is reported as closed.
+func ResolveEffectiveHours(ctx context.Context, conn IDBConn, onDate pgtype.Date) (*models.EffectiveHours, error) {
+	specialHours, err := LoadSpecialHoursByDate(ctx, conn, onDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if specialHours != nil {
+		return &models.EffectiveHours{
+			Date:      onDate,
+			OpenTime:  specialHours.OpenTime,
+			CloseTime: specialHours.CloseTime,
+			IsOpen:    specialHours.IsOpen,
+			Source:    models.HoursSourceSpecial,
+			Notes:     specialHours.Notes,
+		}, nil
+	}
+
+	businessHours, err := LoadBusinessHoursByDow(ctx, conn, int32(onDate.Time.Weekday()))
+	if err != nil {
+		return nil, err
+	}
+
+	if businessHours == nil {
+		return &models.EffectiveHours{
+			Date:   onDate,
+			IsOpen: false,
+			Source: models.HoursSourceNone,
+		}, nil
+	}
+
+	return &models.EffectiveHours{
+		Date:      onDate,
+		OpenTime:  businessHours.OpenTime,
+		CloseTime: businessHours.CloseTime,
+		IsOpen:    businessHours.IsOpen,
+		Source:    models.HoursSourceBusiness,
+	}, nil
+}
+
+// applySpecialHoursUpdates merges the raw input onto specialHours. A closed
+// day may leave out its times, they default to the whole day so the table
+// constraints still hold.
+func applySpecialHoursUpdates(validationErr *ValidationError, specialHours *models.SpecialHours, updates models.SpecialHoursUpdates) {
+	if updates.OnDate != nil {
+		onDate, err := models.ParseDate(*updates.OnDate)
+		if err != nil {
+			validationErr.Add("on_date", "must be a date formatted as YYYY-MM-DD")
+		} else {
+			specialHours.OnDate = onDate
+		}
+	}
+
+	if updates.IsOpen != nil && !*updates.IsOpen {
+		if updates.OpenTime == nil && !specialHours.OpenTime.Valid {
+			specialHours.OpenTime = models.NewTimeOfDay(0, 0)
+		}
+
+		if updates.CloseTime == nil && !specialHours.CloseTime.Valid {
+			specialHours.CloseTime = models.NewTimeOfDay(23, 59)
+		}
+	}
+
+	if updates.Notes != nil {
+		specialHours.Notes = updates.Notes
+	}
+
+	applyHoursUpdates(
+		validationErr,
+		&specialHours.OpenTime,
+		&specialHours.CloseTime,
+		&specialHours.IsOpen,
+		updates.OpenTime,
+		updates.CloseTime,
+		updates.IsOpen,
+	)
+}
+
+// specialHoursDateTaken turns the UNIQUE (on_date) violation into a field error.
+func specialHoursDateTaken(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		validationErr := &ValidationError{}
+		validationErr.Add("on_date", "special hours already exist for this date")
+		return validationErr
+	}
+
+	return err
+}
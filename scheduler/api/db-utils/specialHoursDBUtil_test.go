@@ -0,0 +1,190 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func Test_LoadSpecialHoursData(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	query := `
+		SELECT * FROM special_hours
+		WHERE (@from_date::date IS NULL OR on_date >= @from_date::date)
+			AND (@to_date::date IS NULL OR on_date <= @to_date::date)
+		ORDER BY on_date ASC
+	`
+
+	fromDate, _ := models.ParseDate("2025-11-01")
+	toDate, _ := models.ParseDate("2025-11-30")
+	pgU := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	rows := pgxmock.NewRows([]string{"id", "on_date", "is_open"}).
+		AddRow(pgU, fromDate, false)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(pgx.NamedArgs{
+		"from_date": fromDate,
+		"to_date":   toDate,
+	}).WillReturnRows(rows)
+
+	// exercise
+	result, err := LoadSpecialHoursData(context.Background(), mockConn, fromDate, toDate)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(result) != 1 {
+		t.Fatal("expected 1 row, got", len(result))
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_InsertSpecialHoursData_MissingDate(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	isOpen := false
+
+	// exercise
+	result, err := InsertSpecialHoursData(context.Background(), mockConn, models.SpecialHoursUpdates{IsOpen: &isOpen})
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	if _, ok := validationErr.Fields["on_date"]; !ok {
+		t.Fatal("expected on_date field error, got", validationErr.Fields)
+	}
+
+	// closed days should not need times
+	if len(validationErr.Fields) != 1 {
+		t.Fatal("expected only the on_date error, got", validationErr.Fields)
+	}
+
+	if result != nil {
+		t.Fatal("expected no result, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ResolveEffectiveHours_SpecialHours(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	// thanksgiving
+	onDate, _ := models.ParseDate("2025-11-27")
+	notes := "Thanksgiving"
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM special_hours WHERE on_date=$1`)).
+		WithArgs(onDate).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"on_date", "open_time", "close_time", "is_open", "notes"}).
+				AddRow(onDate, models.NewTimeOfDay(0, 0), models.NewTimeOfDay(23, 59), false, &notes),
+		)
+
+	// exercise
+	result, err := ResolveEffectiveHours(context.Background(), mockConn, onDate)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if result.IsOpen || result.Source != models.HoursSourceSpecial {
+		t.Fatal("expected closed special hours, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ResolveEffectiveHours_BusinessHours(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	// a saturday
+	onDate, _ := models.ParseDate("2025-11-29")
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM special_hours WHERE on_date=$1`)).
+		WithArgs(onDate).
+		WillReturnError(pgx.ErrNoRows)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM business_hours WHERE dow=$1`)).
+		WithArgs(int32(6)).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"dow", "open_time", "close_time", "is_open"}).
+				AddRow(int32(6), models.NewTimeOfDay(10, 0), models.NewTimeOfDay(17, 0), true),
+		)
+
+	// exercise
+	result, err := ResolveEffectiveHours(context.Background(), mockConn, onDate)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if !result.IsOpen || result.Source != models.HoursSourceBusiness {
+		t.Fatal("expected open business hours, got", result)
+	}
+
+	if result.OpenTime.String() != "10:00" || result.CloseTime.String() != "17:00" {
+		t.Fatal("expected 10:00-17:00, got", result.OpenTime.String(), "-", result.CloseTime.String())
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ResolveEffectiveHours_Error(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	onDate, _ := models.ParseDate("2025-11-29")
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM special_hours WHERE on_date=$1`)).
+		WithArgs(onDate).
+		WillReturnError(errors.New("test error"))
+
+	// exercise
+	result, err := ResolveEffectiveHours(context.Background(), mockConn, onDate)
+
+	// verify
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	if result != nil {
+		t.Fatal("expected no result, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,286 @@
+package db_utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// NoTunnelAvailableError is returned when every active tunnel is booked for
+// an "any tunnel" request, nothing was written.
+type NoTunnelAvailableError struct {
+	StartTime pgtype.Timestamptz
+	EndTime   pgtype.Timestamptz
+}
+
+func (e *NoTunnelAvailableError) Error() string {
+	return fmt.Sprintf("no tunnel is free from %s to %s", e.StartTime.Time.Format(time.RFC3339), e.EndTime.Time.Format(time.RFC3339))
+}
+
+// TunnelAssignment is what a strategy knows about the booking it is placing.
+type TunnelAssignment struct {
+	Slot              Interval
+	Day               Interval             // the booking's local day
+	Reservations      []models.Reservation // held/confirmed bookings on every tunnel that day
+	PreviousTunnelIds []int32              // the customer's tunnels, most recently booked first
+}
+
+// TunnelAssignmentStrategy decides which free tunnel an "any tunnel" booking
+// gets. free is never empty and sorted by id.
+type TunnelAssignmentStrategy interface {
+	Pick(free []models.Tunnel, assignment TunnelAssignment) models.Tunnel
+}
+
+// LowestNumberStrategy fills tunnel 1 first, then 2, and so on.
+type LowestNumberStrategy struct{}
+
+func (LowestNumberStrategy) Pick(free []models.Tunnel, _ TunnelAssignment) models.Tunnel {
+	return free[0]
+}
+
+// LeastUtilizedStrategy spreads wear by picking the tunnel booked for the
+// fewest minutes that day.
+type LeastUtilizedStrategy struct{}
+
+func (LeastUtilizedStrategy) Pick(free []models.Tunnel, assignment TunnelAssignment) models.Tunnel {
+	best := free[0]
+	bestBooked := bookedTime(best.Id, assignment)
+
+	for _, tunnel := range free[1:] {
+		if booked := bookedTime(tunnel.Id, assignment); booked < bestBooked {
+			best, bestBooked = tunnel, booked
+		}
+	}
+
+	return best
+}
+
+// RepeatTunnelStrategy gives a customer the tunnel they booked most recently
+// when it is free, otherwise Fallback decides.
+type RepeatTunnelStrategy struct {
+	Fallback TunnelAssignmentStrategy
+}
+
+func (s RepeatTunnelStrategy) Pick(free []models.Tunnel, assignment TunnelAssignment) models.Tunnel {
+	for _, tunnelId := range assignment.PreviousTunnelIds {
+		if i := slices.IndexFunc(free, func(tunnel models.Tunnel) bool { return tunnel.Id == tunnelId }); i >= 0 {
+			return free[i]
+		}
+	}
+
+	return s.Fallback.Pick(free, assignment)
+}
+
+// PackingStrategy puts the booking right up against another one where it
+// can, leaving the smallest sliver of free time next to it, so the free time
+// left on the tunnels stays in long contiguous blocks. Ties go to the
+// tightest fit overall.
+type PackingStrategy struct{}
+
+func (PackingStrategy) Pick(free []models.Tunnel, assignment TunnelAssignment) models.Tunnel {
+	best := free[0]
+	bestBefore, bestAfter := gapsAround(best.Id, assignment)
+
+	for _, tunnel := range free[1:] {
+		before, after := gapsAround(tunnel.Id, assignment)
+
+		sliver, bestSliver := min(before, after), min(bestBefore, bestAfter)
+		if sliver < bestSliver || (sliver == bestSliver && before+after < bestBefore+bestAfter) {
+			best, bestBefore, bestAfter = tunnel, before, after
+		}
+	}
+
+	return best
+}
+
+var tunnelAssignmentStrategies = map[string]TunnelAssignmentStrategy{
+	"lowest_number":   LowestNumberStrategy{},
+	"least_utilized":  LeastUtilizedStrategy{},
+	"repeat_customer": RepeatTunnelStrategy{Fallback: LowestNumberStrategy{}},
+	"pack":            PackingStrategy{},
+}
+
+// TunnelAssignmentStrategyByName looks up a strategy by its config name:
+// lowest_number, least_utilized, repeat_customer or pack.
+func TunnelAssignmentStrategyByName(name string) (TunnelAssignmentStrategy, bool) {
+	strategy, ok := tunnelAssignmentStrategies[name]
+	return strategy, ok
+}
+
+// InsertReservationWithAssignedTunnel books a reservation that didn't ask for
+// a specific tunnel on one that strategy picks among the active tunnels free
+// for the whole slot, see assignTunnel.
+func InsertReservationWithAssignedTunnel(ctx context.Context, conn IDBTxConn, loc *time.Location, strategy TunnelAssignmentStrategy, r models.Reservation) (*models.Reservation, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tunnelId, err := assignTunnel(ctx, tx, loc, strategy, r)
+	if err != nil {
+		return nil, err
+	}
+
+	r.TunnelId = &tunnelId
+
+	out, err := InsertReservationData(ctx, tx, r)
+	if err != nil {
+		log.Println("[API] Error inserting reservation:", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing reservation:", err)
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// assignTunnel picks the tunnel for r inside tx. The active tunnels stay
+// locked until tx ends, so concurrent requests wait their turn instead of
+// picking the same tunnel.
+func assignTunnel(ctx context.Context, tx IDBConn, loc *time.Location, strategy TunnelAssignmentStrategy, r models.Reservation) (int32, error) {
+	// NO KEY so reservations referencing the tunnels can still be written meanwhile
+	tunnels := make([]models.Tunnel, 0)
+	err := pgxscan.Select(ctx, tx, &tunnels, `SELECT * FROM tunnels WHERE is_active ORDER BY id ASC FOR NO KEY UPDATE`)
+	if err != nil {
+		log.Println("[API] Error locking tunnels:", err)
+		return 0, err
+	}
+
+	onDate := models.LocalDate(r.StartTime.Time, loc)
+	dayStart := time.Date(onDate.Time.Year(), onDate.Time.Month(), onDate.Time.Day(), 0, 0, 0, 0, loc)
+
+	assignment := TunnelAssignment{
+		Slot: reservationInterval(r),
+		Day:  Interval{Start: dayStart, End: dayStart.AddDate(0, 0, 1)},
+	}
+
+	var maxBuffer time.Duration
+	for _, tunnel := range tunnels {
+		maxBuffer = max(maxBuffer, tunnel.TurnoverBuffer())
+	}
+
+	// a booking just after the slot still blocks it by the candidate's own buffer
+	from := pgtype.Timestamptz{Time: earliest(assignment.Day.Start, assignment.Slot.Start), Valid: true}
+	to := pgtype.Timestamptz{Time: latest(assignment.Day.End, assignment.Slot.End).Add(maxBuffer), Valid: true}
+
+	assignment.Reservations, err = LoadActiveReservationsWithTurnover(ctx, tx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	assignment.PreviousTunnelIds, err = LoadCustomerTunnelIds(ctx, tx, r.CustomerPhone)
+	if err != nil {
+		return 0, err
+	}
+
+	free := make([]models.Tunnel, 0, len(tunnels))
+	for _, tunnel := range tunnels {
+		busy := slices.ContainsFunc(assignment.Reservations, func(reservation models.Reservation) bool {
+			return reservation.TunnelId != nil && *reservation.TunnelId == tunnel.Id &&
+				tunnelBusyInterval(reservation, tunnel.TurnoverBuffer()).Overlaps(assignment.Slot)
+		})
+
+		if !busy {
+			free = append(free, tunnel)
+		}
+	}
+
+	if len(free) == 0 {
+		return 0, &NoTunnelAvailableError{StartTime: r.StartTime, EndTime: r.EndTime}
+	}
+
+	return strategy.Pick(free, assignment).Id, nil
+}
+
+// LoadCustomerTunnelIds lists the tunnels a customer (by phone) has had, the
+// most recently booked first.
+func LoadCustomerTunnelIds(ctx context.Context, conn IDBConn, phone string) ([]int32, error) {
+	tunnelIds := make([]int32, 0)
+	if phone == "" {
+		return tunnelIds, nil
+	}
+
+	query := `
+		SELECT tunnel_id FROM reservations
+		WHERE customer_phone = @customer_phone AND tunnel_id IS NOT NULL
+		GROUP BY tunnel_id
+		ORDER BY MAX(start_time) DESC
+	`
+
+	err := pgxscan.Select(ctx, conn, &tunnelIds, query, pgx.NamedArgs{"customer_phone": phone})
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return tunnelIds, nil
+}
+
+// bookedTime adds up how long tunnelId is booked during the assignment's day.
+func bookedTime(tunnelId int32, assignment TunnelAssignment) time.Duration {
+	var booked time.Duration
+	for _, reservation := range assignment.Reservations {
+		if reservation.TunnelId == nil || *reservation.TunnelId != tunnelId {
+			continue
+		}
+
+		start := latest(reservation.StartTime.Time, assignment.Day.Start)
+		end := earliest(reservation.EndTime.Time, assignment.Day.End)
+		if end.After(start) {
+			booked += end.Sub(start)
+		}
+	}
+
+	return booked
+}
+
+// gapsAround is the free time the slot would leave on tunnelId before and
+// after it, up to the neighbouring bookings or the day's edges.
+func gapsAround(tunnelId int32, assignment TunnelAssignment) (time.Duration, time.Duration) {
+	before, after := assignment.Day.Start, assignment.Day.End
+
+	for _, reservation := range assignment.Reservations {
+		if reservation.TunnelId == nil || *reservation.TunnelId != tunnelId {
+			continue
+		}
+
+		freeAt := tunnelBusyInterval(reservation, 0).End
+		if !freeAt.After(assignment.Slot.Start) && freeAt.After(before) {
+			before = freeAt
+		}
+
+		if !reservation.StartTime.Time.Before(assignment.Slot.End) && reservation.StartTime.Time.Before(after) {
+			after = reservation.StartTime.Time
+		}
+	}
+
+	return assignment.Slot.Start.Sub(before), after.Sub(assignment.Slot.End)
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+
+	return b
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,183 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const lockTunnelsQuery = `SELECT * FROM tunnels WHERE is_active ORDER BY id ASC FOR NO KEY UPDATE`
+
+func testAssignedReservation() models.Reservation {
+	start := time.Date(2025, 9, 5, 18, 0, 0, 0, time.UTC)
+
+	return models.Reservation{
+		Kind:              models.ReservationKindTunnel,
+		CustomerFirstName: "Jane",
+		CustomerLastName:  "Doe",
+		CustomerPhone:     "5551234567",
+		StartTime:         pgtype.Timestamptz{Time: start, Valid: true},
+		Duration:          60,
+		EndTime:           pgtype.Timestamptz{Time: start.Add(time.Hour), Valid: true},
+		Status:            models.ReservationStatusHeld,
+		BookingChannel:    models.BookingChannelWeb,
+	}
+}
+
+func testBooking(tunnelId int32, startHour, endHour int) models.Reservation {
+	return models.Reservation{
+		TunnelId:  &tunnelId,
+		StartTime: pgtype.Timestamptz{Time: time.Date(2025, 9, 5, startHour, 0, 0, 0, time.UTC), Valid: true},
+		EndTime:   pgtype.Timestamptz{Time: time.Date(2025, 9, 5, endHour, 0, 0, 0, time.UTC), Valid: true},
+	}
+}
+
+func Test_TunnelAssignmentStrategies(t *testing.T) {
+	// setup
+	free := []models.Tunnel{{Id: 1}, {Id: 2}, {Id: 3}}
+
+	// the slot is 18:00-19:00, tunnel 1 is busy most of the day, tunnel 3
+	// has a booking right after the slot and tunnel 2 is empty
+	assignment := TunnelAssignment{
+		Slot: Interval{
+			Start: time.Date(2025, 9, 5, 18, 0, 0, 0, time.UTC),
+			End:   time.Date(2025, 9, 5, 19, 0, 0, 0, time.UTC),
+		},
+		Day: Interval{
+			Start: time.Date(2025, 9, 5, 0, 0, 0, 0, time.UTC),
+			End:   time.Date(2025, 9, 6, 0, 0, 0, 0, time.UTC),
+		},
+		Reservations: []models.Reservation{
+			testBooking(1, 10, 17),
+			testBooking(3, 19, 20),
+		},
+		PreviousTunnelIds: []int32{4, 3, 1},
+	}
+
+	cases := []struct {
+		name     string
+		strategy TunnelAssignmentStrategy
+		expected int32
+	}{
+		{"lowest_number", LowestNumberStrategy{}, 1},
+		{"least_utilized", LeastUtilizedStrategy{}, 2},
+		{"repeat_customer", RepeatTunnelStrategy{Fallback: LowestNumberStrategy{}}, 3},
+		{"pack", PackingStrategy{}, 3},
+	}
+
+	for _, tc := range cases {
+		// exercise
+		picked := tc.strategy.Pick(free, assignment)
+
+		// verify
+		if picked.Id != tc.expected {
+			t.Fatal("expected", tc.name, "to pick tunnel", tc.expected, "got", picked.Id)
+		}
+
+		if _, ok := TunnelAssignmentStrategyByName(tc.name); !ok {
+			t.Fatal("expected a strategy registered as", tc.name)
+		}
+	}
+}
+
+func Test_InsertReservationWithAssignedTunnel(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	reservation := testAssignedReservation()
+	var assigned int32 = 2
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta(lockTunnelsQuery)).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "name", "is_active", "turnover_buffer_minutes"}).
+			AddRow(int32(1), "Tunnel 1", true, int32(5)).
+			AddRow(int32(2), "Tunnel 2", true, int32(5)),
+	)
+
+	// tunnel 1 is still turning over when the slot starts
+	taken := testBooking(1, 17, 18)
+	mockConn.ExpectQuery(regexp.QuoteMeta(turnoverReservationsQuery)).WithArgs(anyArgs(2)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "tunnel_id", "start_time", "end_time", "tunnel_turnover_end"}).
+			AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, taken.TunnelId, taken.StartTime, taken.EndTime,
+				pgtype.Timestamptz{Time: taken.EndTime.Time.Add(5 * time.Minute), Valid: true}),
+	)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta("SELECT tunnel_id FROM reservations")).WithArgs(anyArgs(1)...).WillReturnRows(
+		pgxmock.NewRows([]string{"tunnel_id"}),
+	)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO reservations")).WithArgs(anyArgs(23)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "tunnel_id"}).
+			AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, &assigned),
+	)
+
+	mockConn.ExpectCommit()
+	mockConn.ExpectRollback()
+
+	// exercise
+	result, err := InsertReservationWithAssignedTunnel(context.Background(), mockConn, time.UTC, LowestNumberStrategy{}, reservation)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if result.TunnelId == nil || *result.TunnelId != assigned {
+		t.Fatal("expected tunnel 2 to be assigned, got", result.TunnelId)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_InsertReservationWithAssignedTunnel_NoneFree(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	reservation := testAssignedReservation()
+	taken := testBooking(1, 18, 19)
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta(lockTunnelsQuery)).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "name", "is_active", "turnover_buffer_minutes"}).
+			AddRow(int32(1), "Tunnel 1", true, int32(5)),
+	)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta(turnoverReservationsQuery)).WithArgs(anyArgs(2)...).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "tunnel_id", "start_time", "end_time"}).
+			AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, taken.TunnelId, taken.StartTime, taken.EndTime),
+	)
+
+	mockConn.ExpectQuery(regexp.QuoteMeta("SELECT tunnel_id FROM reservations")).WithArgs(anyArgs(1)...).WillReturnRows(
+		pgxmock.NewRows([]string{"tunnel_id"}).AddRow(int32(1)),
+	)
+
+	mockConn.ExpectRollback()
+
+	// exercise
+	result, err := InsertReservationWithAssignedTunnel(context.Background(), mockConn, time.UTC, RepeatTunnelStrategy{Fallback: LowestNumberStrategy{}}, reservation)
+
+	// verify
+	var noTunnelErr *NoTunnelAvailableError
+	if !errors.As(err, &noTunnelErr) {
+		t.Fatal("expected no tunnel to be available, got", err)
+	}
+
+	if result != nil {
+		t.Fatal("expected nothing to be booked, got", result)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
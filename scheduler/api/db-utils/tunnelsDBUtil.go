@@ -2,12 +2,16 @@ package db_utils
 
 import (
 	"context"
+	"fmt"
 	"log"
 
 	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
 	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
 )
 
+const MaxTurnoverBufferMinutes int32 = 120
+
 func LoadTunnelData(ctx context.Context, conn IDBConn) ([]models.Tunnel, error) {
 	var tunnels []models.Tunnel
 
@@ -25,3 +29,72 @@ func LoadTunnelData(ctx context.Context, conn IDBConn) ([]models.Tunnel, error)
 
 	return tunnels, nil
 }
+
+func LoadTunnelById(ctx context.Context, conn IDBConn, id int32) (*models.Tunnel, error) {
+	var tunnel models.Tunnel
+
+	err := pgxscan.Get(ctx, conn, &tunnel, `SELECT * FROM tunnels WHERE id=$1`, id)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find tunnel with id:", id)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &tunnel, nil
+}
+
+func UpdateTunnelData(ctx context.Context, conn IDBConn, id string, updates models.TunnelUpdates) (*models.Tunnel, error) {
+	if updates.TurnoverBufferMinutes != nil {
+		validationErr := &ValidationError{}
+		validateTurnoverBuffer(validationErr, *updates.TurnoverBufferMinutes)
+
+		if err := validationErr.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var updatedTunnel models.Tunnel
+
+	args := pgx.NamedArgs{
+		"id":                      id,
+		"name":                    updates.Name,
+		"is_active":               updates.IsActive,
+		"turnover_buffer_minutes": updates.TurnoverBufferMinutes,
+	}
+
+	query := `
+		UPDATE tunnels
+		SET
+			name = COALESCE(@name, name),
+			is_active = COALESCE(@is_active, is_active),
+			turnover_buffer_minutes = COALESCE(@turnover_buffer_minutes, turnover_buffer_minutes)
+		WHERE id = @id
+		RETURNING *
+	`
+
+	err := pgxscan.Get(ctx, conn, &updatedTunnel, query, args)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find tunnel with id:", id)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating tunnel:", err)
+		return nil, err
+	}
+
+	return &updatedTunnel, nil
+}
+
+// validateTurnoverBuffer mirrors the CHECK on the turnover_buffer_minutes columns.
+func validateTurnoverBuffer(validationErr *ValidationError, minutes int32) {
+	if minutes < 0 || minutes > MaxTurnoverBufferMinutes {
+		validationErr.Add("turnover_buffer_minutes", fmt.Sprintf("must be between 0 and %d", MaxTurnoverBufferMinutes))
+	}
+}
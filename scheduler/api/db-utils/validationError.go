@@ -0,0 +1,42 @@
+package db_utils
+
+import (
+	"sort"
+	"strings"
+)
+
+// ValidationError collects every bad field of a request so the caller can
+// answer with a 400 before the database CHECK constraints ever run.
+type ValidationError struct {
+	Fields map[string]string `json:"fields"`
+}
+
+func (e *ValidationError) Add(field, message string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+	}
+
+	// keep the first problem reported for a field
+	if _, exists := e.Fields[field]; !exists {
+		e.Fields[field] = message
+	}
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field, message := range e.Fields {
+		fields = append(fields, field+": "+message)
+	}
+	sort.Strings(fields)
+
+	return "validation failed: " + strings.Join(fields, ", ")
+}
+
+// Err returns nil when nothing was added, so it can be returned directly.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+
+	return e
+}
@@ -0,0 +1,379 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// LoadWaitlistData lists waitlist entries oldest first, optionally only those
+// in one status and/or whose window overlaps [from, to).
+func LoadWaitlistData(ctx context.Context, conn IDBConn, status models.WaitlistStatus, from, to pgtype.Timestamptz) ([]models.WaitlistEntry, error) {
+	entries := make([]models.WaitlistEntry, 0)
+
+	args := pgx.NamedArgs{
+		"status":    string(status),
+		"from_time": from,
+		"to_time":   to,
+	}
+
+	query := `
+		SELECT * FROM waitlist_entries
+		WHERE (@status = '' OR status = @status::waitlist_status)
+			AND (@from_time::timestamptz IS NULL OR window_end > @from_time)
+			AND (@to_time::timestamptz IS NULL OR window_start < @to_time)
+		ORDER BY created_at ASC
+	`
+
+	if err := pgxscan.Select(ctx, conn, &entries, query, args); err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func LoadWaitlistEntryById(ctx context.Context, conn IDBConn, id string) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+
+	err := pgxscan.Get(ctx, conn, &entry, `SELECT * FROM waitlist_entries WHERE id=$1`, id)
+
+	if pgxscan.NotFound(err) {
+		log.Println("[API] Could not find waitlist entry with id:", id)
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Println("[API] Error querying database:", err)
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func InsertWaitlistEntryData(ctx context.Context, conn IDBConn, entry models.WaitlistEntry) (*models.WaitlistEntry, error) {
+	if err := validateWaitlistEntry(entry); err != nil {
+		return nil, err
+	}
+
+	args := pgx.NamedArgs{
+		"reservation_kind":    entry.Kind,
+		"tunnel_id":           entry.TunnelId,
+		"coach_id":            entry.CoachId,
+		"lesson_type_id":      entry.LessonTypeId,
+		"customer_first_name": entry.CustomerFirstName,
+		"customer_last_name":  entry.CustomerLastName,
+		"customer_phone":      entry.CustomerPhone,
+		"customer_email":      entry.CustomerEmail,
+		"window_start":        entry.WindowStart,
+		"window_end":          entry.WindowEnd,
+		"duration_minutes":    entry.Duration,
+		"notes":               entry.Notes,
+	}
+
+	const query = `
+		INSERT INTO waitlist_entries (
+			reservation_kind,
+			tunnel_id,
+			coach_id,
+			lesson_type_id,
+			customer_first_name,
+			customer_last_name,
+			customer_phone,
+			customer_email,
+			window_start,
+			window_end,
+			duration_minutes,
+			notes
+		)
+
+		VALUES (
+			@reservation_kind,
+			@tunnel_id,
+			@coach_id,
+			@lesson_type_id,
+			@customer_first_name,
+			@customer_last_name,
+			@customer_phone,
+			@customer_email,
+			@window_start,
+			@window_end,
+			@duration_minutes,
+			@notes
+		)
+
+		RETURNING *;
+	`
+
+	var out models.WaitlistEntry
+	if err := pgxscan.Get(ctx, conn, &out, query, args); err != nil {
+		log.Println("[API] Error inserting waitlist entry:", err)
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// LeaveWaitlist takes a waiting or offered entry off the waitlist. An offered
+// hold is left to expire on its own so the slot is passed on.
+func LeaveWaitlist(ctx context.Context, conn IDBConn, id string) (int64, error) {
+	cmdTag, err := conn.Exec(
+		ctx,
+		`UPDATE waitlist_entries SET status = 'left', updated_at = now() WHERE id = $1 AND status IN ('waiting', 'offered')`,
+		id,
+	)
+
+	if err != nil {
+		log.Println("[API] Error leaving waitlist:", err)
+		return 0, err
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
+
+const waitlistCandidatesQuery = `
+		SELECT * FROM waitlist_entries
+		WHERE status = 'waiting'
+			AND window_start < @slot_end
+			AND window_end > @slot_start
+			AND (tunnel_id IS NULL OR tunnel_id = @tunnel_id)
+			AND (reservation_kind = 'tunnel' OR coach_id = @coach_id)
+		ORDER BY created_at ASC
+		FOR UPDATE SKIP LOCKED
+	`
+
+// OfferFreedSlot gives the slot a cancelled reservation left behind to the
+// longest waiting customer it fits, as a hold that lapses at expiresAt. Any
+// offer the freed reservation itself came from is marked lapsed first. It
+// returns nil when nobody fits, a candidate whose hold would overlap another
+// booking or break the schedule checks is skipped for the next one.
+func OfferFreedSlot(ctx context.Context, conn IDBTxConn, loc *time.Location, freed models.Reservation, expiresAt pgtype.Timestamptz) (*models.WaitlistOffer, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Println("[API] Error starting transaction:", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(
+		ctx,
+		`UPDATE waitlist_entries SET status = 'lapsed', updated_at = now() WHERE offered_reservation_id = $1 AND status = 'offered'`,
+		freed.Id,
+	)
+	if err != nil {
+		log.Println("[API] Error lapsing waitlist offer:", err)
+		return nil, err
+	}
+
+	offer, err := offerToNextCandidate(ctx, tx, loc, freed, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("[API] Error committing waitlist offer:", err)
+		return nil, err
+	}
+
+	return offer, nil
+}
+
+func offerToNextCandidate(ctx context.Context, tx pgx.Tx, loc *time.Location, freed models.Reservation, expiresAt pgtype.Timestamptz) (*models.WaitlistOffer, error) {
+	// only future tunnel time can be handed on
+	if freed.TunnelId == nil || !freed.EndTime.Time.After(time.Now()) {
+		return nil, nil
+	}
+
+	slot := reservationInterval(freed)
+	if now := time.Now(); slot.Start.Before(now) {
+		slot.Start = now
+	}
+
+	candidates := make([]models.WaitlistEntry, 0)
+
+	args := pgx.NamedArgs{
+		"slot_start": pgtype.Timestamptz{Time: slot.Start, Valid: true},
+		"slot_end":   freed.EndTime,
+		"tunnel_id":  freed.TunnelId,
+		"coach_id":   freed.CoachId,
+	}
+
+	if err := pgxscan.Select(ctx, tx, &candidates, waitlistCandidatesQuery, args); err != nil {
+		log.Println("[API] Error loading waitlist candidates:", err)
+		return nil, err
+	}
+
+	for _, entry := range candidates {
+		start := slot.Start
+		if entry.WindowStart.Time.After(start) {
+			start = entry.WindowStart.Time
+		}
+
+		end := start.Add(time.Duration(entry.Duration) * time.Minute)
+		if end.After(slot.End) || end.After(entry.WindowEnd.Time) {
+			continue
+		}
+
+		reservation := models.Reservation{
+			Kind:              entry.Kind,
+			TunnelId:          freed.TunnelId,
+			CustomerFirstName: entry.CustomerFirstName,
+			CustomerLastName:  entry.CustomerLastName,
+			CustomerPhone:     entry.CustomerPhone,
+			CustomerEmail:     entry.CustomerEmail,
+			StartTime:         pgtype.Timestamptz{Time: start, Valid: true},
+			Duration:          entry.Duration,
+			EndTime:           pgtype.Timestamptz{Time: end, Valid: true},
+			Status:            models.ReservationStatusHeld,
+			Notes:             entry.Notes,
+			BookingChannel:    models.BookingChannelWeb,
+			HoldExpiresAt:     expiresAt,
+		}
+
+		if entry.Kind == models.ReservationKindLesson {
+			reservation.CoachId = entry.CoachId
+			reservation.LessonTypeId = entry.LessonTypeId
+
+			// the coach may have stopped teaching the lesson type since they joined
+			_, err := CheckLessonAssignment(ctx, tx, entry.Kind, entry.LessonTypeId, entry.CoachId)
+
+			var validationErr *ValidationError
+			var violations *RuleViolationError
+			if errors.As(err, &validationErr) || errors.As(err, &violations) {
+				log.Println("[API] Waitlist entry no longer books a valid lesson:", entry.Id.String(), err)
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// the hold is linked to the customer with the same phone number or email
+		if err := ResolveReservationCustomer(ctx, tx, &reservation); err != nil {
+			return nil, err
+		}
+
+		// the freed booking may have been an override, or a blackout or the
+		// coach's time off may have been added since it was made
+		err := EnforceReservationSchedule(ctx, tx, loc, ScheduleCheck{
+			StartTime:    reservation.StartTime,
+			EndTime:      reservation.EndTime,
+			Kind:         reservation.Kind,
+			CoachId:      reservation.CoachId,
+			BookingRules: true,
+			CustomerTier: reservation.CustomerTier,
+			Now:          time.Now(),
+		})
+
+		var validationErr *ValidationError
+		var violations *RuleViolationError
+		if errors.As(err, &validationErr) || errors.As(err, &violations) {
+			log.Println("[API] Freed slot breaks the schedule for waitlist entry:", entry.Id.String(), err)
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		// a savepoint keeps the transaction usable if the hold overlaps something,
+		// that is often this candidate's own (their coach is busy, their longer
+		// booking runs into the next one's turnover) so the next one gets a go
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		held, err := InsertReservationData(ctx, savepoint, reservation)
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23P01" {
+			_ = savepoint.Rollback(ctx)
+			log.Println("[API] Freed slot could not be offered to waitlist entry:", entry.Id.String(), pgErr.ConstraintName)
+			continue
+		}
+
+		if err != nil {
+			log.Println("[API] Error creating waitlist hold:", err)
+			return nil, err
+		}
+
+		if err := savepoint.Commit(ctx); err != nil {
+			return nil, err
+		}
+
+		var offered models.WaitlistEntry
+		err = pgxscan.Get(
+			ctx,
+			tx,
+			&offered,
+			`UPDATE waitlist_entries SET status = 'offered', offered_reservation_id = $2, updated_at = now() WHERE id = $1 RETURNING *`,
+			entry.Id,
+			held.Id,
+		)
+		if err != nil {
+			log.Println("[API] Error marking waitlist entry offered:", err)
+			return nil, err
+		}
+
+		return &models.WaitlistOffer{Entry: offered, Reservation: *held}, nil
+	}
+
+	return nil, nil
+}
+
+func validateWaitlistEntry(entry models.WaitlistEntry) error {
+	validationErr := &ValidationError{}
+
+	if entry.Kind != models.ReservationKindTunnel && entry.Kind != models.ReservationKindLesson {
+		validationErr.Add("reservation_kind", "must be tunnel or lesson")
+	}
+
+	if entry.Kind == models.ReservationKindLesson && entry.CoachId == nil {
+		validationErr.Add("coach_id", "is required for lessons")
+	}
+
+	if entry.Kind == models.ReservationKindLesson && entry.LessonTypeId == nil {
+		validationErr.Add("lesson_type_id", "is required for lessons")
+	}
+
+	if entry.Kind == models.ReservationKindTunnel && entry.CoachId != nil {
+		validationErr.Add("coach_id", "must be empty for tunnel rentals")
+	}
+
+	if entry.Kind == models.ReservationKindTunnel && entry.LessonTypeId != nil {
+		validationErr.Add("lesson_type_id", "only lessons have a lesson type")
+	}
+
+	if entry.Duration <= 0 {
+		validationErr.Add("duration_minutes", "must be greater than 0")
+	}
+
+	if !entry.WindowStart.Valid {
+		validationErr.Add("window_start", "is required")
+	}
+
+	if !entry.WindowEnd.Valid {
+		validationErr.Add("window_end", "is required")
+	}
+
+	if entry.WindowStart.Valid && entry.WindowEnd.Valid {
+		window := entry.WindowEnd.Time.Sub(entry.WindowStart.Time)
+
+		if window <= 0 {
+			validationErr.Add("window_end", "must be after window_start")
+		} else if entry.Duration > 0 && time.Duration(entry.Duration)*time.Minute > window {
+			validationErr.Add("duration_minutes", "must fit inside the window")
+		}
+	}
+
+	return validationErr.Err()
+}
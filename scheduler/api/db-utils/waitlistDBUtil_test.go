@@ -0,0 +1,415 @@
+package db_utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+const lapseWaitlistOfferQuery = `UPDATE waitlist_entries SET status = 'lapsed', updated_at = now() WHERE offered_reservation_id = $1 AND status = 'offered'`
+
+// freedReservation is a cancelled tunnel rental tomorrow from 10:00 to 11:00.
+func freedReservation() models.Reservation {
+	var tunnelId int32 = 3
+	start := time.Now().UTC().AddDate(0, 0, 1).Truncate(24 * time.Hour).Add(10 * time.Hour)
+
+	return models.Reservation{
+		Id:        pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true},
+		Kind:      models.ReservationKindTunnel,
+		TunnelId:  &tunnelId,
+		StartTime: pgtype.Timestamptz{Time: start, Valid: true},
+		EndTime:   pgtype.Timestamptz{Time: start.Add(time.Hour), Valid: true},
+		Status:    models.ReservationStatusCancelled,
+	}
+}
+
+// expectOpenSchedule is the schedule check of a waitlist hold: open from
+// 08:00 to 22:00, blackouts as given and no booking rule.
+func expectOpenSchedule(mockConn pgxmock.PgxConnIface, blackouts *pgxmock.Rows) {
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM special_hours WHERE on_date=$1`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnError(pgx.ErrNoRows)
+	mockConn.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM business_hours WHERE dow=$1`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"dow", "open_time", "close_time", "is_open"}).
+				AddRow(int32(1), models.NewTimeOfDay(8, 0), models.NewTimeOfDay(22, 0), true),
+		)
+	mockConn.ExpectQuery(regexp.QuoteMeta(overlappingBlackoutWindowsQuery)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(blackouts)
+	mockConn.ExpectQuery(regexp.QuoteMeta("SELECT * FROM booking_rules")).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(pgx.ErrNoRows)
+}
+
+func candidateArgs(freed models.Reservation) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"slot_start": freed.StartTime,
+		"slot_end":   freed.EndTime,
+		"tunnel_id":  freed.TunnelId,
+		"coach_id":   freed.CoachId,
+	}
+}
+
+// holdArgs is the held reservation offered to the waitlistRows customer.
+func holdArgs(freed models.Reservation, expiresAt pgtype.Timestamptz) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"reservation_kind":    models.ReservationKindTunnel,
+		"tunnel_id":           freed.TunnelId,
+		"coach_id":            (*pgtype.UUID)(nil),
+		"lesson_type_id":      (*pgtype.UUID)(nil),
+		"customer_id":         (*pgtype.UUID)(nil),
+		"athlete_id":          (*pgtype.UUID)(nil),
+		"customer_first_name": "Jane",
+		"customer_last_name":  "",
+		"customer_phone":      "",
+		"customer_email":      (*string)(nil),
+		"customer_tier":       (*string)(nil),
+		"start_time":          freed.StartTime,
+		"duration_minutes":    int32(60),
+		"end_time":            freed.EndTime,
+		"status":              models.ReservationStatusHeld,
+		"notes":               (*string)(nil),
+		"override_reason":     (*string)(nil),
+		"override_by":         (*string)(nil),
+		"booking_channel":     models.BookingChannelWeb,
+		"hold_expires_at":     expiresAt,
+		"series_id":           (*pgtype.UUID)(nil),
+		"group_id":            (*pgtype.UUID)(nil),
+		"session_id":          (*pgtype.UUID)(nil),
+	}
+}
+
+func waitlistRows(freed models.Reservation, duration int32) *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"id", "reservation_kind", "customer_first_name", "window_start", "window_end", "duration_minutes", "status"}).
+		AddRow(
+			pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true},
+			models.ReservationKindTunnel,
+			"Jane",
+			pgtype.Timestamptz{Time: freed.StartTime.Time.Add(-2 * time.Hour), Valid: true},
+			pgtype.Timestamptz{Time: freed.EndTime.Time.Add(2 * time.Hour), Valid: true},
+			duration,
+			models.WaitlistStatusWaiting,
+		)
+}
+
+func Test_InsertWaitlistEntryData_Invalid(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	start := time.Date(2025, 6, 7, 14, 0, 0, 0, time.UTC)
+	entry := models.WaitlistEntry{
+		Kind:        models.ReservationKindLesson,
+		WindowStart: pgtype.Timestamptz{Time: start, Valid: true},
+		WindowEnd:   pgtype.Timestamptz{Time: start.Add(30 * time.Minute), Valid: true},
+		Duration:    60,
+	}
+
+	// exercise
+	_, err := InsertWaitlistEntryData(context.Background(), mockConn, entry)
+
+	// verify
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatal("expected validation error, got", err)
+	}
+
+	for _, field := range []string{"coach_id", "lesson_type_id", "duration_minutes"} {
+		if _, ok := validationErr.Fields[field]; !ok {
+			t.Fatal("expected a field error for", field, "got", validationErr.Fields)
+		}
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_OfferFreedSlot(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	freed := freedReservation()
+	expiresAt := pgtype.Timestamptz{Time: time.Now().Add(30 * time.Minute), Valid: true}
+	heldId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	customerId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	linkedHoldArgs := holdArgs(freed, expiresAt)
+	linkedHoldArgs["customer_id"] = &customerId
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectExec(regexp.QuoteMeta(lapseWaitlistOfferQuery)).WithArgs(freed.Id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mockConn.ExpectQuery(regexp.QuoteMeta(waitlistCandidatesQuery)).WithArgs(candidateArgs(freed)).
+		WillReturnRows(waitlistRows(freed, 60))
+	mockConn.ExpectQuery(regexp.QuoteMeta(matchCustomerQuery)).WithArgs("", (*string)(nil)).WillReturnRows(
+		customerRows().AddRow(customerId, "Jane", "Doe", "(812) 555-0100", nil, "8125550100"),
+	)
+	expectOpenSchedule(mockConn, pgxmock.NewRows([]string{"id"}))
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO reservations")).WithArgs(linkedHoldArgs).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "status", "start_time"}).AddRow(heldId, models.ReservationStatusHeld, freed.StartTime),
+	)
+	mockConn.ExpectCommit()
+	mockConn.ExpectQuery(regexp.QuoteMeta("UPDATE waitlist_entries SET status = 'offered'")).WithArgs(pgxmock.AnyArg(), heldId).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "status", "offered_reservation_id"}).
+			AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, models.WaitlistStatusOffered, &heldId),
+	)
+	mockConn.ExpectCommit()
+	mockConn.ExpectRollback()
+
+	// exercise
+	offer, err := OfferFreedSlot(context.Background(), mockConn, time.UTC, freed, expiresAt)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if offer == nil {
+		t.Fatal("expected an offer, got none")
+	}
+
+	if offer.Entry.Status != models.WaitlistStatusOffered || offer.Reservation.Id != heldId {
+		t.Fatal("expected the entry to be offered the hold", heldId.String(), "got", offer)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_OfferFreedSlot_TooLong(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	freed := freedReservation()
+	expiresAt := pgtype.Timestamptz{Time: time.Now().Add(30 * time.Minute), Valid: true}
+
+	// the only waiting customer wants 90 minutes, the freed slot is 60
+	mockConn.ExpectBegin()
+	mockConn.ExpectExec(regexp.QuoteMeta(lapseWaitlistOfferQuery)).WithArgs(freed.Id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mockConn.ExpectQuery(regexp.QuoteMeta(waitlistCandidatesQuery)).WithArgs(candidateArgs(freed)).
+		WillReturnRows(waitlistRows(freed, 90))
+	mockConn.ExpectCommit()
+	mockConn.ExpectRollback()
+
+	// exercise
+	offer, err := OfferFreedSlot(context.Background(), mockConn, time.UTC, freed, expiresAt)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if offer != nil {
+		t.Fatal("expected no offer, got", offer)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_OfferFreedSlot_Rebooked(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	freed := freedReservation()
+	expiresAt := pgtype.Timestamptz{Time: time.Now().Add(30 * time.Minute), Valid: true}
+
+	mockConn.ExpectBegin()
+	mockConn.ExpectExec(regexp.QuoteMeta(lapseWaitlistOfferQuery)).WithArgs(freed.Id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockConn.ExpectQuery(regexp.QuoteMeta(waitlistCandidatesQuery)).WithArgs(candidateArgs(freed)).
+		WillReturnRows(waitlistRows(freed, 60))
+	mockConn.ExpectQuery(regexp.QuoteMeta(matchCustomerQuery)).WithArgs("", (*string)(nil)).WillReturnRows(customerRows())
+	expectOpenSchedule(mockConn, pgxmock.NewRows([]string{"id"}))
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO reservations")).WithArgs(holdArgs(freed, expiresAt)).
+		WillReturnError(&pgconn.PgError{Code: "23P01"})
+	mockConn.ExpectRollback()
+	mockConn.ExpectCommit()
+	mockConn.ExpectRollback()
+
+	// exercise
+	offer, err := OfferFreedSlot(context.Background(), mockConn, time.UTC, freed, expiresAt)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if offer != nil {
+		t.Fatal("expected no offer, got", offer)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_OfferFreedSlot_NextCandidate(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	freed := freedReservation()
+	expiresAt := pgtype.Timestamptz{Time: time.Now().Add(30 * time.Minute), Valid: true}
+	heldId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	nextId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	candidates := waitlistRows(freed, 60).AddRow(
+		nextId,
+		models.ReservationKindTunnel,
+		"Bob",
+		pgtype.Timestamptz{Time: freed.StartTime.Time.Add(-time.Hour), Valid: true},
+		pgtype.Timestamptz{Time: freed.EndTime.Time.Add(time.Hour), Valid: true},
+		int32(60),
+		models.WaitlistStatusWaiting,
+	)
+
+	nextHoldArgs := holdArgs(freed, expiresAt)
+	nextHoldArgs["customer_first_name"] = "Bob"
+
+	// Jane's hold overlaps another booking of hers, Bob gets the slot instead
+	mockConn.ExpectBegin()
+	mockConn.ExpectExec(regexp.QuoteMeta(lapseWaitlistOfferQuery)).WithArgs(freed.Id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mockConn.ExpectQuery(regexp.QuoteMeta(waitlistCandidatesQuery)).WithArgs(candidateArgs(freed)).
+		WillReturnRows(candidates)
+	mockConn.ExpectQuery(regexp.QuoteMeta(matchCustomerQuery)).WithArgs("", (*string)(nil)).WillReturnRows(customerRows())
+	expectOpenSchedule(mockConn, pgxmock.NewRows([]string{"id"}))
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO reservations")).WithArgs(holdArgs(freed, expiresAt)).
+		WillReturnError(&pgconn.PgError{Code: "23P01"})
+	mockConn.ExpectRollback()
+	mockConn.ExpectQuery(regexp.QuoteMeta(matchCustomerQuery)).WithArgs("", (*string)(nil)).WillReturnRows(customerRows())
+	expectOpenSchedule(mockConn, pgxmock.NewRows([]string{"id"}))
+	mockConn.ExpectBegin()
+	mockConn.ExpectQuery(regexp.QuoteMeta("INSERT INTO reservations")).WithArgs(nextHoldArgs).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "status", "start_time"}).AddRow(heldId, models.ReservationStatusHeld, freed.StartTime),
+	)
+	mockConn.ExpectCommit()
+	mockConn.ExpectQuery(regexp.QuoteMeta("UPDATE waitlist_entries SET status = 'offered'")).WithArgs(nextId, heldId).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "status", "offered_reservation_id"}).
+			AddRow(nextId, models.WaitlistStatusOffered, &heldId),
+	)
+	mockConn.ExpectCommit()
+	mockConn.ExpectRollback()
+
+	// exercise
+	offer, err := OfferFreedSlot(context.Background(), mockConn, time.UTC, freed, expiresAt)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if offer == nil || offer.Entry.Id != nextId || offer.Reservation.Id != heldId {
+		t.Fatal("expected the next entry", nextId.String(), "to be offered the hold, got", offer)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_OfferFreedSlot_LessonWithoutType(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	coachId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	freed := freedReservation()
+	freed.Kind, freed.CoachId = models.ReservationKindLesson, &coachId
+	expiresAt := pgtype.Timestamptz{Time: time.Now().Add(30 * time.Minute), Valid: true}
+
+	// joined before lesson types, so their lesson can't be booked
+	mockConn.ExpectBegin()
+	mockConn.ExpectExec(regexp.QuoteMeta(lapseWaitlistOfferQuery)).WithArgs(freed.Id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mockConn.ExpectQuery(regexp.QuoteMeta(waitlistCandidatesQuery)).WithArgs(candidateArgs(freed)).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "reservation_kind", "coach_id", "customer_first_name", "window_start", "window_end", "duration_minutes", "status"}).
+			AddRow(
+				pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true},
+				models.ReservationKindLesson,
+				&coachId,
+				"Jane",
+				freed.StartTime,
+				freed.EndTime,
+				int32(60),
+				models.WaitlistStatusWaiting,
+			),
+	)
+	mockConn.ExpectCommit()
+	mockConn.ExpectRollback()
+
+	// exercise
+	offer, err := OfferFreedSlot(context.Background(), mockConn, time.UTC, freed, expiresAt)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if offer != nil {
+		t.Fatal("expected no offer, got", offer)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_OfferFreedSlot_Blackout(t *testing.T) {
+	// setup
+	mockConn, _ := pgxmock.NewConn()
+	defer mockConn.Close(context.Background())
+
+	freed := freedReservation()
+	expiresAt := pgtype.Timestamptz{Time: time.Now().Add(30 * time.Minute), Valid: true}
+
+	// a blackout was added over the slot after it was booked
+	mockConn.ExpectBegin()
+	mockConn.ExpectExec(regexp.QuoteMeta(lapseWaitlistOfferQuery)).WithArgs(freed.Id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mockConn.ExpectQuery(regexp.QuoteMeta(waitlistCandidatesQuery)).WithArgs(candidateArgs(freed)).
+		WillReturnRows(waitlistRows(freed, 60))
+	mockConn.ExpectQuery(regexp.QuoteMeta(matchCustomerQuery)).WithArgs("", (*string)(nil)).WillReturnRows(customerRows())
+	expectOpenSchedule(mockConn, pgxmock.NewRows([]string{"id", "starts_at", "ends_at", "reason"}).
+		AddRow(pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}, freed.StartTime, freed.EndTime, "Turf replacement"))
+	mockConn.ExpectCommit()
+	mockConn.ExpectRollback()
+
+	// exercise
+	offer, err := OfferFreedSlot(context.Background(), mockConn, time.UTC, freed, expiresAt)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if offer != nil {
+		t.Fatal("expected no offer, got", offer)
+	}
+
+	if err = mockConn.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+)
+
+// sweepExpiredHolds cancels lapsed holds every interval until ctx is done,
+// freeing the tunnels and coaches they were blocking and offering them to
+// the waitlist.
+func sweepExpiredHolds(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expireHolds(ctx)
+		}
+	}
+}
+
+func expireHolds(ctx context.Context) {
+	expired, err := dbUtils.ExpireStaleHolds(ctx, pool)
+	if err != nil {
+		log.Println("[API] Error sweeping expired holds:", err)
+		return
+	}
+
+	for _, reservation := range expired {
+		log.Println("[API] Hold expired, cancelled reservation:", reservation.Id.String(),
+			"held until:", reservation.HoldExpiresAt.Time.Format(time.RFC3339))
+	}
+
+	offerFreedSlots(ctx, expired)
+}
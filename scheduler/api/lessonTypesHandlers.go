@@ -0,0 +1,133 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// getLessonTypes lists the lesson catalog, ?active=true leaves out the types
+// that can no longer be booked.
+func getLessonTypes(c *gin.Context) {
+	activeOnly := false
+	if activeStr := c.Query("active"); activeStr != "" {
+		parsed, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			validationErr := &dbUtils.ValidationError{}
+			validationErr.Add("active", "must be true or false")
+			respondValidationError(c, validationErr)
+			return
+		}
+
+		activeOnly = parsed
+	}
+
+	lessonTypes, err := dbUtils.LoadLessonTypeData(c.Request.Context(), pool, activeOnly)
+	if err != nil {
+		log.Println("[API] Error loading lesson types:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, lessonTypes)
+}
+
+func getLessonTypeById(c *gin.Context) {
+	id := c.Param("id")
+
+	lessonType, err := dbUtils.LoadLessonTypeById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading lesson type:", err)
+		respondError(c, err)
+		return
+	}
+
+	if lessonType == nil {
+		log.Println("[API] Could not find lesson type with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *lessonType)
+}
+
+func createLessonType(c *gin.Context) {
+	var input models.LessonTypeUpdates
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/lesson-types.", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	result, err := dbUtils.InsertLessonTypeData(c.Request.Context(), pool, input)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error inserting lesson type:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/lesson-types/"+result.Id.String())
+	c.JSON(http.StatusCreated, *result)
+}
+
+func updateLessonTypeById(c *gin.Context) {
+	id := c.Param("id")
+
+	var updates models.LessonTypeUpdates
+
+	if err := c.ShouldBindJSON(&updates); err != nil {
+		log.Println("[API] Error binding JSON on PUT method at /api/lesson-types/"+id, err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	result, err := dbUtils.UpdateLessonTypeData(c.Request.Context(), pool, id, updates)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating lesson type:", err)
+		respondError(c, err)
+		return
+	}
+
+	if result == nil {
+		log.Println("[API] Cannot update lesson type because it does not exist with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *result)
+}
+
+func deleteLessonTypeById(c *gin.Context) {
+	id := c.Param("id")
+
+	rowsAffected, err := dbUtils.DeleteLessonTypeData(c.Request.Context(), pool, id)
+
+	if err != nil {
+		log.Println("[API] Error deleting lesson type:", err)
+		respondError(c, err)
+		return
+	}
+
+	// didn't delete anything
+	if rowsAffected < 1 {
+		log.Println("[API] Could not find lesson type to delete with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	log.Println("[API] Successfully deleted lesson type with id:", id)
+	c.Status(http.StatusNoContent)
+}
@@ -7,10 +7,12 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/joho/godotenv"
 	"github.com/pressly/goose/v3"
@@ -18,7 +20,15 @@ import (
 	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
 )
 
-var conn *pgx.Conn
+var pool dbUtils.IDBPool
+
+// facilityLocation is the timezone business hours and special hours are written in.
+var facilityLocation = time.UTC
+
+var holdConfig = dbUtils.DefaultHoldConfig()
+
+// tunnelAssignment picks the tunnel for reservations that don't name one.
+var tunnelAssignment dbUtils.TunnelAssignmentStrategy = dbUtils.LowestNumberStrategy{}
 
 func main() {
 	_ = godotenv.Load()
@@ -46,6 +56,18 @@ func main() {
 		log.Fatalln("[API] Error finding 'POSTGRES_PASSWORD' in env file.")
 	}
 
+	facilityTimezone := os.Getenv("FACILITY_TIMEZONE")
+	if facilityTimezone == "" {
+		facilityTimezone = "America/Indiana/Indianapolis"
+	}
+
+	location, err := time.LoadLocation(facilityTimezone)
+	if err != nil {
+		log.Fatalln("[API] Error loading 'FACILITY_TIMEZONE':", err)
+	}
+
+	facilityLocation = location
+
 	// generate dbUrl
 	dbUrl := fmt.Sprintf(
 		"postgres://%s:%s@db:5432/the-diamond-scheduler?sslmode=disable",
@@ -72,23 +94,55 @@ func main() {
 
 	log.Println("[API] Goose finished running migrations successfully.")
 
+	// pool settings are optional, anything missing falls back to the defaults
+	poolConfig := dbUtils.DefaultPoolConfig()
+	poolConfig.MaxConns = getEnvInt32("DB_MAX_CONNS", poolConfig.MaxConns)
+	poolConfig.MinConns = getEnvInt32("DB_MIN_CONNS", poolConfig.MinConns)
+	poolConfig.AcquireTimeout = getEnvDuration("DB_ACQUIRE_TIMEOUT", poolConfig.AcquireTimeout)
+	poolConfig.HealthCheckPeriod = getEnvDuration("DB_HEALTH_CHECK_PERIOD", poolConfig.HealthCheckPeriod)
+	poolConfig.MaxConnIdleTime = getEnvDuration("DB_MAX_CONN_IDLE_TIME", poolConfig.MaxConnIdleTime)
+	poolConfig.MaxConnLifetime = getEnvDuration("DB_MAX_CONN_LIFETIME", poolConfig.MaxConnLifetime)
+
 	// connect to database with a 5 second timeout window before it fails + cancels
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	conn, err = pgx.Connect(ctx, dbUrl)
+	dbPool, err := dbUtils.NewDBPool(ctx, dbUrl, poolConfig)
 	if err != nil {
 		log.Println("[API] Error connecting to the database.", err)
 		return
 	}
-	defer conn.Close(context.Background())
+	defer dbPool.Close()
+
+	pool = dbPool
 
 	// ping the database
-	if err = conn.Ping(ctx); err != nil {
+	if err = pool.Ping(ctx); err != nil {
 		log.Println("[API] Error when pinging the database", err)
 		return
 	}
 
+	log.Printf("[API] Database pool ready (max conns: %d, min conns: %d).\n", poolConfig.MaxConns, poolConfig.MinConns)
+
+	holdConfig.WebTTL = getEnvDuration("HOLD_TTL_WEB", holdConfig.WebTTL)
+	holdConfig.PhoneTTL = getEnvDuration("HOLD_TTL_PHONE", holdConfig.PhoneTTL)
+	holdConfig.OfferTTL = getEnvDuration("HOLD_TTL_WAITLIST_OFFER", holdConfig.OfferTTL)
+	holdConfig.SweepInterval = getEnvDuration("HOLD_SWEEP_INTERVAL", holdConfig.SweepInterval)
+
+	if strategyName := os.Getenv("TUNNEL_ASSIGNMENT_STRATEGY"); strategyName != "" {
+		strategy, ok := dbUtils.TunnelAssignmentStrategyByName(strategyName)
+		if !ok {
+			log.Fatalf("[API] Unknown 'TUNNEL_ASSIGNMENT_STRATEGY' in env file: %s\n", strategyName)
+		}
+
+		tunnelAssignment = strategy
+	}
+
+	sweeperCtx, stopSweeper := context.WithCancel(context.Background())
+	defer stopSweeper()
+
+	go sweepExpiredHolds(sweeperCtx, holdConfig.SweepInterval)
+
 	// setup gin
 	gin.SetMode(gin.ReleaseMode)
 	ginEngine := gin.Default()
@@ -120,11 +174,41 @@ func main() {
 
 }
 
+func getEnvInt32(key string, fallback int32) int32 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		log.Fatalf("[API] Error parsing '%s' in env file: %v\n", key, err)
+	}
+
+	return int32(parsed)
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("[API] Error parsing '%s' in env file: %v\n", key, err)
+	}
+
+	return parsed
+}
+
 func setupEndpoints(ginEngine *gin.Engine) {
 	ginEngine.GET("/healthcheck", healthcheck)
 
 	ginEngine.GET("/api/tunnels", getTunnels)
 
+	ginEngine.PUT("/api/tunnels/:id", updateTunnelById)
+
 	ginEngine.GET("/api/reservations", getReservations)
 
 	ginEngine.POST("/api/reservations", createReservation)
@@ -137,6 +221,106 @@ func setupEndpoints(ginEngine *gin.Engine) {
 
 	ginEngine.GET("/api/reservations/search", searchReservations)
 
+	ginEngine.POST("/api/reservations/:id/confirm", transitionReservation(models.ReservationStatusConfirmed))
+
+	ginEngine.POST("/api/reservations/:id/cancel", transitionReservation(models.ReservationStatusCancelled))
+
+	ginEngine.POST("/api/reservations/:id/complete", transitionReservation(models.ReservationStatusCompleted))
+
+	ginEngine.POST("/api/reservations/:id/no-show", transitionReservation(models.ReservationStatusNoShow))
+
+	ginEngine.GET("/api/reservations/:id/status-history", getReservationStatusHistory)
+
+	ginEngine.POST("/api/reservations/:id/reschedule", rescheduleReservation)
+
+	ginEngine.POST("/api/reservation-groups", createReservationGroup)
+
+	ginEngine.GET("/api/reservation-groups/:id", getReservationGroupById)
+
+	ginEngine.POST("/api/reservation-groups/:id/cancel", cancelReservationGroup)
+
+	ginEngine.POST("/api/reservation-groups/:id/move", moveReservationGroup)
+
+	ginEngine.GET("/api/sessions", getSessions)
+
+	ginEngine.POST("/api/sessions", createSession)
+
+	ginEngine.GET("/api/sessions/:id", getSessionById)
+
+	ginEngine.POST("/api/sessions/:id/cancel", cancelSession)
+
+	ginEngine.GET("/api/sessions/:id/roster", getSessionRoster)
+
+	ginEngine.POST("/api/sessions/:id/enrollments", enrollInSession)
+
+	ginEngine.DELETE("/api/sessions/:id/enrollments/:enrollmentId", withdrawFromSession)
+
+	ginEngine.GET("/api/programs", getPrograms)
+
+	ginEngine.POST("/api/programs", createProgram)
+
+	ginEngine.GET("/api/programs/:id", getProgramById)
+
+	ginEngine.POST("/api/programs/:id/cancel", cancelProgram)
+
+	ginEngine.GET("/api/programs/:id/enrollments", getProgramEnrollments)
+
+	ginEngine.POST("/api/programs/:id/enrollments", enrollInProgram)
+
+	ginEngine.DELETE("/api/programs/:id/enrollments/:enrollmentId", withdrawFromProgram)
+
+	ginEngine.GET("/api/customers", getCustomers)
+
+	ginEngine.POST("/api/customers", createCustomer)
+
+	ginEngine.GET("/api/customers/backfill", getCustomerBackfillReport)
+
+	ginEngine.POST("/api/customers/backfill", runCustomerBackfill)
+
+	ginEngine.GET("/api/customers/duplicates", getCustomerDuplicates)
+
+	ginEngine.GET("/api/customers/duplicates/reservations", getUnlinkedReservationMatches)
+
+	ginEngine.GET("/api/customers/:id", getCustomerById)
+
+	ginEngine.PUT("/api/customers/:id", updateCustomerById)
+
+	ginEngine.DELETE("/api/customers/:id", deleteCustomerById)
+
+	ginEngine.GET("/api/customers/:id/reservations", getCustomerReservations)
+
+	ginEngine.GET("/api/customers/:id/household", getHousehold)
+
+	ginEngine.POST("/api/customers/:id/merge", mergeCustomer)
+
+	ginEngine.GET("/api/customers/:id/merges", getCustomerMerges)
+
+	ginEngine.GET("/api/customers/:id/athletes", getAthletes)
+
+	ginEngine.POST("/api/customers/:id/athletes", createAthlete)
+
+	ginEngine.GET("/api/customers/:id/athletes/:athleteId", getAthleteById)
+
+	ginEngine.PUT("/api/customers/:id/athletes/:athleteId", updateAthleteById)
+
+	ginEngine.DELETE("/api/customers/:id/athletes/:athleteId", deleteAthleteById)
+
+	ginEngine.GET("/api/waitlist", getWaitlist)
+
+	ginEngine.POST("/api/waitlist", joinWaitlist)
+
+	ginEngine.GET("/api/waitlist/:id", getWaitlistEntryById)
+
+	ginEngine.DELETE("/api/waitlist/:id", leaveWaitlist)
+
+	ginEngine.POST("/api/reservation-series", createReservationSeries)
+
+	ginEngine.GET("/api/reservation-series/:id", getReservationSeriesById)
+
+	ginEngine.PUT("/api/reservation-series/:id/reservations/:reservationId", updateSeriesReservation)
+
+	ginEngine.POST("/api/reservation-series/:id/reservations/:reservationId/cancel", cancelSeriesReservation)
+
 	ginEngine.GET("/api/coaches", getCoaches)
 
 	ginEngine.POST("/api/coaches", createCoach)
@@ -144,6 +328,72 @@ func setupEndpoints(ginEngine *gin.Engine) {
 	ginEngine.PUT("/api/coaches/:id", updateCoachById)
 
 	ginEngine.DELETE("/api/coaches/:id", deleteCoachById)
+
+	ginEngine.GET("/api/coaches/:id/availability", getCoachAvailability)
+
+	ginEngine.POST("/api/coaches/:id/availability", createCoachAvailability)
+
+	ginEngine.PUT("/api/coaches/:id/availability/:availabilityId", updateCoachAvailabilityById)
+
+	ginEngine.DELETE("/api/coaches/:id/availability/:availabilityId", deleteCoachAvailabilityById)
+
+	ginEngine.GET("/api/coaches/:id/time-off", getCoachTimeOff)
+
+	ginEngine.POST("/api/coaches/:id/time-off", createCoachTimeOff)
+
+	ginEngine.DELETE("/api/coaches/:id/time-off/:timeOffId", deleteCoachTimeOffById)
+
+	ginEngine.GET("/api/business-hours", getBusinessHours)
+
+	ginEngine.GET("/api/business-hours/:dow", getBusinessHoursByDow)
+
+	ginEngine.PUT("/api/business-hours/:dow", updateBusinessHoursByDow)
+
+	ginEngine.GET("/api/special-hours", getSpecialHours)
+
+	ginEngine.POST("/api/special-hours", createSpecialHours)
+
+	ginEngine.GET("/api/special-hours/:id", getSpecialHoursById)
+
+	ginEngine.PUT("/api/special-hours/:id", updateSpecialHoursById)
+
+	ginEngine.DELETE("/api/special-hours/:id", deleteSpecialHoursById)
+
+	ginEngine.GET("/api/effective-hours", getEffectiveHours)
+
+	ginEngine.GET("/api/blackout-windows", getBlackoutWindows)
+
+	ginEngine.POST("/api/blackout-windows", createBlackoutWindow)
+
+	ginEngine.POST("/api/blackout-windows/preview", previewBlackoutWindow)
+
+	ginEngine.GET("/api/blackout-windows/:id", getBlackoutWindowById)
+
+	ginEngine.PUT("/api/blackout-windows/:id", updateBlackoutWindowById)
+
+	ginEngine.DELETE("/api/blackout-windows/:id", deleteBlackoutWindowById)
+
+	ginEngine.GET("/api/availability", getAvailability)
+
+	ginEngine.GET("/api/booking-rules", getBookingRules)
+
+	ginEngine.POST("/api/booking-rules", createBookingRule)
+
+	ginEngine.GET("/api/booking-rules/:id", getBookingRuleById)
+
+	ginEngine.PUT("/api/booking-rules/:id", updateBookingRuleById)
+
+	ginEngine.DELETE("/api/booking-rules/:id", deleteBookingRuleById)
+
+	ginEngine.GET("/api/lesson-types", getLessonTypes)
+
+	ginEngine.POST("/api/lesson-types", createLessonType)
+
+	ginEngine.GET("/api/lesson-types/:id", getLessonTypeById)
+
+	ginEngine.PUT("/api/lesson-types/:id", updateLessonTypeById)
+
+	ginEngine.DELETE("/api/lesson-types/:id", deleteLessonTypeById)
 }
 
 func healthcheck(c *gin.Context) {
@@ -151,7 +401,7 @@ func healthcheck(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	if err := conn.Ping(ctx); err != nil {
+	if err := pool.Ping(ctx); err != nil {
 		// ping failed
 		c.JSON(
 			http.StatusServiceUnavailable,
@@ -174,23 +424,54 @@ func healthcheck(c *gin.Context) {
 }
 
 func getTunnels(c *gin.Context) {
-	tunnels, err := dbUtils.LoadTunnelData(c.Request.Context(), conn)
+	tunnels, err := dbUtils.LoadTunnelData(c.Request.Context(), pool)
 
 	if err != nil {
 		log.Println("[API] Error loading tunnel data:", err)
-		c.Status(http.StatusInternalServerError)
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, tunnels)
 }
 
+func updateTunnelById(c *gin.Context) {
+	id := c.Param("id")
+
+	var tunnelUpdates models.TunnelUpdates
+
+	if err := c.ShouldBindJSON(&tunnelUpdates); err != nil {
+		log.Println("[API] Error binding JSON on PUT method at /api/tunnels/"+id, err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	tunnel, err := dbUtils.UpdateTunnelData(c.Request.Context(), pool, id, tunnelUpdates)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating tunnel:", err)
+		respondError(c, err)
+		return
+	}
+
+	if tunnel == nil {
+		log.Println("[API] Cannot update tunnel because it does not exist with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, tunnel)
+}
+
 func getReservations(c *gin.Context) {
-	reservations, err := dbUtils.LoadReservationData(c.Request.Context(), conn)
+	reservations, err := dbUtils.LoadReservationData(c.Request.Context(), pool)
 
 	if err != nil {
 		log.Println("[API] Error loading reservation data:", err)
-		c.Status(http.StatusInternalServerError)
+		respondError(c, err)
 		return
 	}
 
@@ -200,17 +481,132 @@ func getReservations(c *gin.Context) {
 func createReservation(c *gin.Context) {
 	var reservation models.Reservation
 
-	if err := c.BindJSON(&reservation); err != nil {
+	if err := c.ShouldBindJSON(&reservation); err != nil {
 		log.Println("[API] Error binding JSON on POST method at /api/reservations.", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": err.Error()})
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	// only staff overrides get a reason and an actor recorded
+	if !reservation.OverrideHours {
+		reservation.OverrideReason, reservation.OverrideBy = nil, nil
+	}
+
+	// session blocks are only created with their session
+	if reservation.Kind == models.ReservationKindSession || reservation.SessionId != nil {
+		validationErr := &dbUtils.ValidationError{}
+		validationErr.Add("reservation_kind", "session blocks are created through /api/sessions")
+		respondValidationError(c, validationErr)
+		return
+	}
+
+	// the tier comes from the customer, only staff overriding may pick another
+	if reservation.CustomerTier != nil && !reservation.OverrideHours {
+		validationErr := &dbUtils.ValidationError{}
+		validationErr.Add("customer_tier", "can only be set together with override_hours")
+		respondValidationError(c, validationErr)
+		return
+	}
+
+	// link the booking to its customer, through the athlete, by id or by a matching phone number/email
+	err := dbUtils.ResolveReservationCustomer(c.Request.Context(), pool, &reservation)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error resolving customer:", err)
+		respondError(c, err)
+		return
+	}
+
+	// lessons need a lesson type their coach teaches, it also sets the default length
+	lessonType, err := dbUtils.CheckLessonAssignment(c.Request.Context(), pool, reservation.Kind, reservation.LessonTypeId, reservation.CoachId)
+	if respondValidationError(c, err) || respondRuleViolations(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error checking lesson type:", err)
+		respondError(c, err)
+		return
+	}
+
+	if lessonType != nil && reservation.Duration == 0 && !reservation.EndTime.Valid {
+		reservation.Duration = lessonType.DefaultDuration
+	}
+
+	// the server works out whichever of duration_minutes/end_time is missing
+	if respondValidationError(c, dbUtils.ResolveReservationTimes(&reservation)) {
+		return
+	}
+
+	// mirror the column defaults so the hold expiry below always applies
+	if reservation.Status == "" {
+		reservation.Status = models.ReservationStatusHeld
+	}
+
+	// every other status is only reached through a transition
+	if reservation.Status != models.ReservationStatusHeld && reservation.Status != models.ReservationStatusConfirmed {
+		validationErr := &dbUtils.ValidationError{}
+		validationErr.Add("status", "new reservations must be held or confirmed")
+		respondValidationError(c, validationErr)
+		return
+	}
+
+	if reservation.BookingChannel == "" {
+		reservation.BookingChannel = models.BookingChannelWeb
+	}
+
+	reservation.HoldExpiresAt = pgtype.Timestamptz{}
+	if reservation.Status == models.ReservationStatusHeld {
+		reservation.HoldExpiresAt = holdConfig.ExpiresAt(reservation.BookingChannel, time.Now())
+	}
+
+	if dbUtils.BlocksSchedule(reservation.Status) {
+		err := dbUtils.EnforceReservationSchedule(c.Request.Context(), pool, facilityLocation, dbUtils.ScheduleCheck{
+			StartTime:      reservation.StartTime,
+			EndTime:        reservation.EndTime,
+			Kind:           reservation.Kind,
+			CoachId:        reservation.CoachId,
+			Override:       reservation.OverrideHours,
+			OverrideReason: reservation.OverrideReason,
+			OverrideBy:     reservation.OverrideBy,
+			BookingRules:   true,
+			CustomerTier:   reservation.CustomerTier,
+			Now:            time.Now(),
+		})
+
+		if respondValidationError(c, err) || respondRuleViolations(c, err) {
+			return
+		}
+
+		if err != nil {
+			log.Println("[API] Error checking reservation hours:", err)
+			respondError(c, err)
+			return
+		}
+	}
+
+	// send the data to the db, a lesson without a coach_id gets a free coach
+	// teaching its lesson type and a reservation without a tunnel_id any free tunnel
+	var result *models.Reservation
+	switch {
+	case reservation.Kind == models.ReservationKindLesson && reservation.CoachId == nil:
+		result, err = dbUtils.InsertLessonWithAssignedCoach(c.Request.Context(), pool, facilityLocation, tunnelAssignment, *lessonType, reservation)
+	case reservation.TunnelId == nil:
+		result, err = dbUtils.InsertReservationWithAssignedTunnel(c.Request.Context(), pool, facilityLocation, tunnelAssignment, reservation)
+	default:
+		result, err = dbUtils.InsertReservationData(c.Request.Context(), pool, reservation)
+	}
+
+	if respondNoCoachAvailable(c, err) || respondNoTunnelAvailable(c, err) {
 		return
 	}
 
-	// send the data to the db
-	result, err := dbUtils.InsertReservationData(c.Request.Context(), conn, reservation)
 	if err != nil {
 		log.Println("[API] Error inserting reservation:", err)
-		c.Status(http.StatusInternalServerError)
+		respondError(c, err)
 		return
 	}
 
@@ -221,16 +617,16 @@ func createReservation(c *gin.Context) {
 func getReservationById(c *gin.Context) {
 	id := c.Param("id")
 
-	reservation, err := dbUtils.LoadReservationById(c.Request.Context(), conn, id)
+	reservation, err := dbUtils.LoadReservationById(c.Request.Context(), pool, id)
 	if err != nil {
 		log.Println("[API] Error loading reservation:", err)
-		c.Status(http.StatusInternalServerError)
+		respondError(c, err)
 		return
 	}
 
 	if reservation == nil {
 		log.Println("[API] Could not find reservation with id:", id)
-		c.Status(http.StatusNotFound)
+		respondNotFound(c)
 		return
 	}
 
@@ -242,43 +638,197 @@ func updateReservationById(c *gin.Context) {
 
 	var reservationUpdates models.ReservationUpdates
 
-	if err := c.BindJSON(&reservationUpdates); err != nil {
+	if err := c.ShouldBindJSON(&reservationUpdates); err != nil {
 		log.Println("[API] Error binding JSON on PUT method at /api/reservations/"+id, err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": err.Error()})
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	if !reservationUpdates.OverrideHours {
+		reservationUpdates.OverrideReason, reservationUpdates.OverrideBy = nil, nil
+	}
+
+	if reservationUpdates.CustomerTier != nil && !reservationUpdates.OverrideHours {
+		validationErr := &dbUtils.ValidationError{}
+		validationErr.Add("customer_tier", "can only be set together with override_hours")
+		respondValidationError(c, validationErr)
+		return
+	}
+
+	existing, err := dbUtils.LoadReservationById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading reservation:", err)
+		respondError(c, err)
+		return
+	}
+
+	if existing == nil {
+		log.Println("[API] Cannot update reservation because it does not exist with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	if respondSessionBlock(c, *existing) {
+		return
+	}
+
+	// status changes go through the transition table, applied after the other fields
+	var statusChange *models.ReservationStatus
+
+	// only re-check the schedule when the booked time, coach or status changes
+	if reservationUpdates.StartTime != nil ||
+		reservationUpdates.Duration != nil ||
+		reservationUpdates.EndTime != nil ||
+		reservationUpdates.Status != nil ||
+		reservationUpdates.CoachId != nil ||
+		reservationUpdates.Kind != nil ||
+		reservationUpdates.LessonTypeId != nil ||
+		reservationUpdates.CustomerTier != nil {
+		if respondValidationError(c, dbUtils.ResolveReservationTimeUpdates(*existing, &reservationUpdates)) {
+			return
+		}
+
+		startTime, endTime, status := existing.StartTime, existing.EndTime, existing.Status
+		kind, coachId := existing.Kind, existing.CoachId
+		if reservationUpdates.StartTime != nil {
+			startTime = *reservationUpdates.StartTime
+		}
+
+		if reservationUpdates.EndTime != nil {
+			endTime = *reservationUpdates.EndTime
+		}
+
+		if reservationUpdates.Status != nil && *reservationUpdates.Status != existing.Status {
+			if !existing.Status.CanTransitionTo(*reservationUpdates.Status) {
+				respondInvalidTransition(c, &dbUtils.InvalidTransitionError{From: existing.Status, To: *reservationUpdates.Status})
+				return
+			}
+
+			statusChange = reservationUpdates.Status
+			status = *statusChange
+		}
+		reservationUpdates.Status = nil
+
+		if reservationUpdates.Kind != nil {
+			kind = *reservationUpdates.Kind
+		}
+
+		if reservationUpdates.CoachId != nil {
+			coachId = reservationUpdates.CoachId
+		}
+
+		if reservationUpdates.Kind != nil || reservationUpdates.CoachId != nil || reservationUpdates.LessonTypeId != nil {
+			lessonTypeId := existing.LessonTypeId
+			if reservationUpdates.LessonTypeId != nil {
+				lessonTypeId = reservationUpdates.LessonTypeId
+			}
+
+			_, err = dbUtils.CheckLessonAssignment(c.Request.Context(), pool, kind, lessonTypeId, coachId)
+			if respondValidationError(c, err) || respondRuleViolations(c, err) {
+				return
+			}
+
+			if err != nil {
+				log.Println("[API] Error checking lesson type:", err)
+				respondError(c, err)
+				return
+			}
+		}
+
+		customerTier := existing.CustomerTier
+		if reservationUpdates.CustomerTier != nil {
+			customerTier = reservationUpdates.CustomerTier
+		}
+
+		// booking rules only apply to a changed booking, an unchanged one keeps
+		// the rules it was made under, and lead time only to a new start
+		bookingRules := !startTime.Time.Equal(existing.StartTime.Time) ||
+			!endTime.Time.Equal(existing.EndTime.Time) ||
+			kind != existing.Kind ||
+			reservationUpdates.CustomerTier != nil
+
+		var bookedAt time.Time
+		if !startTime.Time.Equal(existing.StartTime.Time) {
+			bookedAt = time.Now()
+		}
+
+		if dbUtils.BlocksSchedule(status) {
+			err = dbUtils.EnforceReservationSchedule(c.Request.Context(), pool, facilityLocation, dbUtils.ScheduleCheck{
+				StartTime:      startTime,
+				EndTime:        endTime,
+				Kind:           kind,
+				CoachId:        coachId,
+				Override:       reservationUpdates.OverrideHours,
+				OverrideReason: reservationUpdates.OverrideReason,
+				OverrideBy:     reservationUpdates.OverrideBy,
+				BookingRules:   bookingRules,
+				CustomerTier:   customerTier,
+				Now:            bookedAt,
+			})
+
+			if respondValidationError(c, err) || respondRuleViolations(c, err) {
+				return
+			}
+
+			if err != nil {
+				log.Println("[API] Error checking reservation hours:", err)
+				respondError(c, err)
+				return
+			}
+		}
+	}
+
+	// the field updates and the status change stand or fall together
+	reservation, err := dbUtils.UpdateReservationWithStatus(c.Request.Context(), pool, id, reservationUpdates, statusChange)
+	if respondInvalidTransition(c, err) {
 		return
 	}
 
-	reservation, err := dbUtils.UpdateReservationData(c.Request.Context(), conn, id, reservationUpdates)
 	if err != nil {
 		log.Println("[API] Error updating reservation:", err)
-		c.Status(http.StatusInternalServerError)
+		respondError(c, err)
 		return
 	}
 
 	if reservation == nil {
 		log.Println("[API] Cannot update reservation because it does not exist with id:", id)
-		c.Status(http.StatusNotFound)
+		respondNotFound(c)
 		return
 	}
 
+	if statusChange != nil && reservation.Status == models.ReservationStatusCancelled {
+		offerFreedSlots(c.Request.Context(), []models.Reservation{*reservation})
+	}
+
 	c.JSON(http.StatusOK, reservation)
 }
 
 func deleteReservationById(c *gin.Context) {
 	id := c.Param("id")
 
-	rowsAffected, err := dbUtils.DeleteReservationData(c.Request.Context(), conn, id)
+	existing, err := dbUtils.LoadReservationById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading reservation:", err)
+		respondError(c, err)
+		return
+	}
+
+	if existing != nil && respondSessionBlock(c, *existing) {
+		return
+	}
+
+	rowsAffected, err := dbUtils.DeleteReservationData(c.Request.Context(), pool, id)
 
 	if err != nil {
 		log.Println("[API] Error deleting reservation:", err)
-		c.Status(http.StatusInternalServerError)
+		respondError(c, err)
 		return
 	}
 
 	// didn't delete anything
 	if rowsAffected < 1 {
 		log.Println("[API] Could not find reservation to delete with id:", id)
-		c.Status(http.StatusNotFound)
+		respondNotFound(c)
 		return
 	}
 
@@ -291,10 +841,10 @@ func searchReservations(c *gin.Context) {
 	toTimeStr := c.Query("to")
 	tunnelIdStr := c.Query("tunnel_id")
 
-	reservations, err := dbUtils.LoadReservationDataWithParams(c.Request.Context(), conn, fromTimeStr, toTimeStr, tunnelIdStr)
+	reservations, err := dbUtils.LoadReservationDataWithParams(c.Request.Context(), pool, fromTimeStr, toTimeStr, tunnelIdStr)
 	if err != nil {
 		log.Println("[API] Error loading reservation data:", err)
-		c.Status(http.StatusInternalServerError)
+		respondError(c, err)
 		return
 	}
 
@@ -302,10 +852,10 @@ func searchReservations(c *gin.Context) {
 }
 
 func getCoaches(c *gin.Context) {
-	coaches, err := dbUtils.LoadCoachesData(c.Request.Context(), conn)
+	coaches, err := dbUtils.LoadCoachesData(c.Request.Context(), pool)
 	if err != nil {
 		log.Println("[API] Error loading coaches:", err)
-		c.Status(http.StatusInternalServerError)
+		respondError(c, err)
 		return
 	}
 
@@ -313,19 +863,24 @@ func getCoaches(c *gin.Context) {
 }
 
 func createCoach(c *gin.Context) {
-	var coach models.Coach
+	// a coach that doesn't say otherwise gets the default break between lessons
+	coach := models.Coach{TurnoverBufferMinutes: models.DefaultCoachTurnoverMinutes}
 
-	if err := c.BindJSON(&coach); err != nil {
+	if err := c.ShouldBindJSON(&coach); err != nil {
 		log.Println("[API] Error binding JSON on POST method at /api/coaches.", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": err.Error()})
+		respondInvalidRequest(c, err)
 		return
 	}
 
 	// send the data to the db
-	result, err := dbUtils.InsertCoachData(c.Request.Context(), conn, coach)
+	result, err := dbUtils.InsertCoachData(c.Request.Context(), pool, coach)
+	if respondValidationError(c, err) {
+		return
+	}
+
 	if err != nil {
 		log.Println("[API] Error inserting coach:", err)
-		c.Status(http.StatusInternalServerError)
+		respondError(c, err)
 		return
 	}
 
@@ -338,22 +893,26 @@ func updateCoachById(c *gin.Context) {
 
 	var coachUpdates models.CoachUpdates
 
-	if err := c.BindJSON(&coachUpdates); err != nil {
+	if err := c.ShouldBindJSON(&coachUpdates); err != nil {
 		log.Println("[API] Error binding JSON on PUT method at /api/coaches/"+id, err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": err.Error()})
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	coach, err := dbUtils.UpdateCoachData(c.Request.Context(), pool, id, coachUpdates)
+	if respondValidationError(c, err) {
 		return
 	}
 
-	coach, err := dbUtils.UpdateCoachData(c.Request.Context(), conn, id, coachUpdates)
 	if err != nil {
 		log.Println("[API] Error updating coach:", err)
-		c.Status(http.StatusInternalServerError)
+		respondError(c, err)
 		return
 	}
 
 	if coach == nil {
 		log.Println("[API] Cannot update coach because it does not exist with id:", id)
-		c.Status(http.StatusNotFound)
+		respondNotFound(c)
 		return
 	}
 
@@ -363,18 +922,18 @@ func updateCoachById(c *gin.Context) {
 func deleteCoachById(c *gin.Context) {
 	id := c.Param("id")
 
-	rowsAffected, err := dbUtils.DeleteCoachData(c.Request.Context(), conn, id)
+	rowsAffected, err := dbUtils.DeleteCoachData(c.Request.Context(), pool, id)
 
 	if err != nil {
 		log.Println("[API] Error deleting coach:", err)
-		c.Status(http.StatusInternalServerError)
+		respondError(c, err)
 		return
 	}
 
 	// didn't delete anything
 	if rowsAffected < 1 {
 		log.Println("[API] Could not find coach to delete with id:", id)
-		c.Status(http.StatusNotFound)
+		respondNotFound(c)
 		return
 	}
 
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// newTestEngine swaps the package pool for a mock and returns a router with every endpoint registered.
+func newTestEngine(t *testing.T) (*gin.Engine, pgxmock.PgxPoolIface) {
+	t.Helper()
+
+	mockPool, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal("unexpected error creating mock pool:", err)
+	}
+
+	// handlers race each other, so expectations can be met in any order
+	mockPool.MatchExpectationsInOrder(false)
+
+	previous := pool
+	pool = mockPool
+	t.Cleanup(func() {
+		pool = previous
+		mockPool.Close()
+	})
+
+	gin.SetMode(gin.TestMode)
+	ginEngine := gin.New()
+	setupEndpoints(ginEngine)
+
+	return ginEngine, mockPool
+}
+
+func Test_ConcurrentRequests(t *testing.T) {
+	// setup
+	ginEngine, mockPool := newTestEngine(t)
+
+	const workers = 50
+
+	for i := 0; i < workers; i++ {
+		pgU := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+		// every request gets its own rows, the small delay keeps the handlers overlapping
+		mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM reservations`)).
+			WillReturnRows(
+				pgxmock.NewRows([]string{"id", "customer_first_name", "customer_last_name"}).
+					AddRow(pgU, "John", "Doe"),
+			).
+			WillDelayFor(5 * time.Millisecond)
+
+		mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM tunnels`)).
+			WillReturnRows(
+				pgxmock.NewRows([]string{"id", "name", "is_active"}).
+					AddRow(int32(1), "Tunnel 1", true),
+			).
+			WillDelayFor(5 * time.Millisecond)
+
+		mockPool.ExpectPing()
+	}
+
+	paths := []string{"/api/reservations", "/api/tunnels", "/healthcheck"}
+	statuses := make(chan int, workers*len(paths))
+
+	// exercise
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+
+	for i := 0; i < workers; i++ {
+		for _, path := range paths {
+			wg.Add(1)
+
+			go func(path string) {
+				defer wg.Done()
+				<-start
+
+				recorder := httptest.NewRecorder()
+				request := httptest.NewRequest(http.MethodGet, path, nil)
+				ginEngine.ServeHTTP(recorder, request)
+
+				statuses <- recorder.Code
+			}(path)
+		}
+	}
+
+	close(start)
+	wg.Wait()
+	close(statuses)
+
+	// verify
+	for status := range statuses {
+		if status != http.StatusOK {
+			t.Fatal("expected status 200, got", status)
+		}
+	}
+
+	if err := mockPool.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ConcurrentRequests_Healthcheck_DBDown(t *testing.T) {
+	// setup
+	ginEngine, mockPool := newTestEngine(t)
+
+	const workers = 20
+
+	for i := 0; i < workers; i++ {
+		mockPool.ExpectPing().WillReturnError(http.ErrHandlerTimeout)
+	}
+
+	statuses := make(chan int, workers)
+
+	// exercise
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, "/healthcheck", nil)
+			ginEngine.ServeHTTP(recorder, request)
+
+			statuses <- recorder.Code
+		}()
+	}
+
+	wg.Wait()
+	close(statuses)
+
+	// verify
+	for status := range statuses {
+		if status != http.StatusServiceUnavailable {
+			t.Fatal("expected status 503, got", status)
+		}
+	}
+
+	if err := mockPool.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_GetReservationById_MalformedId(t *testing.T) {
+	// setup
+	ginEngine, mockPool := newTestEngine(t)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM reservations WHERE id=$1`)).
+		WithArgs("not-a-uuid").
+		WillReturnError(&pgconn.PgError{Code: "22P02", Message: `invalid input syntax for type uuid: "not-a-uuid"`})
+
+	// exercise
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/api/reservations/not-a-uuid", nil)
+	ginEngine.ServeHTTP(recorder, request)
+
+	// verify
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatal("expected status 400, got", recorder.Code)
+	}
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != problemContentType {
+		t.Fatal("expected a problem+json response, got", contentType)
+	}
+
+	var problem map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+		t.Fatal("unexpected error decoding body:", err)
+	}
+
+	if problem["code"] != "invalid_input" || problem["field"] != "id" || problem["status"] != float64(http.StatusBadRequest) {
+		t.Fatal("unexpected problem body:", problem)
+	}
+}
+
+func Test_CreateReservation_SessionBlock(t *testing.T) {
+	// setup
+	ginEngine, mockPool := newTestEngine(t)
+
+	body := `{"reservation_kind": "session", "tunnel_id": 1, "session_id": "` + uuid.NewString() + `"}`
+
+	// exercise
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/api/reservations", strings.NewReader(body))
+	ginEngine.ServeHTTP(recorder, request)
+
+	// verify
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatal("expected status 400, got", recorder.Code, recorder.Body.String())
+	}
+
+	if err := mockPool.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_UpdateReservationById_SessionBlock(t *testing.T) {
+	// setup
+	ginEngine, mockPool := newTestEngine(t)
+
+	id := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	sessionId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM reservations WHERE id=$1`)).WithArgs(id.String()).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "reservation_kind", "session_id"}).AddRow(id, "session", &sessionId),
+	)
+
+	// exercise
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPut, "/api/reservations/"+id.String(), strings.NewReader(`{"notes": "moved by hand"}`))
+	ginEngine.ServeHTTP(recorder, request)
+
+	// verify
+	if recorder.Code != http.StatusConflict {
+		t.Fatal("expected status 409, got", recorder.Code, recorder.Body.String())
+	}
+
+	var problem map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+		t.Fatal("unexpected error decoding body:", err)
+	}
+
+	if problem["code"] != "session_block" || problem["session_id"] != sessionId.String() {
+		t.Fatal("unexpected problem body:", problem)
+	}
+
+	if err := mockPool.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_RescheduleReservation_SessionBlock(t *testing.T) {
+	// setup
+	ginEngine, mockPool := newTestEngine(t)
+
+	id := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	sessionId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM reservations WHERE id=$1`)).WithArgs(id.String()).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "reservation_kind", "status", "session_id"}).AddRow(id, "session", "confirmed", &sessionId),
+	)
+
+	body := `{"start_time": "` + time.Now().Add(48*time.Hour).UTC().Format(time.RFC3339) + `"}`
+
+	// exercise
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/api/reservations/"+id.String()+"/reschedule", strings.NewReader(body))
+	ginEngine.ServeHTTP(recorder, request)
+
+	// verify
+	if recorder.Code != http.StatusConflict {
+		t.Fatal("expected status 409, got", recorder.Code, recorder.Body.String())
+	}
+
+	if !strings.Contains(recorder.Body.String(), `"session_block"`) {
+		t.Fatal("expected a session_block problem, got", recorder.Body.String())
+	}
+
+	if err := mockPool.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_UpdateSeriesReservation_CoachCannotTeach(t *testing.T) {
+	// setup
+	ginEngine, mockPool := newTestEngine(t)
+
+	id := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	seriesId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	lessonTypeId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+	coachId := pgtype.UUID{Bytes: [16]byte(uuid.New()), Valid: true}
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM reservations WHERE id=$1`)).WithArgs(id.String()).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "reservation_kind", "lesson_type_id", "status", "series_id"}).
+			AddRow(id, "lesson", &lessonTypeId, "confirmed", &seriesId),
+	)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM lesson_types WHERE id=$1`)).WithArgs(lessonTypeId.String()).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "name", "specialty", "default_duration_minutes", "price_cents", "is_active"}).
+			AddRow(lessonTypeId, "Bullpen session", models.SpecialtyPitching, int32(45), int32(5000), true),
+	)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM coaches WHERE id=$1`)).WithArgs(coachId).WillReturnRows(
+		pgxmock.NewRows([]string{"id", "first_name", "last_name", "is_active", "specialties"}).
+			AddRow(coachId, "Casey", "Stengel", true, []string{models.SpecialtyHitting}),
+	)
+
+	body := `{"coach_id": "` + coachId.String() + `"}`
+
+	// exercise
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPut, "/api/reservation-series/"+seriesId.String()+"/reservations/"+id.String()+"?scope=all", strings.NewReader(body))
+	ginEngine.ServeHTTP(recorder, request)
+
+	// verify
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Fatal("expected status 422, got", recorder.Code, recorder.Body.String())
+	}
+
+	if err := mockPool.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,60 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+const (
+	SportBaseball string = "baseball"
+	SportSoftball string = "softball"
+)
+
+const (
+	HandRight  string = "right"
+	HandLeft   string = "left"
+	HandSwitch string = "switch" // bats only
+)
+
+// FieldPositions are the field_position values.
+var FieldPositions = []string{
+	"pitcher", "catcher", "first_base", "second_base", "third_base", "shortstop",
+	"left_field", "center_field", "right_field", "infield", "outfield", "utility",
+}
+
+// Athlete is one of the kids a customer books for, reservations name them in
+// athlete_id so the coach knows who is coming.
+type Athlete struct {
+	Id         pgtype.UUID        `db:"id" json:"id"`
+	CustomerId pgtype.UUID        `db:"customer_id" json:"customer_id"` // the guardian paying for the athlete
+	FirstName  string             `db:"first_name" json:"first_name"`
+	LastName   string             `db:"last_name" json:"last_name"`
+	BirthYear  int32              `db:"birth_year" json:"birth_year"`
+	Sport      string             `db:"sport" json:"sport"`
+	Bats       *string            `db:"bats" json:"bats"`
+	Throws     *string            `db:"throws" json:"throws"`
+	Position   *string            `db:"position" json:"position"`
+	Notes      *string            `db:"notes" json:"notes"`
+	IsActive   bool               `db:"is_active" json:"is_active"` // false => no longer booked for
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt  pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+// AthleteUpdates is used for both creating and updating athletes.
+type AthleteUpdates struct {
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+	BirthYear *int32  `json:"birth_year"`
+	Sport     *string `json:"sport"`
+	Bats      *string `json:"bats"`
+	Throws    *string `json:"throws"`
+	Position  *string `json:"position"`
+	Notes     *string `json:"notes"`
+	IsActive  *bool   `json:"is_active"`
+}
+
+// Household is a customer with their athletes and every reservation made by
+// them, split around now.
+type Household struct {
+	Customer Customer      `json:"customer"`
+	Athletes []Athlete     `json:"athletes"`
+	Upcoming []Reservation `json:"upcoming"` // soonest first
+	Past     []Reservation `json:"past"`     // latest first
+}
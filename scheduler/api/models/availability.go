@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TimeSlot times are sent in the facility's timezone so the offset shows the local wall time.
+type TimeSlot struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type TunnelAvailability struct {
+	TunnelId   int32      `json:"tunnel_id"`
+	TunnelName string     `json:"tunnel_name"`
+	Slots      []TimeSlot `json:"slots"`
+}
+
+type Availability struct {
+	Date               pgtype.Date          `json:"date"`
+	Timezone           string               `json:"timezone"`
+	IsOpen             bool                 `json:"is_open"`
+	OpenTime           TimeOfDay            `json:"open_time"`
+	CloseTime          TimeOfDay            `json:"close_time"`
+	Kind               ReservationKind      `json:"reservation_kind"`
+	DurationMinutes    int32                `json:"duration_minutes"`
+	GranularityMinutes int32                `json:"granularity_minutes"`
+	Tunnels            []TunnelAvailability `json:"tunnels"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type BlackoutWindow struct {
+	Id       pgtype.UUID        `db:"id" json:"id"`
+	StartsAt pgtype.Timestamptz `db:"starts_at" json:"starts_at"`
+	EndsAt   pgtype.Timestamptz `db:"ends_at" json:"ends_at"`
+	Reason   string             `db:"reason" json:"reason"`
+}
+
+type BlackoutWindowUpdates struct {
+	StartsAt *pgtype.Timestamptz `db:"starts_at" json:"starts_at"`
+	EndsAt   *pgtype.Timestamptz `db:"ends_at" json:"ends_at"`
+	Reason   *string             `db:"reason" json:"reason"`
+}
+
+// BlackoutImpact lists what a blackout would run into: the held/confirmed
+// reservations it covers and any existing blackout it overlaps.
+type BlackoutImpact struct {
+	StartsAt             pgtype.Timestamptz `json:"starts_at"`
+	EndsAt               pgtype.Timestamptz `json:"ends_at"`
+	ImpactedReservations []Reservation      `json:"impacted_reservations"`
+	ConflictingWindows   []BlackoutWindow   `json:"conflicting_windows"`
+}
+
+type BlackoutWindowResult struct {
+	BlackoutWindow       BlackoutWindow `json:"blackout_window"`
+	ImpactedReservations []Reservation  `json:"impacted_reservations"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+// BookingRule limits how a kind of reservation may be booked. A rule with a
+// customer tier replaces the kind's default rule for that tier.
+type BookingRule struct {
+	Id                      pgtype.UUID        `db:"id" json:"id"`
+	Kind                    ReservationKind    `db:"reservation_kind" json:"reservation_kind"`
+	CustomerTier            *string            `db:"customer_tier" json:"customer_tier"`                         // nil => the kind's default rule
+	AllowedDurations        []int32            `db:"allowed_durations" json:"allowed_durations"`                 // minutes, empty => any duration
+	StartGranularityMinutes int32              `db:"start_granularity_minutes" json:"start_granularity_minutes"` // starts fall on multiples of this past local midnight
+	MinLeadMinutes          int32              `db:"min_lead_minutes" json:"min_lead_minutes"`
+	MaxHorizonDays          *int32             `db:"max_horizon_days" json:"max_horizon_days"` // nil => no limit
+	CreatedAt               pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt               pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+// BookingRuleUpdates is used for both creating and updating booking rules.
+type BookingRuleUpdates struct {
+	Kind                    *ReservationKind `json:"reservation_kind"`
+	CustomerTier            *string          `json:"customer_tier"`
+	AllowedDurations        *[]int32         `json:"allowed_durations"`
+	StartGranularityMinutes *int32           `json:"start_granularity_minutes"`
+	MinLeadMinutes          *int32           `json:"min_lead_minutes"`
+	MaxHorizonDays          *int32           `json:"max_horizon_days"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type BusinessHours struct {
+	Id        pgtype.UUID `db:"id" json:"id"`
+	Dow       int32       `db:"dow" json:"dow"` // 0=Sun … 6=Sat
+	OpenTime  TimeOfDay   `db:"open_time" json:"open_time"`
+	CloseTime TimeOfDay   `db:"close_time" json:"close_time"`
+	IsOpen    bool        `db:"is_open" json:"is_open"`
+}
+
+// BusinessHoursUpdates keeps the times as raw strings so bad input can be
+// reported per field instead of failing the whole JSON bind.
+type BusinessHoursUpdates struct {
+	OpenTime  *string `json:"open_time"`
+	CloseTime *string `json:"close_time"`
+	IsOpen    *bool   `json:"is_open"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "github.com/jackc/pgx/v5/pgtype"
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
 
 const (
 	SpecialtyHitting  string = "hitting"
@@ -9,23 +13,37 @@ const (
 	SpecialtyCatching string = "catching"
 )
 
+// IsSpecialty reports whether s is one of the coach_specialty values.
+func IsSpecialty(s string) bool {
+	return s == SpecialtyHitting || s == SpecialtyPitching || s == SpecialtyFielding || s == SpecialtyCatching
+}
+
+// DefaultCoachTurnoverMinutes is the break new coaches get between lessons.
+const DefaultCoachTurnoverMinutes int32 = 10
+
 type Coach struct {
-	Id          pgtype.UUID        `db:"id" json:"id"`
-	FirstName   string             `db:"first_name" json:"first_name"`
-	LastName    string             `db:"last_name" json:"last_name"`
-	Email       *string            `db:"email" json:"email"`
-	Phone       string             `db:"phone" json:"phone"`
-	IsActive    bool               `db:"is_active" json:"is_active"`
-	Specialties []string           `db:"specialties" json:"specialties"`
-	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
-	UpdatedAt   pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	Id                    pgtype.UUID        `db:"id" json:"id"`
+	FirstName             string             `db:"first_name" json:"first_name"`
+	LastName              string             `db:"last_name" json:"last_name"`
+	Email                 *string            `db:"email" json:"email"`
+	Phone                 string             `db:"phone" json:"phone"`
+	IsActive              bool               `db:"is_active" json:"is_active"`
+	Specialties           []string           `db:"specialties" json:"specialties"`
+	TurnoverBufferMinutes int32              `db:"turnover_buffer_minutes" json:"turnover_buffer_minutes"` // break after a lesson before the next
+	CreatedAt             pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt             pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 }
 
 type CoachUpdates struct {
-	FirstName   *string   `db:"first_name" json:"first_name"`
-	LastName    *string   `db:"last_name" json:"last_name"`
-	Email       *string   `db:"email" json:"email"`
-	Phone       *string   `db:"phone" json:"phone"`
-	IsActive    *bool     `db:"is_active" json:"is_active"`
-	Specialties *[]string `db:"specialties" json:"specialties"`
+	FirstName             *string   `db:"first_name" json:"first_name"`
+	LastName              *string   `db:"last_name" json:"last_name"`
+	Email                 *string   `db:"email" json:"email"`
+	Phone                 *string   `db:"phone" json:"phone"`
+	IsActive              *bool     `db:"is_active" json:"is_active"`
+	Specialties           *[]string `db:"specialties" json:"specialties"`
+	TurnoverBufferMinutes *int32    `db:"turnover_buffer_minutes" json:"turnover_buffer_minutes"`
+}
+
+func (c Coach) TurnoverBuffer() time.Duration {
+	return time.Duration(c.TurnoverBufferMinutes) * time.Minute
 }
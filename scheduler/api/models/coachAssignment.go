@@ -0,0 +1,22 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+// CoachCandidate is one coach that was considered for a lesson without a
+// coach_id and why they could or couldn't take it.
+type CoachCandidate struct {
+	CoachId           pgtype.UUID `json:"coach_id"`
+	CoachName         string      `json:"coach_name"`
+	Available         bool        `json:"available"`
+	WeekLessonMinutes int32       `json:"week_lesson_minutes"` // held/confirmed lesson time that week
+	Reason            string      `json:"reason"`
+}
+
+// CoachAssignment explains which coach the server gave a lesson and why.
+type CoachAssignment struct {
+	CoachId    pgtype.UUID      `json:"coach_id"`
+	CoachName  string           `json:"coach_name"`
+	Specialty  string           `json:"specialty"`
+	Reason     string           `json:"reason"`
+	Candidates []CoachCandidate `json:"candidates"`
+}
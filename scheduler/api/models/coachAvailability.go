@@ -0,0 +1,36 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+// CoachAvailability is one weekly working window for a coach. EffectiveTo is
+// inclusive and left invalid (null) when the window has no end date.
+type CoachAvailability struct {
+	Id            pgtype.UUID        `db:"id" json:"id"`
+	CoachId       pgtype.UUID        `db:"coach_id" json:"coach_id"`
+	Dow           int32              `db:"dow" json:"dow"` // 0=Sun … 6=Sat
+	StartTime     TimeOfDay          `db:"start_time" json:"start_time"`
+	EndTime       TimeOfDay          `db:"end_time" json:"end_time"`
+	EffectiveFrom pgtype.Date        `db:"effective_from" json:"effective_from"`
+	EffectiveTo   pgtype.Date        `db:"effective_to" json:"effective_to"`
+	CreatedAt     pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+// CoachAvailabilityUpdates is used for both creating and updating a window.
+// Dates and times stay raw strings so bad input is reported per field.
+type CoachAvailabilityUpdates struct {
+	Dow           *int32  `json:"dow"`
+	StartTime     *string `json:"start_time"`
+	EndTime       *string `json:"end_time"`
+	EffectiveFrom *string `json:"effective_from"`
+	EffectiveTo   *string `json:"effective_to"`
+}
+
+type CoachTimeOff struct {
+	Id        pgtype.UUID        `db:"id" json:"id"`
+	CoachId   pgtype.UUID        `db:"coach_id" json:"coach_id"`
+	StartsAt  pgtype.Timestamptz `db:"starts_at" json:"starts_at"`
+	EndsAt    pgtype.Timestamptz `db:"ends_at" json:"ends_at"`
+	Reason    *string            `db:"reason" json:"reason"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Customer is whoever books, reservations point at it through customer_id.
+// Phone and email are matched in their normalized form.
+type Customer struct {
+	Id              pgtype.UUID        `db:"id" json:"id"`
+	FirstName       string             `db:"first_name" json:"first_name"`
+	LastName        string             `db:"last_name" json:"last_name"`
+	Phone           string             `db:"phone" json:"phone"`
+	Email           *string            `db:"email" json:"email"`
+	PhoneNormalized string             `db:"phone_normalized" json:"phone_normalized"` // set by the db, digits only
+	EmailNormalized *string            `db:"email_normalized" json:"email_normalized"` // set by the db, trimmed and lower case
+	Notes           *string            `db:"notes" json:"notes"`
+	Tier            *string            `db:"tier" json:"tier"` // picks the booking rules of their reservations, nil => the kind's default
+	CreatedAt       pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+// CustomerUpdates is used for both creating and updating customers.
+type CustomerUpdates struct {
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+	Phone     *string `json:"phone"`
+	Email     *string `json:"email"`
+	Notes     *string `json:"notes"`
+	Tier      *string `json:"tier"` // an empty tier clears it
+}
+
+// CustomerBackfillGroup is a row of the customer backfill report: the
+// unlinked reservations sharing a normalized phone number and what the
+// backfill does with them.
+type CustomerBackfillGroup struct {
+	PhoneKey            *string       `db:"phone_key" json:"phone_key"`
+	ReservationCount    int32         `db:"reservation_count" json:"reservation_count"`
+	Emails              []string      `db:"emails" json:"emails"`
+	Names               []string      `db:"names" json:"names"`
+	MatchingCustomerIds []pgtype.UUID `db:"matching_customer_ids" json:"matching_customer_ids"`
+	Action              string        `db:"action" json:"action"`           // link, create or ambiguous
+	Reason              *string       `db:"reason" json:"reason"`           // why an ambiguous group was left alone
+	CustomerId          *pgtype.UUID  `db:"customer_id" json:"customer_id"` // nil when a dry run would create the customer
+}
+
+// CustomerBackfillReport is what a backfill did, or would do when DryRun.
+type CustomerBackfillReport struct {
+	DryRun    bool                    `json:"dry_run"`
+	Linked    []CustomerBackfillGroup `json:"linked"`
+	Ambiguous []CustomerBackfillGroup `json:"ambiguous"`
+}
+
+// CustomerDuplicate is a pair of customers that are likely the same family,
+// Customer is the older record.
+type CustomerDuplicate struct {
+	Customer       Customer `json:"customer"`
+	Duplicate      Customer `json:"duplicate"`
+	Score          float64  `json:"score"` // 0-1, higher is more likely the same
+	SamePhone      bool     `json:"same_phone"`
+	SameEmail      bool     `json:"same_email"`
+	NameSimilarity float64  `json:"name_similarity"` // trigram similarity of the full names
+}
+
+// CustomerReservationMatch is a reservation not linked to any customer that
+// likely belongs to Customer, linking it is up to the front desk.
+type CustomerReservationMatch struct {
+	Reservation    Reservation `json:"reservation"`
+	Customer       Customer    `json:"customer"`
+	Score          float64     `json:"score"` // 0-1, scored as for CustomerDuplicate
+	SamePhone      bool        `json:"same_phone"`
+	SameEmail      bool        `json:"same_email"`
+	NameSimilarity float64     `json:"name_similarity"`
+}
+
+// CustomerMergeRequest is the body of a merge, the duplicate is merged into
+// the customer of the URL.
+type CustomerMergeRequest struct {
+	DuplicateId pgtype.UUID `json:"duplicate_id"`
+	MergedBy    *string     `json:"merged_by"`
+	Reason      *string     `json:"reason"`
+}
+
+// CustomerMerge is the audit record of a merge.
+type CustomerMerge struct {
+	Id                  pgtype.UUID        `db:"id" json:"id"`
+	SurvivingCustomerId pgtype.UUID        `db:"surviving_customer_id" json:"surviving_customer_id"`
+	MergedCustomerId    pgtype.UUID        `db:"merged_customer_id" json:"merged_customer_id"`
+	MergedCustomer      json.RawMessage    `db:"merged_customer" json:"merged_customer"` // the deleted customer as it was
+	ReservationsMoved   int32              `db:"reservations_moved" json:"reservations_moved"`
+	AthletesMoved       int32              `db:"athletes_moved" json:"athletes_moved"`
+	MergedBy            *string            `db:"merged_by" json:"merged_by"`
+	Reason              *string            `db:"reason" json:"reason"`
+	MergedAt            pgtype.Timestamptz `db:"merged_at" json:"merged_at"`
+}
+
+type CustomerMergeResult struct {
+	Customer Customer      `json:"customer"`
+	Merge    CustomerMerge `json:"merge"`
+}
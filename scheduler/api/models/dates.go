@@ -0,0 +1,31 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const DateLayout = "2006-01-02"
+
+// ParseDate parses a "YYYY-MM-DD" calendar date.
+func ParseDate(s string) (pgtype.Date, error) {
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return pgtype.Date{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s)
+	}
+
+	return pgtype.Date{Time: t, Valid: true, InfinityModifier: pgtype.Finite}, nil
+}
+
+// LocalDate is the calendar date of t as seen on a wall clock in loc.
+func LocalDate(t time.Time, loc *time.Location) pgtype.Date {
+	year, month, day := t.In(loc).Date()
+
+	return pgtype.Date{
+		Time:             time.Date(year, month, day, 0, 0, 0, 0, time.UTC),
+		Valid:            true,
+		InfinityModifier: pgtype.Finite,
+	}
+}
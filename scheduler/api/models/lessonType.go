@@ -0,0 +1,25 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+// LessonType is an entry of the lesson catalog, a lesson of this type needs a
+// coach with its specialty.
+type LessonType struct {
+	Id              pgtype.UUID        `db:"id" json:"id"`
+	Name            string             `db:"name" json:"name"`
+	Specialty       string             `db:"specialty" json:"specialty"`
+	DefaultDuration int32              `db:"default_duration_minutes" json:"default_duration_minutes"` // used when a booking leaves out its length
+	PriceCents      int32              `db:"price_cents" json:"price_cents"`
+	IsActive        bool               `db:"is_active" json:"is_active"` // false => no longer bookable
+	CreatedAt       pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+// LessonTypeUpdates is used for both creating and updating lesson types.
+type LessonTypeUpdates struct {
+	Name            *string `json:"name"`
+	Specialty       *string `json:"specialty"`
+	DefaultDuration *int32  `json:"default_duration_minutes"`
+	PriceCents      *int32  `json:"price_cents"`
+	IsActive        *bool   `json:"is_active"`
+}
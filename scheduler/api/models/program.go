@@ -0,0 +1,89 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type ProgramEnrollmentStatus string
+
+const (
+	ProgramEnrollmentStatusEnrolled  ProgramEnrollmentStatus = "enrolled"
+	ProgramEnrollmentStatusWithdrawn ProgramEnrollmentStatus = "withdrawn"
+	ProgramEnrollmentStatusRefunded  ProgramEnrollmentStatus = "refunded"
+)
+
+// Program is a multi-day camp, each day is a camp session holding the
+// program's tunnels and coaches. Athletes enroll in the whole program.
+type Program struct {
+	Id            pgtype.UUID        `db:"id" json:"id"`
+	Name          string             `db:"name" json:"name"` // e.g. "Winter Hitting Camp"
+	Description   *string            `db:"description" json:"description"`
+	PriceCents    int32              `db:"price_cents" json:"price_cents"` // per athlete for the whole program
+	MaxEnrollment int32              `db:"max_enrollment" json:"max_enrollment"`
+	MinAge        *int32             `db:"min_age" json:"min_age"` // on the first day
+	MaxAge        *int32             `db:"max_age" json:"max_age"`
+	CancelledAt   pgtype.Timestamptz `db:"cancelled_at" json:"cancelled_at"`
+	CancelReason  *string            `db:"cancel_reason" json:"cancel_reason"`
+	CreatedAt     pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+
+	// worked out when loading
+	StartsAt      pgtype.Timestamptz `db:"starts_at" json:"starts_at"`
+	EndsAt        pgtype.Timestamptz `db:"ends_at" json:"ends_at"`
+	EnrolledCount int32              `db:"enrolled_count" json:"enrolled_count"`
+	SpotsLeft     int32              `db:"spots_left" json:"spots_left"`
+}
+
+// ProgramDay is when one day of a program runs.
+type ProgramDay struct {
+	StartTime pgtype.Timestamptz `json:"start_time"`
+	EndTime   pgtype.Timestamptz `json:"end_time"`
+}
+
+// ProgramStaff is a tunnel the program uses every day and the coach running
+// it, if any.
+type ProgramStaff struct {
+	TunnelId int32        `json:"tunnel_id"`
+	CoachId  *pgtype.UUID `json:"coach_id"`
+}
+
+// ProgramRequest creates a program and holds Staff on every one of Days.
+type ProgramRequest struct {
+	Name           string         `json:"name"`
+	Description    *string        `json:"description"`
+	PriceCents     int32          `json:"price_cents"`
+	MaxEnrollment  int32          `json:"max_enrollment"`
+	MinAge         *int32         `json:"min_age"`
+	MaxAge         *int32         `json:"max_age"`
+	Days           []ProgramDay   `json:"days"`
+	Staff          []ProgramStaff `json:"staff"`
+	OverrideHours  bool           `json:"override_hours"`
+	OverrideReason *string        `json:"override_reason"`
+	OverrideBy     *string        `json:"override_by"`
+}
+
+// ProgramCancelResult is what cancelling a program undid.
+type ProgramCancelResult struct {
+	Reservations []Reservation       `json:"reservations"` // the holds that were cancelled
+	Refunds      []ProgramEnrollment `json:"refunds"`
+}
+
+type ProgramResult struct {
+	Program      Program       `json:"program"`
+	Sessions     []Session     `json:"sessions"`     // one per day
+	Reservations []Reservation `json:"reservations"` // the tunnel and coach holds of every day
+}
+
+type ProgramEnrollment struct {
+	Id               pgtype.UUID             `db:"id" json:"id"`
+	ProgramId        pgtype.UUID             `db:"program_id" json:"program_id"`
+	AthleteFirstName string                  `db:"athlete_first_name" json:"athlete_first_name"`
+	AthleteLastName  string                  `db:"athlete_last_name" json:"athlete_last_name"`
+	AthleteBirthDate pgtype.Date             `db:"athlete_birth_date" json:"athlete_birth_date"`
+	ContactPhone     string                  `db:"contact_phone" json:"contact_phone"`
+	ContactEmail     *string                 `db:"contact_email" json:"contact_email"`
+	Status           ProgramEnrollmentStatus `db:"status" json:"status"`
+	RefundCents      *int32                  `db:"refund_cents" json:"refund_cents"`
+	Notes            *string                 `db:"notes" json:"notes"`
+	EnrolledAt       pgtype.Timestamptz      `db:"enrolled_at" json:"enrolled_at"`
+	WithdrawnAt      pgtype.Timestamptz      `db:"withdrawn_at" json:"withdrawn_at"`
+	UpdatedAt        pgtype.Timestamptz      `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,227 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type RecurrenceFrequency string
+
+const (
+	RecurrenceDaily  RecurrenceFrequency = "DAILY"
+	RecurrenceWeekly RecurrenceFrequency = "WEEKLY"
+)
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// RecurrenceRule is the subset of an RFC 5545 RRULE the scheduler supports:
+// FREQ=DAILY|WEEKLY with INTERVAL, BYDAY (plain weekdays), and exactly one
+// of COUNT or UNTIL so a series always ends.
+type RecurrenceRule struct {
+	Freq     RecurrenceFrequency
+	Interval int
+	Count    int
+	Until    time.Time // zero when Count is used
+	ByDay    []time.Weekday
+}
+
+// ParseRecurrenceRule parses e.g. "FREQ=WEEKLY;BYDAY=TU;UNTIL=20250826".
+// A date-only or floating UNTIL is read as wall-clock time in loc, a date
+// includes the whole day.
+func ParseRecurrenceRule(s string, loc *time.Location) (RecurrenceRule, error) {
+	rule := RecurrenceRule{Interval: 1}
+
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return rule, fmt.Errorf("rrule is empty")
+	}
+
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return rule, fmt.Errorf("invalid rrule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = RecurrenceFrequency(strings.ToUpper(value))
+			if rule.Freq != RecurrenceDaily && rule.Freq != RecurrenceWeekly {
+				return rule, fmt.Errorf("unsupported FREQ %q, expected DAILY or WEEKLY", value)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return rule, fmt.Errorf("INTERVAL must be a positive number")
+			}
+			rule.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return rule, fmt.Errorf("COUNT must be a positive number")
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := parseRRuleUntil(value, loc)
+			if err != nil {
+				return rule, err
+			}
+			rule.Until = until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := rruleWeekdays[strings.ToUpper(day)]
+				if !ok {
+					return rule, fmt.Errorf("unsupported BYDAY value %q", day)
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "WKST":
+			// weeks always start on Monday, the RFC default
+			if strings.ToUpper(value) != "MO" {
+				return rule, fmt.Errorf("only WKST=MO is supported")
+			}
+		default:
+			return rule, fmt.Errorf("unsupported rrule part %q", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return rule, fmt.Errorf("FREQ is required")
+	}
+
+	if (rule.Count == 0) == rule.Until.IsZero() {
+		return rule, fmt.Errorf("exactly one of COUNT or UNTIL is required")
+	}
+
+	return rule, nil
+}
+
+func parseRRuleUntil(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.ParseInLocation("20060102", value, loc); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q, expected YYYYMMDD or YYYYMMDDTHHMMSSZ", value)
+}
+
+// Occurrences expands the rule from dtstart, keeping dtstart's wall-clock
+// time in loc on every date so a 6 PM series stays at 6 PM across DST.
+// COUNT applies before exdates are removed, as in RFC 5545. dtstart is only
+// an occurrence when it matches BYDAY. It fails once more than limit
+// occurrences would be produced.
+func (r RecurrenceRule) Occurrences(dtstart time.Time, loc *time.Location, exdates []pgtype.Date, limit int) ([]time.Time, error) {
+	local := dtstart.In(loc)
+	year, month, day := local.Date()
+	hour, minute, second := local.Clock()
+
+	byDay := r.ByDay
+	if len(byDay) == 0 {
+		byDay = []time.Weekday{local.Weekday()}
+	}
+
+	// iterate weekdays in Monday-first order
+	byDay = append([]time.Weekday(nil), byDay...)
+	sort.Slice(byDay, func(i, j int) bool {
+		return (byDay[i]+6)%7 < (byDay[j]+6)%7
+	})
+
+	excluded := make(map[string]bool, len(exdates))
+	for _, exdate := range exdates {
+		excluded[exdate.Time.Format(DateLayout)] = true
+	}
+
+	occurrences := make([]time.Time, 0)
+	generated := 0
+
+	// emit reports whether expansion should continue
+	emit := func(offsetDays int) (bool, error) {
+		candidate := time.Date(year, month, day+offsetDays, hour, minute, second, 0, loc)
+
+		if !r.Until.IsZero() && candidate.After(r.Until) {
+			return false, nil
+		}
+
+		generated++
+
+		if !excluded[LocalDate(candidate, loc).Time.Format(DateLayout)] {
+			if len(occurrences) == limit {
+				return false, fmt.Errorf("rrule expands to more than %d occurrences", limit)
+			}
+			occurrences = append(occurrences, candidate)
+		}
+
+		return r.Count == 0 || generated < r.Count, nil
+	}
+
+	switch r.Freq {
+	case RecurrenceDaily:
+		skipped := 0
+
+		for offset := 0; ; offset += r.Interval {
+			candidate := time.Date(year, month, day+offset, 0, 0, 0, 0, loc)
+			if len(r.ByDay) > 0 && !containsWeekday(r.ByDay, candidate.Weekday()) {
+				// the weekdays repeat within 7 steps, past that BYDAY can never match
+				skipped++
+				if skipped > 7 || (!r.Until.IsZero() && candidate.After(r.Until)) {
+					return occurrences, nil
+				}
+				continue
+			}
+			skipped = 0
+
+			more, err := emit(offset)
+			if err != nil || !more {
+				return occurrences, err
+			}
+		}
+	case RecurrenceWeekly:
+		// offset of dtstart from the Monday of its week
+		startOffset := (int(local.Weekday()) + 6) % 7
+
+		for week := 0; ; week += r.Interval {
+			for _, weekday := range byDay {
+				offset := week*7 + (int(weekday)+6)%7 - startOffset
+				if offset < 0 {
+					continue
+				}
+
+				more, err := emit(offset)
+				if err != nil || !more {
+					return occurrences, err
+				}
+			}
+		}
+	}
+
+	return occurrences, nil
+}
+
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+
+	return false
+}
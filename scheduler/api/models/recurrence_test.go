@@ -0,0 +1,113 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func Test_ParseRecurrenceRule(t *testing.T) {
+	// exercise
+	rule, err := ParseRecurrenceRule("RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH;COUNT=6", time.UTC)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if rule.Freq != RecurrenceWeekly || rule.Interval != 2 || rule.Count != 6 || len(rule.ByDay) != 2 {
+		t.Fatal("unexpected rule:", rule)
+	}
+}
+
+func Test_ParseRecurrenceRule_Invalid(t *testing.T) {
+	cases := []string{
+		"",
+		"FREQ=MONTHLY;COUNT=3",
+		"FREQ=WEEKLY",
+		"FREQ=WEEKLY;COUNT=3;UNTIL=20250901",
+		"FREQ=WEEKLY;BYDAY=1TU;COUNT=3",
+		"FREQ=WEEKLY;BYSETPOS=1;COUNT=3",
+		"FREQ=DAILY;INTERVAL=0;COUNT=3",
+	}
+
+	for _, rrule := range cases {
+		// exercise
+		_, err := ParseRecurrenceRule(rrule, time.UTC)
+
+		// verify
+		if err == nil {
+			t.Fatal("expected an error for", rrule)
+		}
+	}
+}
+
+func Test_RecurrenceRule_Occurrences_WeeklyAcrossDST(t *testing.T) {
+	// setup
+	loc, err := time.LoadLocation("America/Indiana/Indianapolis")
+	if err != nil {
+		t.Fatal("unexpected error loading location:", err)
+	}
+
+	// Tuesdays at 6 PM, DST ends Nov 2 2025
+	rule, err := ParseRecurrenceRule("FREQ=WEEKLY;BYDAY=TU;UNTIL=20251118", loc)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	dtstart := time.Date(2025, 10, 21, 18, 0, 0, 0, loc)
+	exdate := pgtype.Date{Time: time.Date(2025, 11, 4, 0, 0, 0, 0, time.UTC), Valid: true}
+
+	// exercise
+	occurrences, err := rule.Occurrences(dtstart, loc, []pgtype.Date{exdate}, 100)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	expected := []string{"2025-10-21", "2025-10-28", "2025-11-11", "2025-11-18"}
+	if len(occurrences) != len(expected) {
+		t.Fatal("expected", len(expected), "occurrences, got", occurrences)
+	}
+
+	for i, occurrence := range occurrences {
+		local := occurrence.In(loc)
+		if local.Format(DateLayout) != expected[i] || local.Hour() != 18 {
+			t.Fatal("expected", expected[i], "at 18:00, got", local)
+		}
+	}
+}
+
+func Test_RecurrenceRule_Occurrences_CountIncludesExdates(t *testing.T) {
+	// setup
+	rule, _ := ParseRecurrenceRule("FREQ=DAILY;COUNT=3", time.UTC)
+	dtstart := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	exdate := pgtype.Date{Time: time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC), Valid: true}
+
+	// exercise
+	occurrences, err := rule.Occurrences(dtstart, time.UTC, []pgtype.Date{exdate}, 100)
+
+	// verify
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(occurrences) != 2 || occurrences[1].Day() != 4 {
+		t.Fatal("expected June 2 and June 4, got", occurrences)
+	}
+}
+
+func Test_RecurrenceRule_Occurrences_Limit(t *testing.T) {
+	// setup
+	rule, _ := ParseRecurrenceRule("FREQ=DAILY;COUNT=500", time.UTC)
+
+	// exercise
+	_, err := rule.Occurrences(time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC), time.UTC, nil, 200)
+
+	// verify
+	if err == nil {
+		t.Fatal("expected an error past the limit")
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RescheduleRequest moves a reservation to a new time. EndTime defaults to
+// keeping the current duration, the tunnel and coach stay the same unless
+// given.
+type RescheduleRequest struct {
+	StartTime      pgtype.Timestamptz  `json:"start_time"`
+	EndTime        *pgtype.Timestamptz `json:"end_time"`
+	TunnelId       *int32              `json:"tunnel_id"`
+	CoachId        *pgtype.UUID        `json:"coach_id"`
+	OverrideHours  bool                `json:"override_hours"` // skips the hours/blackout checks
+	OverrideReason *string             `json:"override_reason"`
+	OverrideBy     *string             `json:"override_by"`
+}
+
+type AlternativeKind string
+
+const (
+	AlternativeSameTunnel  AlternativeKind = "same_tunnel"  // same tunnel and coach, another time
+	AlternativeOtherTunnel AlternativeKind = "other_tunnel" // another tunnel at the requested time
+	AlternativeOtherCoach  AlternativeKind = "other_coach"  // a coach with the same specialty at the requested time
+)
+
+// AlternativeSlot is a bookable slot offered when a reschedule conflicts.
+// Times are sent in the facility's timezone.
+type AlternativeSlot struct {
+	Rank          int             `json:"rank"`
+	Kind          AlternativeKind `json:"kind"`
+	TunnelId      int32           `json:"tunnel_id"`
+	CoachId       *pgtype.UUID    `json:"coach_id"`
+	StartTime     time.Time       `json:"start_time"`
+	EndTime       time.Time       `json:"end_time"`
+	OffsetMinutes int32           `json:"offset_minutes"` // from the requested start
+}
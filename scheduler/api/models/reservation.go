@@ -7,8 +7,9 @@ import (
 type ReservationKind string
 
 const (
-	ReservationKindTunnel ReservationKind = "tunnel"
-	ReservationKindLesson ReservationKind = "lesson"
+	ReservationKindTunnel  ReservationKind = "tunnel"
+	ReservationKindLesson  ReservationKind = "lesson"
+	ReservationKindSession ReservationKind = "session" // a tunnel block of a clinic or drop-in
 )
 
 type ReservationStatus string
@@ -21,35 +22,98 @@ const (
 	ReservationStatusNoShow    ReservationStatus = "no_show"
 )
 
+// reservationStatusTransitions lists the statuses each status may move to.
+// cancelled, completed and no_show are final.
+var reservationStatusTransitions = map[ReservationStatus][]ReservationStatus{
+	ReservationStatusHeld:      {ReservationStatusConfirmed, ReservationStatusCancelled},
+	ReservationStatusConfirmed: {ReservationStatusCompleted, ReservationStatusNoShow, ReservationStatusCancelled},
+}
+
+func (s ReservationStatus) CanTransitionTo(next ReservationStatus) bool {
+	for _, allowed := range reservationStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BookingChannel is where a reservation was made, it decides how long a hold lasts.
+type BookingChannel string
+
+const (
+	BookingChannelWeb   BookingChannel = "web"
+	BookingChannelPhone BookingChannel = "phone"
+)
+
 type Reservation struct {
 	Id                pgtype.UUID        `db:"id" json:"id"`
 	Kind              ReservationKind    `db:"reservation_kind" json:"reservation_kind"`
 	TunnelId          *int32             `db:"tunnel_id" json:"tunnel_id"`
 	CoachId           *pgtype.UUID       `db:"coach_id" json:"coach_id"`
+	LessonTypeId      *pgtype.UUID       `db:"lesson_type_id" json:"lesson_type_id"` // required for new lessons
+	CustomerId        *pgtype.UUID       `db:"customer_id" json:"customer_id"`       // nil => not linked to a customer yet
+	AthleteId         *pgtype.UUID       `db:"athlete_id" json:"athlete_id"`         // the customer's athlete who is coming
 	CustomerFirstName string             `db:"customer_first_name" json:"customer_first_name"`
 	CustomerLastName  string             `db:"customer_last_name" json:"customer_last_name"`
 	CustomerPhone     string             `db:"customer_phone" json:"customer_phone"`
 	CustomerEmail     *string            `db:"customer_email" json:"customer_email"`
+	CustomerTier      *string            `db:"customer_tier" json:"customer_tier"` // the customer's tier, only an override may set another
 	StartTime         pgtype.Timestamptz `db:"start_time" json:"start_time"`
 	Duration          int32              `db:"duration_minutes" json:"duration_minutes"`
 	EndTime           pgtype.Timestamptz `db:"end_time" json:"end_time"`
 	Status            ReservationStatus  `db:"status" json:"status"`
 	Notes             *string            `db:"notes" json:"notes"`
+	OverrideReason    *string            `db:"override_reason" json:"override_reason"`
+	OverrideBy        *string            `db:"override_by" json:"override_by"`    // who overrode the checks, required with override_hours
+	OverrideHours     bool               `db:"-" json:"override_hours,omitempty"` // request only, skips the hours/blackout checks
+	BookingChannel    BookingChannel     `db:"booking_channel" json:"booking_channel"`
+	HoldExpiresAt     pgtype.Timestamptz `db:"hold_expires_at" json:"hold_expires_at"` // only set while the reservation is held
+	SeriesId          *pgtype.UUID       `db:"series_id" json:"series_id"`
+	GroupId           *pgtype.UUID       `db:"group_id" json:"group_id"`
+	SessionId         *pgtype.UUID       `db:"session_id" json:"session_id"`                   // set on the tunnel blocks of a session
+	TunnelTurnoverEnd pgtype.Timestamptz `db:"tunnel_turnover_end" json:"tunnel_turnover_end"` // set by the db from the tunnel's buffer
+	CoachTurnoverEnd  pgtype.Timestamptz `db:"coach_turnover_end" json:"coach_turnover_end"`   // set by the db from the coach's buffer
 	CreatedAt         pgtype.Timestamptz `db:"created_at" json:"created_at"`
 	UpdatedAt         pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	CoachAssignment   *CoachAssignment   `db:"-" json:"coach_assignment,omitempty"` // response only, set when the server picked the coach
 }
 
 type ReservationUpdates struct {
 	Kind              *ReservationKind    `db:"reservation_kind" json:"reservation_kind"`
 	TunnelId          *int32              `db:"tunnel_id" json:"tunnel_id"`
 	CoachId           *pgtype.UUID        `db:"coach_id" json:"coach_id"`
+	LessonTypeId      *pgtype.UUID        `db:"lesson_type_id" json:"lesson_type_id"`
+	CustomerId        *pgtype.UUID        `db:"customer_id" json:"customer_id"`
+	AthleteId         *pgtype.UUID        `db:"athlete_id" json:"athlete_id"`
 	CustomerFirstName *string             `db:"customer_first_name" json:"customer_first_name"`
 	CustomerLastName  *string             `db:"customer_last_name" json:"customer_last_name"`
 	CustomerPhone     *string             `db:"customer_phone" json:"customer_phone"`
 	CustomerEmail     *string             `db:"customer_email" json:"customer_email"`
+	CustomerTier      *string             `db:"customer_tier" json:"customer_tier"`
 	StartTime         *pgtype.Timestamptz `db:"start_time" json:"start_time"`
 	Duration          *int32              `db:"duration_minutes" json:"duration_minutes"`
 	EndTime           *pgtype.Timestamptz `db:"end_time" json:"end_time"`
 	Status            *ReservationStatus  `db:"status" json:"status"`
 	Notes             *string             `db:"notes" json:"notes"`
+	OverrideReason    *string             `db:"override_reason" json:"override_reason"`
+	OverrideBy        *string             `db:"override_by" json:"override_by"`
+	OverrideHours     bool                `db:"-" json:"override_hours"` // skips the hours/blackout checks
+}
+
+type ReservationStatusChange struct {
+	Id            pgtype.UUID        `db:"id" json:"id"`
+	ReservationId pgtype.UUID        `db:"reservation_id" json:"reservation_id"`
+	FromStatus    ReservationStatus  `db:"from_status" json:"from_status"`
+	ToStatus      ReservationStatus  `db:"to_status" json:"to_status"`
+	ChangedBy     *string            `db:"changed_by" json:"changed_by"`
+	Reason        *string            `db:"reason" json:"reason"`
+	ChangedAt     pgtype.Timestamptz `db:"changed_at" json:"changed_at"`
+}
+
+// ReservationStatusChangeRequest is the optional body of the transition endpoints.
+type ReservationStatusChangeRequest struct {
+	ChangedBy *string `json:"changed_by"`
+	Reason    *string `json:"reason"`
 }
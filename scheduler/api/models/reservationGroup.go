@@ -0,0 +1,49 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type ReservationGroup struct {
+	Id                pgtype.UUID        `db:"id" json:"id"`
+	Name              string             `db:"name" json:"name"` // e.g. "Indy Hawks 14U"
+	CustomerFirstName string             `db:"customer_first_name" json:"customer_first_name"`
+	CustomerLastName  string             `db:"customer_last_name" json:"customer_last_name"`
+	CustomerPhone     string             `db:"customer_phone" json:"customer_phone"`
+	CustomerEmail     *string            `db:"customer_email" json:"customer_email"`
+	Notes             *string            `db:"notes" json:"notes"`
+	CreatedAt         pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+// ReservationGroupRequest books the same time on every tunnel in TunnelIds.
+type ReservationGroupRequest struct {
+	Name              string             `json:"name"`
+	TunnelIds         []int32            `json:"tunnel_ids"`
+	CustomerFirstName string             `json:"customer_first_name"`
+	CustomerLastName  string             `json:"customer_last_name"`
+	CustomerPhone     string             `json:"customer_phone"`
+	CustomerEmail     *string            `json:"customer_email"`
+	StartTime         pgtype.Timestamptz `json:"start_time"`
+	EndTime           pgtype.Timestamptz `json:"end_time"`
+	Status            ReservationStatus  `json:"status"`
+	BookingChannel    BookingChannel     `json:"booking_channel"`
+	Notes             *string            `json:"notes"`
+	OverrideHours     bool               `json:"override_hours"`
+	OverrideReason    *string            `json:"override_reason"`
+	OverrideBy        *string            `json:"override_by"`
+	CustomerId        *pgtype.UUID       `json:"customer_id"`
+	CustomerTier      *string            `json:"-"` // set from the customer
+}
+
+// ReservationGroupMove moves every active member to a new time together.
+type ReservationGroupMove struct {
+	StartTime      pgtype.Timestamptz `json:"start_time"`
+	EndTime        pgtype.Timestamptz `json:"end_time"`
+	OverrideHours  bool               `json:"override_hours"`
+	OverrideReason *string            `json:"override_reason"`
+	OverrideBy     *string            `json:"override_by"`
+}
+
+type ReservationGroupResult struct {
+	Group        ReservationGroup `json:"group"`
+	Reservations []Reservation    `json:"reservations"`
+}
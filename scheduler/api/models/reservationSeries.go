@@ -0,0 +1,81 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type ReservationSeries struct {
+	Id                pgtype.UUID        `db:"id" json:"id"`
+	Kind              ReservationKind    `db:"reservation_kind" json:"reservation_kind"`
+	TunnelId          *int32             `db:"tunnel_id" json:"tunnel_id"`
+	CoachId           *pgtype.UUID       `db:"coach_id" json:"coach_id"`
+	LessonTypeId      *pgtype.UUID       `db:"lesson_type_id" json:"lesson_type_id"` // required for new lesson series
+	CustomerFirstName string             `db:"customer_first_name" json:"customer_first_name"`
+	CustomerLastName  string             `db:"customer_last_name" json:"customer_last_name"`
+	CustomerPhone     string             `db:"customer_phone" json:"customer_phone"`
+	CustomerEmail     *string            `db:"customer_email" json:"customer_email"`
+	StartTime         pgtype.Timestamptz `db:"start_time" json:"start_time"` // first occurrence
+	Duration          int32              `db:"duration_minutes" json:"duration_minutes"`
+	RRule             string             `db:"rrule" json:"rrule"`     // RFC 5545, e.g. FREQ=WEEKLY;BYDAY=TU;COUNT=12
+	ExDates           []pgtype.Date      `db:"exdates" json:"exdates"` // local dates skipped by the series
+	Notes             *string            `db:"notes" json:"notes"`
+	CreatedAt         pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+
+	// request only, applied to every occurrence
+	Status         ReservationStatus `db:"-" json:"status,omitempty"`
+	BookingChannel BookingChannel    `db:"-" json:"booking_channel,omitempty"`
+	OverrideHours  bool              `db:"-" json:"override_hours,omitempty"`
+	OverrideReason *string           `db:"-" json:"override_reason,omitempty"`
+	OverrideBy     *string           `db:"-" json:"override_by,omitempty"`
+	SkipConflicts  bool              `db:"-" json:"skip_conflicts,omitempty"` // book the free dates, leave out the conflicting ones
+	CustomerId     *pgtype.UUID      `db:"-" json:"customer_id,omitempty"`
+	AthleteId      *pgtype.UUID      `db:"-" json:"athlete_id,omitempty"`
+	CustomerTier   *string           `db:"-" json:"-"` // set from the customer
+}
+
+// SeriesConflict is one reason an occurrence cannot be booked.
+type SeriesConflict struct {
+	Rule          string       `json:"rule"`
+	Message       string       `json:"message"`
+	ReservationId *pgtype.UUID `json:"reservation_id,omitempty"` // the booking in the way, if any
+}
+
+type SeriesOccurrence struct {
+	StartTime     pgtype.Timestamptz `json:"start_time"`
+	EndTime       pgtype.Timestamptz `json:"end_time"`
+	Conflicts     []SeriesConflict   `json:"conflicts"`
+	ReservationId *pgtype.UUID       `json:"reservation_id,omitempty"` // set once booked
+}
+
+// ReservationSeriesResult is the expanded series, Series is nil on a dry run.
+type ReservationSeriesResult struct {
+	Series       *ReservationSeries `json:"series"`
+	Occurrences  []SeriesOccurrence `json:"occurrences"`
+	Reservations []Reservation      `json:"reservations"`
+}
+
+// SeriesScope picks which occurrences an edit or cancel applies to.
+type SeriesScope string
+
+const (
+	SeriesScopeThis      SeriesScope = "this"
+	SeriesScopeFollowing SeriesScope = "following"
+	SeriesScopeAll       SeriesScope = "all"
+)
+
+// SeriesUpdates edits one or more occurrences. A new start_time moves the
+// chosen occurrence and shifts the others in scope by the same number of
+// days to the same wall-clock time.
+type SeriesUpdates struct {
+	TunnelId          *int32              `json:"tunnel_id"`
+	CoachId           *pgtype.UUID        `json:"coach_id"`
+	CustomerFirstName *string             `json:"customer_first_name"`
+	CustomerLastName  *string             `json:"customer_last_name"`
+	CustomerPhone     *string             `json:"customer_phone"`
+	CustomerEmail     *string             `json:"customer_email"`
+	StartTime         *pgtype.Timestamptz `json:"start_time"`
+	Duration          *int32              `json:"duration_minutes"`
+	Notes             *string             `json:"notes"`
+	OverrideHours     bool                `json:"override_hours"`
+	OverrideReason    *string             `json:"override_reason"`
+	OverrideBy        *string             `json:"override_by"`
+}
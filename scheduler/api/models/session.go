@@ -0,0 +1,68 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type SessionKind string
+
+const (
+	SessionKindClinic SessionKind = "clinic"
+	SessionKindDropIn SessionKind = "drop_in"
+	SessionKindCamp   SessionKind = "camp" // a day of a program, created with the program
+)
+
+type EnrollmentStatus string
+
+const (
+	EnrollmentStatusEnrolled  EnrollmentStatus = "enrolled"
+	EnrollmentStatusWithdrawn EnrollmentStatus = "withdrawn"
+)
+
+// Session is a clinic or drop-in with room for several participants, it
+// blocks every tunnel it runs on with a 'session' reservation.
+type Session struct {
+	Id              pgtype.UUID        `db:"id" json:"id"`
+	Kind            SessionKind        `db:"session_kind" json:"session_kind"`
+	Name            string             `db:"name" json:"name"`         // e.g. "10U Pitching Clinic"
+	CoachId         *pgtype.UUID       `db:"coach_id" json:"coach_id"` // required for clinics
+	StartTime       pgtype.Timestamptz `db:"start_time" json:"start_time"`
+	EndTime         pgtype.Timestamptz `db:"end_time" json:"end_time"`
+	MaxParticipants int32              `db:"max_participants" json:"max_participants"`
+	PriceCents      int32              `db:"price_cents" json:"price_cents"` // per participant
+	Notes           *string            `db:"notes" json:"notes"`
+	ProgramId       *pgtype.UUID       `db:"program_id" json:"program_id"` // set on camp days
+	CancelledAt     pgtype.Timestamptz `db:"cancelled_at" json:"cancelled_at"`
+	CreatedAt       pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+
+	// worked out when loading, enrolled can exceed max_participants when staff overbooked
+	EnrolledCount int32 `db:"enrolled_count" json:"enrolled_count"`
+	SpotsLeft     int32 `db:"spots_left" json:"spots_left"`
+
+	// request only
+	TunnelIds      []int32 `db:"-" json:"tunnel_ids,omitempty"`
+	OverrideHours  bool    `db:"-" json:"override_hours,omitempty"`
+	OverrideReason *string `db:"-" json:"override_reason,omitempty"`
+	OverrideBy     *string `db:"-" json:"override_by,omitempty"`
+}
+
+type SessionResult struct {
+	Session      Session       `json:"session"`
+	Reservations []Reservation `json:"reservations"` // the tunnel blocks
+}
+
+type SessionEnrollment struct {
+	Id                   pgtype.UUID        `db:"id" json:"id"`
+	SessionId            pgtype.UUID        `db:"session_id" json:"session_id"`
+	ParticipantFirstName string             `db:"participant_first_name" json:"participant_first_name"`
+	ParticipantLastName  string             `db:"participant_last_name" json:"participant_last_name"`
+	ParticipantPhone     string             `db:"participant_phone" json:"participant_phone"`
+	ParticipantEmail     *string            `db:"participant_email" json:"participant_email"`
+	Status               EnrollmentStatus   `db:"status" json:"status"`
+	Overbooked           bool               `db:"overbooked" json:"overbooked"` // enrolled past max_participants
+	Notes                *string            `db:"notes" json:"notes"`
+	EnrolledAt           pgtype.Timestamptz `db:"enrolled_at" json:"enrolled_at"`
+	WithdrawnAt          pgtype.Timestamptz `db:"withdrawn_at" json:"withdrawn_at"`
+	UpdatedAt            pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+
+	AllowOverbook bool `db:"-" json:"allow_overbook,omitempty"` // request only, staff letting someone into a full session
+}
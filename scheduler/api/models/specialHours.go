@@ -0,0 +1,39 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type SpecialHours struct {
+	Id        pgtype.UUID `db:"id" json:"id"`
+	OnDate    pgtype.Date `db:"on_date" json:"on_date"` // local calendar date
+	OpenTime  TimeOfDay   `db:"open_time" json:"open_time"`
+	CloseTime TimeOfDay   `db:"close_time" json:"close_time"`
+	IsOpen    bool        `db:"is_open" json:"is_open"` // false => closed all day
+	Notes     *string     `db:"notes" json:"notes"`
+}
+
+// SpecialHoursUpdates is used for both creating and updating special hours.
+// Dates and times stay raw strings so bad input is reported per field.
+type SpecialHoursUpdates struct {
+	OnDate    *string `json:"on_date"`
+	OpenTime  *string `json:"open_time"`
+	CloseTime *string `json:"close_time"`
+	IsOpen    *bool   `json:"is_open"`
+	Notes     *string `json:"notes"`
+}
+
+const (
+	HoursSourceSpecial  string = "special_hours"
+	HoursSourceBusiness string = "business_hours"
+	HoursSourceNone     string = "none"
+)
+
+// EffectiveHours are the hours that actually apply on a calendar date once
+// special_hours have been layered over the weekly business_hours.
+type EffectiveHours struct {
+	Date      pgtype.Date `json:"date"`
+	OpenTime  TimeOfDay   `json:"open_time"`
+	CloseTime TimeOfDay   `json:"close_time"`
+	IsOpen    bool        `json:"is_open"`
+	Source    string      `json:"source"`
+	Notes     *string     `json:"notes"`
+}
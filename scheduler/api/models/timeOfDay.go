@@ -0,0 +1,88 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const microsecondsPerMinute = int64(time.Minute / time.Microsecond)
+
+// TimeOfDay is a local wall-clock time backed by a postgres TIME column.
+// It is sent over the api as "HH:MM".
+type TimeOfDay struct {
+	pgtype.Time
+}
+
+func NewTimeOfDay(hour, minute int) TimeOfDay {
+	return TimeOfDay{pgtype.Time{
+		Microseconds: int64(hour*60+minute) * microsecondsPerMinute,
+		Valid:        true,
+	}}
+}
+
+func ParseTimeOfDay(s string) (TimeOfDay, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			tod := NewTimeOfDay(t.Hour(), t.Minute())
+			tod.Microseconds += int64(t.Second()) * int64(time.Second/time.Microsecond)
+			return tod, nil
+		}
+	}
+
+	return TimeOfDay{}, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+}
+
+// Minutes returns the number of whole minutes since midnight.
+func (t TimeOfDay) Minutes() int {
+	return int(t.Microseconds / microsecondsPerMinute)
+}
+
+// On places the wall-clock time on the given calendar date in loc. The
+// fields go through time.Date rather than adding an offset to midnight so
+// the result stays correct on DST transition days.
+func (t TimeOfDay) On(date pgtype.Date, loc *time.Location) time.Time {
+	seconds := int(t.Microseconds / int64(time.Second/time.Microsecond))
+	year, month, day := date.Time.Date()
+
+	return time.Date(year, month, day, seconds/3600, seconds/60%60, seconds%60, 0, loc)
+}
+
+func (t TimeOfDay) String() string {
+	if !t.Valid {
+		return ""
+	}
+
+	minutes := t.Minutes()
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+func (t TimeOfDay) MarshalJSON() ([]byte, error) {
+	if !t.Valid {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(t.String())
+}
+
+func (t *TimeOfDay) UnmarshalJSON(b []byte) error {
+	var s *string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	if s == nil {
+		*t = TimeOfDay{}
+		return nil
+	}
+
+	parsed, err := ParseTimeOfDay(*s)
+	if err != nil {
+		return err
+	}
+
+	*t = parsed
+	return nil
+}
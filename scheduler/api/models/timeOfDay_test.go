@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func Test_TimeOfDay_On_AcrossDST(t *testing.T) {
+	// setup
+	loc, err := time.LoadLocation("America/Indiana/Indianapolis")
+	if err != nil {
+		t.Fatal("unexpected error loading location:", err)
+	}
+
+	open := NewTimeOfDay(8, 30)
+
+	// DST starts Mar 9 2025 and ends Nov 2 2025, both days are 23 or 25 hours long
+	for _, day := range []time.Time{
+		time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 11, 2, 0, 0, 0, 0, time.UTC),
+	} {
+		// exercise
+		at := open.On(pgtype.Date{Time: day, Valid: true}, loc)
+
+		// verify
+		if at.Hour() != 8 || at.Minute() != 30 || at.Day() != day.Day() {
+			t.Fatal("expected 08:30 on", day.Format(time.DateOnly), "got", at)
+		}
+	}
+}
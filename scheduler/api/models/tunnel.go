@@ -1,7 +1,20 @@
 package models
 
+import "time"
+
 type Tunnel struct {
-	Id       int32  `json:"id"`
-	Name     string `json:"name"`
-	IsActive bool   `json:"is_active"`
+	Id                    int32  `json:"id"`
+	Name                  string `json:"name"`
+	IsActive              bool   `json:"is_active"`
+	TurnoverBufferMinutes int32  `json:"turnover_buffer_minutes"` // setup time after a booking before the next
+}
+
+type TunnelUpdates struct {
+	Name                  *string `db:"name" json:"name"`
+	IsActive              *bool   `db:"is_active" json:"is_active"`
+	TurnoverBufferMinutes *int32  `db:"turnover_buffer_minutes" json:"turnover_buffer_minutes"`
+}
+
+func (t Tunnel) TurnoverBuffer() time.Duration {
+	return time.Duration(t.TurnoverBufferMinutes) * time.Minute
 }
@@ -0,0 +1,39 @@
+package models
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type WaitlistStatus string
+
+const (
+	WaitlistStatusWaiting   WaitlistStatus = "waiting"
+	WaitlistStatusOffered   WaitlistStatus = "offered"   // a held reservation was created for them
+	WaitlistStatusFulfilled WaitlistStatus = "fulfilled" // the offered hold was confirmed
+	WaitlistStatusLapsed    WaitlistStatus = "lapsed"    // the offered hold expired or was cancelled
+	WaitlistStatusLeft      WaitlistStatus = "left"
+)
+
+type WaitlistEntry struct {
+	Id                   pgtype.UUID        `db:"id" json:"id"`
+	Kind                 ReservationKind    `db:"reservation_kind" json:"reservation_kind"`
+	TunnelId             *int32             `db:"tunnel_id" json:"tunnel_id"` // nil => any tunnel
+	CoachId              *pgtype.UUID       `db:"coach_id" json:"coach_id"`
+	LessonTypeId         *pgtype.UUID       `db:"lesson_type_id" json:"lesson_type_id"` // required for new lesson entries
+	CustomerFirstName    string             `db:"customer_first_name" json:"customer_first_name"`
+	CustomerLastName     string             `db:"customer_last_name" json:"customer_last_name"`
+	CustomerPhone        string             `db:"customer_phone" json:"customer_phone"`
+	CustomerEmail        *string            `db:"customer_email" json:"customer_email"`
+	WindowStart          pgtype.Timestamptz `db:"window_start" json:"window_start"`
+	WindowEnd            pgtype.Timestamptz `db:"window_end" json:"window_end"`
+	Duration             int32              `db:"duration_minutes" json:"duration_minutes"`
+	Status               WaitlistStatus     `db:"status" json:"status"`
+	OfferedReservationId *pgtype.UUID       `db:"offered_reservation_id" json:"offered_reservation_id"`
+	Notes                *string            `db:"notes" json:"notes"`
+	CreatedAt            pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt            pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+// WaitlistOffer is a freed slot handed to a waitlisted customer as a hold.
+type WaitlistOffer struct {
+	Entry       WaitlistEntry `json:"entry"`
+	Reservation Reservation   `json:"reservation"`
+}
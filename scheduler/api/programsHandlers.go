@@ -0,0 +1,230 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func getPrograms(c *gin.Context) {
+	programs, err := dbUtils.LoadProgramData(c.Request.Context(), pool)
+	if err != nil {
+		log.Println("[API] Error loading programs:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, programs)
+}
+
+func getProgramById(c *gin.Context) {
+	id := c.Param("id")
+
+	program, err := dbUtils.LoadProgramById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading program:", err)
+		respondError(c, err)
+		return
+	}
+
+	if program == nil {
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *program)
+}
+
+func createProgram(c *gin.Context) {
+	var request models.ProgramRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/programs.", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	err := dbUtils.CheckProgramSchedule(c.Request.Context(), pool, facilityLocation, request)
+	if respondValidationError(c, err) || respondRuleViolations(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error checking program schedule:", err)
+		respondError(c, err)
+		return
+	}
+
+	result, err := dbUtils.InsertProgram(c.Request.Context(), pool, request)
+	if respondValidationError(c, err) || respondRuleViolations(c, err) || respondProgramConflict(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error inserting program:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/programs/"+result.Program.Id.String())
+	c.JSON(http.StatusCreated, *result)
+}
+
+func cancelProgram(c *gin.Context) {
+	id := c.Param("id")
+
+	var change models.ReservationStatusChangeRequest
+
+	if err := c.ShouldBindJSON(&change); err != nil && !errors.Is(err, io.EOF) {
+		log.Println("[API] Error binding JSON on POST method at /api/programs/"+id+"/cancel", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	program, err := dbUtils.LoadProgramById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading program:", err)
+		respondError(c, err)
+		return
+	}
+
+	if program == nil {
+		respondNotFound(c)
+		return
+	}
+
+	result, err := dbUtils.CancelProgram(c.Request.Context(), pool, id, change)
+	if err != nil {
+		log.Println("[API] Error cancelling program:", err)
+		respondError(c, err)
+		return
+	}
+
+	offerFreedSlots(c.Request.Context(), result.Reservations)
+
+	c.JSON(http.StatusOK, *result)
+}
+
+func getProgramEnrollments(c *gin.Context) {
+	id := c.Param("id")
+
+	program, err := dbUtils.LoadProgramById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading program:", err)
+		respondError(c, err)
+		return
+	}
+
+	if program == nil {
+		respondNotFound(c)
+		return
+	}
+
+	enrollments, err := dbUtils.LoadProgramEnrollments(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading program enrollments:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollments)
+}
+
+func enrollInProgram(c *gin.Context) {
+	id := c.Param("id")
+
+	var enrollment models.ProgramEnrollment
+
+	if err := c.ShouldBindJSON(&enrollment); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/programs/"+id+"/enrollments", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	result, err := dbUtils.EnrollInProgram(c.Request.Context(), pool, facilityLocation, id, enrollment)
+	if respondValidationError(c, err) || respondRuleViolations(c, err) || respondProgramFull(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error enrolling in program:", err)
+		respondError(c, err)
+		return
+	}
+
+	if result == nil {
+		respondNotFound(c)
+		return
+	}
+
+	c.Header("Location", "/api/programs/"+id+"/enrollments/"+result.Id.String())
+	c.JSON(http.StatusCreated, *result)
+}
+
+// withdrawFromProgram takes an athlete out of the program, ?refund=true
+// refunds the full program price.
+func withdrawFromProgram(c *gin.Context) {
+	id := c.Param("id")
+	enrollmentId := c.Param("enrollmentId")
+
+	refund := false
+	if refundStr := c.Query("refund"); refundStr != "" {
+		parsed, err := strconv.ParseBool(refundStr)
+		if err != nil {
+			validationErr := &dbUtils.ValidationError{}
+			validationErr.Add("refund", "must be true or false")
+			respondValidationError(c, validationErr)
+			return
+		}
+
+		refund = parsed
+	}
+
+	enrollment, err := dbUtils.WithdrawFromProgram(c.Request.Context(), pool, id, enrollmentId, refund)
+	if err != nil {
+		log.Println("[API] Error withdrawing from program:", err)
+		respondError(c, err)
+		return
+	}
+
+	if enrollment == nil {
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *enrollment)
+}
+
+func respondProgramFull(c *gin.Context, err error) bool {
+	var fullErr *dbUtils.ProgramFullError
+	if !errors.As(err, &fullErr) {
+		return false
+	}
+
+	respondProblem(c, http.StatusConflict, "program_full", fullErr.Error(), gin.H{
+		"max_enrollment": fullErr.Program.MaxEnrollment,
+		"enrolled_count": fullErr.Program.EnrolledCount,
+	})
+	return true
+}
+
+func respondProgramConflict(c *gin.Context, err error) bool {
+	var conflictErr *dbUtils.ProgramConflictError
+	if !errors.As(err, &conflictErr) {
+		return false
+	}
+
+	respondProblem(c, http.StatusConflict, "program_conflict", conflictErr.Message, gin.H{
+		"day":        conflictErr.DayNumber,
+		"start_time": conflictErr.Day.StartTime,
+		"end_time":   conflictErr.Day.EndTime,
+		"tunnel_id":  conflictErr.TunnelId,
+	})
+	return true
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// rescheduleReservation moves a held or confirmed reservation in one step.
+// When the new time is taken the reservation stays put and the 409 lists
+// what is in the way along with ranked alternatives nearby.
+func rescheduleReservation(c *gin.Context) {
+	id := c.Param("id")
+
+	var request models.RescheduleRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/reservations/"+id+"/reschedule", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	existing, err := dbUtils.LoadReservationById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading reservation:", err)
+		respondError(c, err)
+		return
+	}
+
+	if existing == nil {
+		log.Println("[API] Cannot reschedule reservation because it does not exist with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	if respondSessionBlock(c, *existing) {
+		return
+	}
+
+	if !dbUtils.BlocksSchedule(existing.Status) {
+		respondNotReschedulable(c, existing.Status)
+		return
+	}
+
+	moved, err := dbUtils.PlanReschedule(*existing, request)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	// a lesson's new coach has to teach its lesson type
+	if moved.Kind == models.ReservationKindLesson && request.CoachId != nil && *request.CoachId != *existing.CoachId {
+		_, err = dbUtils.CheckLessonAssignment(c.Request.Context(), pool, moved.Kind, moved.LessonTypeId, moved.CoachId)
+		if respondValidationError(c, err) || respondRuleViolations(c, err) {
+			return
+		}
+
+		if err != nil {
+			log.Println("[API] Error checking lesson type:", err)
+			respondError(c, err)
+			return
+		}
+	}
+
+	err = dbUtils.EnforceReservationSchedule(c.Request.Context(), pool, facilityLocation, dbUtils.ScheduleCheck{
+		StartTime:      moved.StartTime,
+		EndTime:        moved.EndTime,
+		Kind:           moved.Kind,
+		CoachId:        moved.CoachId,
+		Override:       request.OverrideHours,
+		OverrideReason: request.OverrideReason,
+		OverrideBy:     request.OverrideBy,
+		BookingRules:   true,
+		CustomerTier:   moved.CustomerTier,
+		Now:            time.Now(),
+	})
+
+	if respondValidationError(c, err) || respondRuleViolations(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error checking reservation hours:", err)
+		respondError(c, err)
+		return
+	}
+
+	reservation, err := dbUtils.RescheduleReservation(c.Request.Context(), pool, moved)
+
+	var conflictErr *dbUtils.RescheduleConflictError
+	if errors.As(err, &conflictErr) {
+		alternatives, err := dbUtils.SuggestRescheduleAlternatives(c.Request.Context(), pool, facilityLocation, moved)
+		if err != nil {
+			// the conflict is still worth reporting without suggestions
+			log.Println("[API] Error finding reschedule alternatives:", err)
+			alternatives = make([]models.AlternativeSlot, 0)
+		}
+
+		respondProblem(c, http.StatusConflict, "reschedule_conflict", conflictErr.Error(), gin.H{
+			"blocking_reservations": conflictErr.Blocking,
+			"alternatives":          alternatives,
+		})
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error rescheduling reservation:", err)
+		respondError(c, err)
+		return
+	}
+
+	// cancelled or finished between loading and moving it
+	if reservation == nil {
+		respondProblem(c, http.StatusConflict, "not_reschedulable", "The reservation is no longer held or confirmed.", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, *reservation)
+}
+
+func respondNotReschedulable(c *gin.Context, status models.ReservationStatus) {
+	respondProblem(c, http.StatusConflict, "not_reschedulable", "Only held or confirmed reservations can be rescheduled.", gin.H{
+		"current_status": status,
+	})
+}
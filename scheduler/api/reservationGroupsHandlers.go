@@ -0,0 +1,227 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func createReservationGroup(c *gin.Context) {
+	var request models.ReservationGroupRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/reservation-groups.", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	if request.Status == "" {
+		request.Status = models.ReservationStatusHeld
+	}
+
+	if request.BookingChannel == "" {
+		request.BookingChannel = models.BookingChannelWeb
+	}
+
+	// link the group to its customer as for a single booking, every member gets it
+	customer := models.Reservation{
+		CustomerId:        request.CustomerId,
+		CustomerFirstName: request.CustomerFirstName,
+		CustomerLastName:  request.CustomerLastName,
+		CustomerPhone:     request.CustomerPhone,
+		CustomerEmail:     request.CustomerEmail,
+	}
+
+	err := dbUtils.ResolveReservationCustomer(c.Request.Context(), pool, &customer)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error resolving customer:", err)
+		respondError(c, err)
+		return
+	}
+
+	request.CustomerId, request.CustomerTier = customer.CustomerId, customer.CustomerTier
+	request.CustomerFirstName, request.CustomerLastName = customer.CustomerFirstName, customer.CustomerLastName
+	request.CustomerPhone, request.CustomerEmail = customer.CustomerPhone, customer.CustomerEmail
+
+	err = dbUtils.EnforceReservationSchedule(c.Request.Context(), pool, facilityLocation, dbUtils.ScheduleCheck{
+		StartTime:      request.StartTime,
+		EndTime:        request.EndTime,
+		Kind:           models.ReservationKindTunnel,
+		Override:       request.OverrideHours,
+		OverrideReason: request.OverrideReason,
+		OverrideBy:     request.OverrideBy,
+		BookingRules:   true,
+		CustomerTier:   request.CustomerTier,
+		Now:            time.Now(),
+	})
+
+	if respondValidationError(c, err) || respondRuleViolations(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error checking reservation hours:", err)
+		respondError(c, err)
+		return
+	}
+
+	holdExpiresAt := holdConfig.ExpiresAt(request.BookingChannel, time.Now())
+
+	result, err := dbUtils.InsertReservationGroup(c.Request.Context(), pool, request, holdExpiresAt)
+	if respondValidationError(c, err) || respondGroupConflict(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error inserting reservation group:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/reservation-groups/"+result.Group.Id.String())
+	c.JSON(http.StatusCreated, *result)
+}
+
+func getReservationGroupById(c *gin.Context) {
+	id := c.Param("id")
+
+	group, err := dbUtils.LoadReservationGroupById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading reservation group:", err)
+		respondError(c, err)
+		return
+	}
+
+	if group == nil {
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *group)
+}
+
+func cancelReservationGroup(c *gin.Context) {
+	id := c.Param("id")
+
+	var change models.ReservationStatusChangeRequest
+
+	if err := c.ShouldBindJSON(&change); err != nil && !errors.Is(err, io.EOF) {
+		log.Println("[API] Error binding JSON on POST method at /api/reservation-groups/"+id+"/cancel", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	group, err := dbUtils.LoadReservationGroupById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading reservation group:", err)
+		respondError(c, err)
+		return
+	}
+
+	if group == nil {
+		respondNotFound(c)
+		return
+	}
+
+	cancelled, err := dbUtils.CancelReservationGroup(c.Request.Context(), pool, id, change)
+	if err != nil {
+		log.Println("[API] Error cancelling reservation group:", err)
+		respondError(c, err)
+		return
+	}
+
+	offerFreedSlots(c.Request.Context(), cancelled)
+
+	c.JSON(http.StatusOK, cancelled)
+}
+
+func moveReservationGroup(c *gin.Context) {
+	id := c.Param("id")
+
+	var move models.ReservationGroupMove
+
+	if err := c.ShouldBindJSON(&move); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/reservation-groups/"+id+"/move", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	if respondValidationError(c, dbUtils.ValidateGroupMove(move)) {
+		return
+	}
+
+	group, err := dbUtils.LoadReservationGroupById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading reservation group:", err)
+		respondError(c, err)
+		return
+	}
+
+	if group == nil {
+		respondNotFound(c)
+		return
+	}
+
+	// the members were booked for one customer, so any of them carries its tier
+	var customerTier *string
+	if len(group.Reservations) > 0 {
+		customerTier = group.Reservations[0].CustomerTier
+	}
+
+	err = dbUtils.EnforceReservationSchedule(c.Request.Context(), pool, facilityLocation, dbUtils.ScheduleCheck{
+		StartTime:      move.StartTime,
+		EndTime:        move.EndTime,
+		Kind:           models.ReservationKindTunnel,
+		Override:       move.OverrideHours,
+		OverrideReason: move.OverrideReason,
+		OverrideBy:     move.OverrideBy,
+		BookingRules:   true,
+		CustomerTier:   customerTier,
+		Now:            time.Now(),
+	})
+
+	if respondValidationError(c, err) || respondRuleViolations(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error checking reservation hours:", err)
+		respondError(c, err)
+		return
+	}
+
+	moved, err := dbUtils.MoveReservationGroup(c.Request.Context(), pool, *group, move)
+	if respondGroupConflict(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error moving reservation group:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, moved)
+}
+
+func respondGroupConflict(c *gin.Context, err error) bool {
+	var conflictErr *dbUtils.GroupConflictError
+	if !errors.As(err, &conflictErr) {
+		return false
+	}
+
+	respondProblem(c, http.StatusConflict, "group_conflict", conflictErr.Error(), gin.H{
+		"conflicting_reservations": conflictErr.Conflicts,
+	})
+	return true
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// createReservationSeries expands the rule and books every occurrence. With
+// ?dry_run=true it only reports the conflicts. Unless skip_conflicts is set
+// any conflict fails the whole series with a 409.
+func createReservationSeries(c *gin.Context) {
+	var series models.ReservationSeries
+
+	if err := c.ShouldBindJSON(&series); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/reservation-series.", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	// series are booked by staff, so occurrences are confirmed unless asked otherwise
+	if series.Status == "" {
+		series.Status = models.ReservationStatusConfirmed
+	}
+
+	if series.BookingChannel == "" {
+		series.BookingChannel = models.BookingChannelPhone
+	}
+
+	// link the series to its customer as for a single booking, every occurrence gets it
+	customer := models.Reservation{
+		CustomerId:        series.CustomerId,
+		AthleteId:         series.AthleteId,
+		CustomerFirstName: series.CustomerFirstName,
+		CustomerLastName:  series.CustomerLastName,
+		CustomerPhone:     series.CustomerPhone,
+		CustomerEmail:     series.CustomerEmail,
+	}
+
+	err := dbUtils.ResolveReservationCustomer(c.Request.Context(), pool, &customer)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error resolving customer:", err)
+		respondError(c, err)
+		return
+	}
+
+	series.CustomerId, series.CustomerTier = customer.CustomerId, customer.CustomerTier
+	series.CustomerFirstName, series.CustomerLastName = customer.CustomerFirstName, customer.CustomerLastName
+	series.CustomerPhone, series.CustomerEmail = customer.CustomerPhone, customer.CustomerEmail
+
+	// lesson series need a lesson type their coach teaches, it also sets the default length
+	lessonType, err := dbUtils.CheckLessonAssignment(c.Request.Context(), pool, series.Kind, series.LessonTypeId, series.CoachId)
+	if respondValidationError(c, err) || respondRuleViolations(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error checking lesson type:", err)
+		respondError(c, err)
+		return
+	}
+
+	if lessonType != nil && series.Duration == 0 {
+		series.Duration = lessonType.DefaultDuration
+	}
+
+	occurrences, err := dbUtils.PlanReservationSeries(c.Request.Context(), pool, facilityLocation, series)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error planning reservation series:", err)
+		respondError(c, err)
+		return
+	}
+
+	if c.Query("dry_run") == "true" {
+		c.JSON(http.StatusOK, models.ReservationSeriesResult{
+			Occurrences:  occurrences,
+			Reservations: make([]models.Reservation, 0),
+		})
+		return
+	}
+
+	conflicting := 0
+	for _, occurrence := range occurrences {
+		if len(occurrence.Conflicts) > 0 {
+			conflicting++
+		}
+	}
+
+	if conflicting > 0 && !series.SkipConflicts {
+		respondProblem(c, http.StatusConflict, "series_conflicts", "Some occurrences conflict with existing bookings or closures.", gin.H{
+			"occurrences": occurrences,
+		})
+		return
+	}
+
+	if conflicting == len(occurrences) {
+		validationErr := &dbUtils.ValidationError{}
+		validationErr.Add("rrule", "no occurrences can be booked")
+		respondValidationError(c, validationErr)
+		return
+	}
+
+	holdExpiresAt := holdConfig.ExpiresAt(series.BookingChannel, time.Now())
+
+	result, err := dbUtils.InsertReservationSeries(c.Request.Context(), pool, series, occurrences, holdExpiresAt)
+	if err != nil {
+		log.Println("[API] Error inserting reservation series:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/reservation-series/"+result.Series.Id.String())
+	c.JSON(http.StatusCreated, *result)
+}
+
+func getReservationSeriesById(c *gin.Context) {
+	id := c.Param("id")
+
+	series, err := dbUtils.LoadReservationSeriesById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading reservation series:", err)
+		respondError(c, err)
+		return
+	}
+
+	if series == nil {
+		respondNotFound(c)
+		return
+	}
+
+	reservations, err := dbUtils.LoadSeriesReservations(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading series reservations:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": *series, "reservations": reservations})
+}
+
+func updateSeriesReservation(c *gin.Context) {
+	scope, anchor, ok := loadSeriesAnchor(c)
+	if !ok {
+		return
+	}
+
+	var updates models.SeriesUpdates
+
+	if err := c.ShouldBindJSON(&updates); err != nil {
+		log.Println("[API] Error binding JSON on PUT method at "+c.Request.URL.Path, err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	// a lesson series' new coach has to teach its lesson type
+	if anchor.Kind == models.ReservationKindLesson && updates.CoachId != nil {
+		_, err := dbUtils.CheckLessonAssignment(c.Request.Context(), pool, anchor.Kind, anchor.LessonTypeId, updates.CoachId)
+		if respondValidationError(c, err) || respondRuleViolations(c, err) {
+			return
+		}
+
+		if err != nil {
+			log.Println("[API] Error checking lesson type:", err)
+			respondError(c, err)
+			return
+		}
+	}
+
+	reservations, err := dbUtils.UpdateSeriesReservations(c.Request.Context(), pool, facilityLocation, *anchor, scope, updates)
+	if respondValidationError(c, err) || respondRuleViolations(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating series reservations:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservations)
+}
+
+func cancelSeriesReservation(c *gin.Context) {
+	scope, anchor, ok := loadSeriesAnchor(c)
+	if !ok {
+		return
+	}
+
+	var change models.ReservationStatusChangeRequest
+
+	if err := c.ShouldBindJSON(&change); err != nil && !errors.Is(err, io.EOF) {
+		log.Println("[API] Error binding JSON on POST method at "+c.Request.URL.Path, err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	// a single occurrence follows the normal transition rules
+	if scope == models.SeriesScopeThis {
+		reservation, err := dbUtils.TransitionReservationStatus(c.Request.Context(), pool, anchor.Id.String(), models.ReservationStatusCancelled, change)
+		if respondInvalidTransition(c, err) {
+			return
+		}
+
+		if err != nil || reservation == nil {
+			log.Println("[API] Error cancelling series reservation:", err)
+			respondError(c, err)
+			return
+		}
+
+		offerFreedSlots(c.Request.Context(), []models.Reservation{*reservation})
+
+		c.JSON(http.StatusOK, []models.Reservation{*reservation})
+		return
+	}
+
+	reservations, err := dbUtils.CancelSeriesReservations(c.Request.Context(), pool, *anchor, scope, change)
+	if err != nil {
+		log.Println("[API] Error cancelling series reservations:", err)
+		respondError(c, err)
+		return
+	}
+
+	offerFreedSlots(c.Request.Context(), reservations)
+
+	c.JSON(http.StatusOK, reservations)
+}
+
+// loadSeriesAnchor reads ?scope= (default this) and the occurrence the
+// request is about, answering 400/404 itself when either is wrong.
+func loadSeriesAnchor(c *gin.Context) (models.SeriesScope, *models.Reservation, bool) {
+	seriesId := c.Param("id")
+	reservationId := c.Param("reservationId")
+
+	scope := models.SeriesScope(c.DefaultQuery("scope", string(models.SeriesScopeThis)))
+	if scope != models.SeriesScopeThis && scope != models.SeriesScopeFollowing && scope != models.SeriesScopeAll {
+		validationErr := &dbUtils.ValidationError{}
+		validationErr.Add("scope", "must be one of this, following, all")
+		respondValidationError(c, validationErr)
+		return "", nil, false
+	}
+
+	anchor, err := dbUtils.LoadReservationById(c.Request.Context(), pool, reservationId)
+	if err != nil {
+		log.Println("[API] Error loading reservation:", err)
+		respondError(c, err)
+		return "", nil, false
+	}
+
+	var seriesUUID pgtype.UUID
+	if anchor == nil || anchor.SeriesId == nil || seriesUUID.Scan(seriesId) != nil || *anchor.SeriesId != seriesUUID {
+		log.Println("[API] Could not find reservation", reservationId, "in series", seriesId)
+		respondNotFound(c)
+		return "", nil, false
+	}
+
+	return scope, anchor, true
+}
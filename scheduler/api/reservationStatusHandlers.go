@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+// transitionReservation builds the handler for one of the status endpoints,
+// e.g. POST /api/reservations/:id/confirm. The body is optional.
+func transitionReservation(to models.ReservationStatus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		var change models.ReservationStatusChangeRequest
+
+		if err := c.ShouldBindJSON(&change); err != nil && !errors.Is(err, io.EOF) {
+			log.Println("[API] Error binding JSON on POST method at "+c.Request.URL.Path, err)
+			respondInvalidRequest(c, err)
+			return
+		}
+
+		existing, err := dbUtils.LoadReservationById(c.Request.Context(), pool, id)
+		if err != nil {
+			log.Println("[API] Error loading reservation:", err)
+			respondError(c, err)
+			return
+		}
+
+		if existing == nil {
+			log.Println("[API] Cannot change status because reservation does not exist with id:", id)
+			respondNotFound(c)
+			return
+		}
+
+		if respondSessionBlock(c, *existing) {
+			return
+		}
+
+		reservation, err := dbUtils.TransitionReservationStatus(c.Request.Context(), pool, id, to, change)
+		if respondInvalidTransition(c, err) {
+			return
+		}
+
+		if err != nil {
+			log.Println("[API] Error changing reservation status:", err)
+			respondError(c, err)
+			return
+		}
+
+		if reservation == nil {
+			log.Println("[API] Cannot change status because reservation does not exist with id:", id)
+			respondNotFound(c)
+			return
+		}
+
+		if to == models.ReservationStatusCancelled {
+			offerFreedSlots(c.Request.Context(), []models.Reservation{*reservation})
+		}
+
+		c.JSON(http.StatusOK, *reservation)
+	}
+}
+
+func getReservationStatusHistory(c *gin.Context) {
+	id := c.Param("id")
+
+	changes, err := dbUtils.LoadReservationStatusChanges(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading reservation status history:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+func respondInvalidTransition(c *gin.Context, err error) bool {
+	var expiredErr *dbUtils.HoldExpiredError
+	if errors.As(err, &expiredErr) {
+		respondProblem(c, http.StatusConflict, "hold_expired", "The hold expired before it was confirmed.", gin.H{
+			"hold_expires_at": expiredErr.ExpiredAt,
+		})
+		return true
+	}
+
+	var transitionErr *dbUtils.InvalidTransitionError
+	if !errors.As(err, &transitionErr) {
+		return false
+	}
+
+	respondProblem(c, http.StatusConflict, "invalid_transition", transitionErr.Error(), gin.H{
+		"current_status": transitionErr.From,
+	})
+	return true
+}
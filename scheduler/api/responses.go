@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+)
+
+const problemContentType = "application/problem+json"
+
+// respondProblem answers with an RFC 7807 problem details body. code is the
+// machine-readable error clients switch on, extra members (fields,
+// violations, conflicts, ...) are added next to the standard ones.
+func respondProblem(c *gin.Context, status int, code, detail string, extra gin.H) {
+	body := gin.H{
+		"type":     "about:blank",
+		"title":    http.StatusText(status),
+		"status":   status,
+		"code":     code,
+		"instance": c.Request.URL.Path,
+	}
+
+	if detail != "" {
+		body["detail"] = detail
+	}
+
+	for key, value := range extra {
+		body[key] = value
+	}
+
+	// set first so c.JSON keeps it instead of application/json
+	c.Header("Content-Type", problemContentType)
+	c.JSON(status, body)
+}
+
+// respondInvalidRequest answers with a 400 for a body that couldn't be bound.
+func respondInvalidRequest(c *gin.Context, err error) {
+	respondProblem(c, http.StatusBadRequest, "invalid_request", err.Error(), nil)
+}
+
+func respondNotFound(c *gin.Context) {
+	respondProblem(c, http.StatusNotFound, "not_found", "", nil)
+}
+
+// respondError answers for an error nothing more specific handled: a 4xx
+// when postgres rejected the request's data, otherwise a 500.
+func respondError(c *gin.Context, err error) {
+	if respondDBError(c, err) {
+		return
+	}
+
+	respondProblem(c, http.StatusInternalServerError, "internal_error", "", nil)
+}
+
+var dbErrorStatus = map[dbUtils.DBErrorKind]int{
+	dbUtils.DBErrorInvalidInput:     http.StatusBadRequest,
+	dbUtils.DBErrorMissingReference: http.StatusUnprocessableEntity,
+	dbUtils.DBErrorStillReferenced:  http.StatusConflict,
+	dbUtils.DBErrorCheckViolation:   http.StatusUnprocessableEntity,
+	dbUtils.DBErrorOverlap:          http.StatusConflict,
+}
+
+// respondDBError answers when err is a postgres failure the client caused
+// and reports whether it did.
+func respondDBError(c *gin.Context, err error) bool {
+	var dbErr *dbUtils.DBError
+	if !errors.As(dbUtils.TranslateDBError(err), &dbErr) {
+		return false
+	}
+
+	field := dbErr.Field
+
+	// a malformed path param (usually an :id) is named after the param
+	if field == "" && dbErr.Kind == dbUtils.DBErrorInvalidInput {
+		for _, param := range c.Params {
+			if param.Value == dbErr.Value {
+				field = param.Key
+				break
+			}
+		}
+	}
+
+	extra := gin.H{}
+	if field != "" {
+		extra["field"] = field
+	}
+
+	if dbErr.Constraint != "" {
+		extra["constraint"] = dbErr.Constraint
+	}
+
+	respondProblem(c, dbErrorStatus[dbErr.Kind], string(dbErr.Kind), dbErr.Message, extra)
+	return true
+}
+
+// respondValidationError answers with a field-level 400 when err is a
+// validation failure and reports whether it did.
+func respondValidationError(c *gin.Context, err error) bool {
+	var validationErr *dbUtils.ValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+
+	respondProblem(c, http.StatusBadRequest, "validation_failed", validationErr.Error(), gin.H{
+		"fields": validationErr.Fields,
+	})
+	return true
+}
+
+// respondRuleViolations answers with a 422 listing every broken scheduling
+// rule when err is a rule violation and reports whether it did.
+func respondRuleViolations(c *gin.Context, err error) bool {
+	var violationErr *dbUtils.RuleViolationError
+	if !errors.As(err, &violationErr) {
+		return false
+	}
+
+	respondProblem(c, http.StatusUnprocessableEntity, "rule_violation", violationErr.Error(), gin.H{
+		"violations": violationErr.Violations,
+	})
+	return true
+}
+
+// respondNoTunnelAvailable answers with a 409 when an "any tunnel" request
+// found every tunnel booked and reports whether it did.
+func respondNoTunnelAvailable(c *gin.Context, err error) bool {
+	var noTunnelErr *dbUtils.NoTunnelAvailableError
+	if !errors.As(err, &noTunnelErr) {
+		return false
+	}
+
+	respondProblem(c, http.StatusConflict, "no_tunnel_available", "Every tunnel is already booked for that time.", gin.H{
+		"start_time": noTunnelErr.StartTime,
+		"end_time":   noTunnelErr.EndTime,
+	})
+	return true
+}
+
+// respondNoCoachAvailable answers with a 409 listing why each coach was
+// passed over when a lesson without a coach_id found nobody free and reports
+// whether it did.
+func respondNoCoachAvailable(c *gin.Context, err error) bool {
+	var noCoachErr *dbUtils.NoCoachAvailableError
+	if !errors.As(err, &noCoachErr) {
+		return false
+	}
+
+	respondProblem(c, http.StatusConflict, "no_coach_available", "No coach teaching "+noCoachErr.Specialty+" is free for that time.", gin.H{
+		"specialty":  noCoachErr.Specialty,
+		"candidates": noCoachErr.Candidates,
+	})
+	return true
+}
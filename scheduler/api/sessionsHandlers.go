@@ -0,0 +1,327 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func getSessions(c *gin.Context) {
+	validationErr := &dbUtils.ValidationError{}
+	fromTime := parseTimestampQuery(c, validationErr, "from")
+	toTime := parseTimestampQuery(c, validationErr, "to")
+
+	if respondValidationError(c, validationErr.Err()) {
+		return
+	}
+
+	sessions, err := dbUtils.LoadSessionData(c.Request.Context(), pool, fromTime, toTime)
+	if err != nil {
+		log.Println("[API] Error loading sessions:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func getSessionById(c *gin.Context) {
+	id := c.Param("id")
+
+	session, err := dbUtils.LoadSessionById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading session:", err)
+		respondError(c, err)
+		return
+	}
+
+	if session == nil {
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *session)
+}
+
+func createSession(c *gin.Context) {
+	var session models.Session
+
+	if err := c.ShouldBindJSON(&session); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/sessions.", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	err := dbUtils.EnforceReservationSchedule(c.Request.Context(), pool, facilityLocation, dbUtils.ScheduleCheck{
+		StartTime:      session.StartTime,
+		EndTime:        session.EndTime,
+		Kind:           models.ReservationKindSession,
+		CoachId:        session.CoachId,
+		Override:       session.OverrideHours,
+		OverrideReason: session.OverrideReason,
+		OverrideBy:     session.OverrideBy,
+		BookingRules:   true,
+		Now:            time.Now(),
+	})
+
+	if respondValidationError(c, err) || respondRuleViolations(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error checking reservation hours:", err)
+		respondError(c, err)
+		return
+	}
+
+	result, err := dbUtils.InsertSession(c.Request.Context(), pool, session)
+	if respondValidationError(c, err) || respondRuleViolations(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error inserting session:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/sessions/"+result.Session.Id.String())
+	c.JSON(http.StatusCreated, *result)
+}
+
+func cancelSession(c *gin.Context) {
+	id := c.Param("id")
+
+	var change models.ReservationStatusChangeRequest
+
+	if err := c.ShouldBindJSON(&change); err != nil && !errors.Is(err, io.EOF) {
+		log.Println("[API] Error binding JSON on POST method at /api/sessions/"+id+"/cancel", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	session, err := dbUtils.LoadSessionById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading session:", err)
+		respondError(c, err)
+		return
+	}
+
+	if session == nil {
+		respondNotFound(c)
+		return
+	}
+
+	cancelled, err := dbUtils.CancelSession(c.Request.Context(), pool, id, change)
+	if err != nil {
+		log.Println("[API] Error cancelling session:", err)
+		respondError(c, err)
+		return
+	}
+
+	offerFreedSlots(c.Request.Context(), cancelled)
+
+	c.JSON(http.StatusOK, cancelled)
+}
+
+// getSessionRoster lists who is enrolled, ?include_withdrawn=true adds the
+// participants who dropped out and ?format=csv downloads it for the front desk.
+func getSessionRoster(c *gin.Context) {
+	id := c.Param("id")
+	validationErr := &dbUtils.ValidationError{}
+
+	includeWithdrawn := false
+	if withdrawnStr := c.Query("include_withdrawn"); withdrawnStr != "" {
+		parsed, err := strconv.ParseBool(withdrawnStr)
+		if err != nil {
+			validationErr.Add("include_withdrawn", "must be true or false")
+		}
+
+		includeWithdrawn = parsed
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		validationErr.Add("format", "must be json or csv")
+	}
+
+	if respondValidationError(c, validationErr.Err()) {
+		return
+	}
+
+	session, err := dbUtils.LoadSessionById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading session:", err)
+		respondError(c, err)
+		return
+	}
+
+	if session == nil {
+		respondNotFound(c)
+		return
+	}
+
+	roster, err := dbUtils.LoadSessionRoster(c.Request.Context(), pool, id, includeWithdrawn)
+	if err != nil {
+		log.Println("[API] Error loading session roster:", err)
+		respondError(c, err)
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, roster)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+rosterFileName(session.Session)+`"`)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	if err := writeRosterCSV(c.Writer, roster); err != nil {
+		log.Println("[API] Error writing session roster:", err)
+	}
+}
+
+func enrollInSession(c *gin.Context) {
+	id := c.Param("id")
+
+	var enrollment models.SessionEnrollment
+
+	if err := c.ShouldBindJSON(&enrollment); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/sessions/"+id+"/enrollments", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	result, err := dbUtils.EnrollInSession(c.Request.Context(), pool, id, enrollment)
+	if respondValidationError(c, err) || respondRuleViolations(c, err) || respondSessionFull(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error enrolling in session:", err)
+		respondError(c, err)
+		return
+	}
+
+	if result == nil {
+		respondNotFound(c)
+		return
+	}
+
+	c.Header("Location", "/api/sessions/"+id+"/enrollments/"+result.Id.String())
+	c.JSON(http.StatusCreated, *result)
+}
+
+func withdrawFromSession(c *gin.Context) {
+	id := c.Param("id")
+	enrollmentId := c.Param("enrollmentId")
+
+	rowsAffected, err := dbUtils.WithdrawFromSession(c.Request.Context(), pool, id, enrollmentId)
+	if err != nil {
+		log.Println("[API] Error withdrawing from session:", err)
+		respondError(c, err)
+		return
+	}
+
+	if rowsAffected == 0 {
+		log.Println("[API] No active enrollment with id:", enrollmentId)
+		respondNotFound(c)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondSessionFull(c *gin.Context, err error) bool {
+	var fullErr *dbUtils.SessionFullError
+	if !errors.As(err, &fullErr) {
+		return false
+	}
+
+	respondProblem(c, http.StatusConflict, "session_full", fullErr.Error(), gin.H{
+		"max_participants": fullErr.Session.MaxParticipants,
+		"enrolled_count":   fullErr.Session.EnrolledCount,
+	})
+	return true
+}
+
+func writeRosterCSV(w io.Writer, roster []models.SessionEnrollment) error {
+	out := csv.NewWriter(w)
+
+	header := []string{"last_name", "first_name", "phone", "email", "status", "overbooked", "enrolled_at", "notes"}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+
+	for _, enrollment := range roster {
+		email, notes := "", ""
+		if enrollment.ParticipantEmail != nil {
+			email = *enrollment.ParticipantEmail
+		}
+
+		if enrollment.Notes != nil {
+			notes = *enrollment.Notes
+		}
+
+		record := []string{
+			enrollment.ParticipantLastName,
+			enrollment.ParticipantFirstName,
+			enrollment.ParticipantPhone,
+			email,
+			string(enrollment.Status),
+			strconv.FormatBool(enrollment.Overbooked),
+			enrollment.EnrolledAt.Time.In(facilityLocation).Format(time.RFC3339),
+			notes,
+		}
+
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// rosterFileName is e.g. "10u-pitching-clinic-2025-09-05-roster.csv".
+func rosterFileName(session models.Session) string {
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '-'
+		}
+	}, strings.ToLower(session.Name))
+
+	slug = strings.Trim(slug, "-")
+	for strings.Contains(slug, "--") {
+		slug = strings.ReplaceAll(slug, "--", "-")
+	}
+
+	return slug + "-" + session.StartTime.Time.In(facilityLocation).Format("2006-01-02") + "-roster.csv"
+}
+
+// respondSessionBlock refuses to change a session's tunnel block through the
+// reservation endpoints, it would drift from its session. Blocks only change
+// through /api/sessions and /api/programs.
+func respondSessionBlock(c *gin.Context, reservation models.Reservation) bool {
+	if reservation.SessionId == nil {
+		return false
+	}
+
+	respondProblem(c, http.StatusConflict, "session_block", "The reservation is a session block, change it through its session.", gin.H{
+		"session_id": reservation.SessionId,
+	})
+	return true
+}
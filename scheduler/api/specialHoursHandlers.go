@@ -0,0 +1,168 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func getSpecialHours(c *gin.Context) {
+	validationErr := &dbUtils.ValidationError{}
+	fromDate := parseDateQuery(c, validationErr, "from")
+	toDate := parseDateQuery(c, validationErr, "to")
+
+	if fromDate.Valid && toDate.Valid && fromDate.Time.After(toDate.Time) {
+		validationErr.Add("to", "must not be before from")
+	}
+
+	if respondValidationError(c, validationErr.Err()) {
+		return
+	}
+
+	specialHours, err := dbUtils.LoadSpecialHoursData(c.Request.Context(), pool, fromDate, toDate)
+	if err != nil {
+		log.Println("[API] Error loading special hours:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, specialHours)
+}
+
+func getSpecialHoursById(c *gin.Context) {
+	id := c.Param("id")
+
+	specialHours, err := dbUtils.LoadSpecialHoursById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading special hours:", err)
+		respondError(c, err)
+		return
+	}
+
+	if specialHours == nil {
+		log.Println("[API] Could not find special hours with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *specialHours)
+}
+
+func createSpecialHours(c *gin.Context) {
+	var input models.SpecialHoursUpdates
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/special-hours.", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	result, err := dbUtils.InsertSpecialHoursData(c.Request.Context(), pool, input)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error inserting special hours:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/special-hours/"+result.Id.String())
+	c.JSON(http.StatusCreated, *result)
+}
+
+func updateSpecialHoursById(c *gin.Context) {
+	id := c.Param("id")
+
+	var specialHoursUpdates models.SpecialHoursUpdates
+
+	if err := c.ShouldBindJSON(&specialHoursUpdates); err != nil {
+		log.Println("[API] Error binding JSON on PUT method at /api/special-hours/"+id, err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	specialHours, err := dbUtils.UpdateSpecialHoursData(c.Request.Context(), pool, id, specialHoursUpdates)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error updating special hours:", err)
+		respondError(c, err)
+		return
+	}
+
+	if specialHours == nil {
+		log.Println("[API] Cannot update special hours because they do not exist with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *specialHours)
+}
+
+func deleteSpecialHoursById(c *gin.Context) {
+	id := c.Param("id")
+
+	rowsAffected, err := dbUtils.DeleteSpecialHoursData(c.Request.Context(), pool, id)
+
+	if err != nil {
+		log.Println("[API] Error deleting special hours:", err)
+		respondError(c, err)
+		return
+	}
+
+	// didn't delete anything
+	if rowsAffected < 1 {
+		log.Println("[API] Could not find special hours to delete with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	log.Println("[API] Successfully deleted special hours with id:", id)
+	c.Status(http.StatusNoContent)
+}
+
+func getEffectiveHours(c *gin.Context) {
+	validationErr := &dbUtils.ValidationError{}
+	onDate := parseDateQuery(c, validationErr, "date")
+
+	if !onDate.Valid {
+		validationErr.Add("date", "is required")
+	}
+
+	if respondValidationError(c, validationErr.Err()) {
+		return
+	}
+
+	effectiveHours, err := dbUtils.ResolveEffectiveHours(c.Request.Context(), pool, onDate)
+	if err != nil {
+		log.Println("[API] Error resolving effective hours:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, *effectiveHours)
+}
+
+// parseDateQuery reads an optional YYYY-MM-DD query param, the date is left
+// invalid when the param is missing.
+func parseDateQuery(c *gin.Context, validationErr *dbUtils.ValidationError, key string) pgtype.Date {
+	value := c.Query(key)
+	if value == "" {
+		return pgtype.Date{}
+	}
+
+	date, err := models.ParseDate(value)
+	if err != nil {
+		validationErr.Add(key, "must be a date formatted as YYYY-MM-DD")
+	}
+
+	return date
+}
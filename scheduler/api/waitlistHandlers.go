@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	dbUtils "github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/db-utils"
+	"github.com/rhit-lopezmo/the-diamond-scheduling-web-app/api/models"
+)
+
+func getWaitlist(c *gin.Context) {
+	validationErr := &dbUtils.ValidationError{}
+	fromTime := parseTimestampQuery(c, validationErr, "from")
+	toTime := parseTimestampQuery(c, validationErr, "to")
+
+	status := models.WaitlistStatus(c.Query("status"))
+	switch status {
+	case "",
+		models.WaitlistStatusWaiting,
+		models.WaitlistStatusOffered,
+		models.WaitlistStatusFulfilled,
+		models.WaitlistStatusLapsed,
+		models.WaitlistStatusLeft:
+	default:
+		validationErr.Add("status", "must be one of waiting, offered, fulfilled, lapsed, left")
+	}
+
+	if respondValidationError(c, validationErr.Err()) {
+		return
+	}
+
+	entries, err := dbUtils.LoadWaitlistData(c.Request.Context(), pool, status, fromTime, toTime)
+	if err != nil {
+		log.Println("[API] Error loading waitlist:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+func getWaitlistEntryById(c *gin.Context) {
+	id := c.Param("id")
+
+	entry, err := dbUtils.LoadWaitlistEntryById(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error loading waitlist entry:", err)
+		respondError(c, err)
+		return
+	}
+
+	if entry == nil {
+		respondNotFound(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, *entry)
+}
+
+func joinWaitlist(c *gin.Context) {
+	var entry models.WaitlistEntry
+
+	if err := c.ShouldBindJSON(&entry); err != nil {
+		log.Println("[API] Error binding JSON on POST method at /api/waitlist.", err)
+		respondInvalidRequest(c, err)
+		return
+	}
+
+	// a lesson entry needs a lesson type its coach teaches
+	_, err := dbUtils.CheckLessonAssignment(c.Request.Context(), pool, entry.Kind, entry.LessonTypeId, entry.CoachId)
+	if respondValidationError(c, err) || respondRuleViolations(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error checking lesson type:", err)
+		respondError(c, err)
+		return
+	}
+
+	result, err := dbUtils.InsertWaitlistEntryData(c.Request.Context(), pool, entry)
+	if respondValidationError(c, err) {
+		return
+	}
+
+	if err != nil {
+		log.Println("[API] Error joining waitlist:", err)
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/waitlist/"+result.Id.String())
+	c.JSON(http.StatusCreated, *result)
+}
+
+func leaveWaitlist(c *gin.Context) {
+	id := c.Param("id")
+
+	rowsAffected, err := dbUtils.LeaveWaitlist(c.Request.Context(), pool, id)
+	if err != nil {
+		log.Println("[API] Error leaving waitlist:", err)
+		respondError(c, err)
+		return
+	}
+
+	if rowsAffected == 0 {
+		log.Println("[API] No active waitlist entry with id:", id)
+		respondNotFound(c)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// offerFreedSlots hands the slots of just-cancelled reservations to the
+// waitlist. Failures are only logged, the cancellation already happened.
+func offerFreedSlots(ctx context.Context, freed []models.Reservation) {
+	for _, reservation := range freed {
+		expiresAt := pgtype.Timestamptz{Time: time.Now().Add(holdConfig.OfferTTL), Valid: true}
+
+		offer, err := dbUtils.OfferFreedSlot(ctx, pool, facilityLocation, reservation, expiresAt)
+		if err != nil {
+			log.Println("[API] Error offering freed slot to the waitlist:", err)
+			continue
+		}
+
+		if offer != nil {
+			log.Println("[API] Offered freed slot to waitlist entry:", offer.Entry.Id.String(),
+				"hold:", offer.Reservation.Id.String())
+		}
+	}
+}
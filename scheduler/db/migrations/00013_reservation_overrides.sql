@@ -0,0 +1,7 @@
+-- +goose Up
+-- Set when staff book outside of business/special hours or over a blackout.
+ALTER TABLE reservations
+  ADD COLUMN override_reason text;
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
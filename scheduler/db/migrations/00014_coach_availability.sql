@@ -0,0 +1,32 @@
+-- +goose Up
+-- Weekly working windows per coach, in local wall times like business_hours.
+CREATE TABLE coach_availability (
+  id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  coach_id       UUID NOT NULL REFERENCES coaches(id) ON DELETE CASCADE,
+  dow            INT  NOT NULL CHECK (dow BETWEEN 0 AND 6), -- 0=Sun … 6=Sat
+  start_time     TIME NOT NULL,
+  end_time       TIME NOT NULL,
+  effective_from DATE NOT NULL DEFAULT CURRENT_DATE,
+  effective_to   DATE,                                      -- inclusive, NULL => open ended
+  created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CHECK (start_time < end_time),
+  CHECK (effective_to IS NULL OR effective_from <= effective_to)
+);
+
+CREATE INDEX idx_coach_availability_coach_dow ON coach_availability (coach_id, dow);
+
+CREATE TABLE coach_time_off (
+  id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  coach_id   UUID NOT NULL REFERENCES coaches(id) ON DELETE CASCADE,
+  starts_at  TIMESTAMPTZ NOT NULL, -- UTC
+  ends_at    TIMESTAMPTZ NOT NULL, -- UTC
+  reason     TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CHECK (starts_at < ends_at)
+);
+
+CREATE INDEX idx_coach_time_off_coach_start ON coach_time_off (coach_id, starts_at);
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Audit trail of every status transition (who moved it, when and why).
+CREATE TABLE reservation_status_changes (
+  id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  reservation_id UUID NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+  from_status    reservation_status NOT NULL,
+  to_status      reservation_status NOT NULL,
+  changed_by     TEXT,
+  reason         TEXT,
+  changed_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_reservation_status_changes_reservation ON reservation_status_changes (reservation_id, changed_at);
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'booking_channel') THEN
+        CREATE TYPE booking_channel AS ENUM ('web', 'phone');
+    END IF;
+END$$;
+-- +goose StatementEnd
+
+-- Held reservations expire so an unconfirmed hold cannot block a tunnel forever.
+ALTER TABLE reservations
+  ADD COLUMN booking_channel booking_channel NOT NULL DEFAULT 'web',
+  ADD COLUMN hold_expires_at timestamptz;
+
+-- existing holds get the longest (phone) TTL from when they were made
+UPDATE reservations
+SET hold_expires_at = created_at + interval '24 hours'
+WHERE status = 'held';
+
+CREATE INDEX idx_reservations_hold_expiry ON reservations (hold_expires_at) WHERE status = 'held';
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
@@ -0,0 +1,27 @@
+-- +goose Up
+-- A recurring booking, each occurrence is a regular reservation row pointing back here.
+CREATE TABLE reservation_series (
+  id                  uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  reservation_kind    reservation_kind NOT NULL,
+  tunnel_id           int REFERENCES tunnels(id) ON DELETE RESTRICT,
+  coach_id            uuid REFERENCES coaches(id) ON DELETE RESTRICT,
+  customer_first_name text NOT NULL,
+  customer_last_name  text NOT NULL,
+  customer_phone      text NOT NULL,
+  customer_email      text,
+  start_time          timestamptz NOT NULL, -- first occurrence, its wall-clock time repeats
+  duration_minutes    int NOT NULL CHECK (duration_minutes > 0),
+  rrule               text NOT NULL,
+  exdates             date[] NOT NULL DEFAULT '{}',
+  notes               text,
+  created_at          timestamptz NOT NULL DEFAULT now(),
+  updated_at          timestamptz NOT NULL DEFAULT now()
+);
+
+ALTER TABLE reservations
+  ADD COLUMN series_id uuid REFERENCES reservation_series(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_reservations_series ON reservations (series_id, start_time) WHERE series_id IS NOT NULL;
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'waitlist_status') THEN
+        CREATE TYPE waitlist_status AS ENUM ('waiting', 'offered', 'fulfilled', 'lapsed', 'left');
+    END IF;
+END$$;
+-- +goose StatementEnd
+
+-- Customers waiting for a slot inside [window_start, window_end) to free up.
+CREATE TABLE waitlist_entries (
+  id                     uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  reservation_kind       reservation_kind NOT NULL,
+  tunnel_id              int REFERENCES tunnels(id) ON DELETE CASCADE,  -- NULL => any tunnel
+  coach_id               uuid REFERENCES coaches(id) ON DELETE CASCADE,
+  customer_first_name    text NOT NULL,
+  customer_last_name     text NOT NULL,
+  customer_phone         text NOT NULL,
+  customer_email         text,
+  window_start           timestamptz NOT NULL,
+  window_end             timestamptz NOT NULL,
+  duration_minutes       int NOT NULL CHECK (duration_minutes > 0),
+  status                 waitlist_status NOT NULL DEFAULT 'waiting',
+  offered_reservation_id uuid REFERENCES reservations(id) ON DELETE SET NULL,
+  notes                  text,
+  created_at             timestamptz NOT NULL DEFAULT now(),
+  updated_at             timestamptz NOT NULL DEFAULT now(),
+
+  CHECK (window_end > window_start),
+  CHECK (reservation_kind <> 'lesson' OR coach_id IS NOT NULL)
+);
+
+CREATE INDEX idx_waitlist_entries_waiting ON waitlist_entries (window_start, window_end) WHERE status = 'waiting';
+CREATE INDEX idx_waitlist_entries_offer ON waitlist_entries (offered_reservation_id) WHERE offered_reservation_id IS NOT NULL;
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Reservations booked together (e.g. a team taking several tunnels) that are
+-- created, cancelled and moved as one.
+CREATE TABLE reservation_groups (
+  id                  uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  name                text NOT NULL,
+  customer_first_name text NOT NULL,
+  customer_last_name  text NOT NULL,
+  customer_phone      text NOT NULL,
+  customer_email      text,
+  notes               text,
+  created_at          timestamptz NOT NULL DEFAULT now(),
+  updated_at          timestamptz NOT NULL DEFAULT now()
+);
+
+ALTER TABLE reservations
+  ADD COLUMN group_id uuid REFERENCES reservation_groups(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_reservations_group ON reservations (group_id) WHERE group_id IS NOT NULL;
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
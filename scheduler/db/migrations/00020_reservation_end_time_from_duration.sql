@@ -0,0 +1,62 @@
+-- +goose Up
+-- end_time is derived from start_time + duration_minutes, repair rows where they disagree.
+
+-- a positive range is what the overlap constraints have been enforcing, so it wins
+UPDATE reservations
+SET duration_minutes = GREATEST(1, FLOOR(EXTRACT(EPOCH FROM (end_time - start_time)) / 60))::int,
+    updated_at = now()
+WHERE end_time > start_time
+  AND end_time <> start_time + make_interval(mins => duration_minutes);
+
+-- empty or backwards ranges never blocked anything, take the duration instead.
+-- After this every row ends at start_time + duration_minutes, a held or confirmed row
+-- whose longer range runs into another booking of its tunnel or coach would
+-- abort the overlap constraints, so it is cancelled and reported instead.
+CREATE TEMP TABLE end_time_repair_conflicts AS
+SELECT r.id, r.status, r.start_time, r.start_time + make_interval(mins => r.duration_minutes) AS end_time
+FROM reservations r
+WHERE r.status IN ('held', 'confirmed')
+  AND r.end_time <> r.start_time + make_interval(mins => r.duration_minutes)
+  AND EXISTS (
+    SELECT 1
+    FROM reservations o
+    WHERE o.id <> r.id
+      AND o.status IN ('held', 'confirmed')
+      AND (o.tunnel_id = r.tunnel_id OR o.coach_id = r.coach_id)
+      AND tstzrange(o.start_time, o.start_time + make_interval(mins => o.duration_minutes), '[)')
+       && tstzrange(r.start_time, r.start_time + make_interval(mins => r.duration_minutes), '[)')
+  );
+
+-- +goose StatementBegin
+DO $$
+DECLARE
+  conflict record;
+BEGIN
+  FOR conflict IN SELECT * FROM end_time_repair_conflicts ORDER BY start_time LOOP
+    RAISE NOTICE 'reservation % cancelled: % to % overlaps another booking', conflict.id, conflict.start_time, conflict.end_time;
+  END LOOP;
+END $$;
+-- +goose StatementEnd
+
+INSERT INTO reservation_status_changes (reservation_id, from_status, to_status, changed_by, reason)
+SELECT id, status, 'cancelled', 'migration 00020', 'end_time repaired from duration_minutes overlaps another booking'
+FROM end_time_repair_conflicts;
+
+UPDATE reservations
+SET status = 'cancelled',
+    updated_at = now()
+WHERE id IN (SELECT id FROM end_time_repair_conflicts);
+
+DROP TABLE end_time_repair_conflicts;
+
+UPDATE reservations
+SET end_time = start_time + make_interval(mins => duration_minutes),
+    updated_at = now()
+WHERE end_time <> start_time + make_interval(mins => duration_minutes);
+
+ALTER TABLE reservations
+  ADD CONSTRAINT reservations_end_time_matches_duration
+  CHECK (end_time = start_time + make_interval(mins => duration_minutes));
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
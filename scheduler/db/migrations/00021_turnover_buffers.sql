@@ -0,0 +1,96 @@
+-- +goose Up
+-- Turnover time a tunnel or coach needs after a booking before the next one can start.
+ALTER TABLE tunnels
+  ADD COLUMN turnover_buffer_minutes int NOT NULL DEFAULT 5 CHECK (turnover_buffer_minutes BETWEEN 0 AND 120);
+
+ALTER TABLE coaches
+  ADD COLUMN turnover_buffer_minutes int NOT NULL DEFAULT 10 CHECK (turnover_buffer_minutes BETWEEN 0 AND 120);
+
+-- Each reservation keeps when its tunnel and coach are free again, taken from
+-- the buffers at write time, so the overlap constraints can include them.
+ALTER TABLE reservations
+  ADD COLUMN tunnel_turnover_end timestamptz,
+  ADD COLUMN coach_turnover_end timestamptz;
+
+UPDATE reservations SET tunnel_turnover_end = end_time, coach_turnover_end = end_time;
+
+-- existing bookings get their buffer unless that would run into the next
+-- booking, those back-to-back pairs are left as they were
+UPDATE reservations r
+SET tunnel_turnover_end = r.end_time + make_interval(mins => t.turnover_buffer_minutes)
+FROM tunnels t
+WHERE t.id = r.tunnel_id
+  AND NOT EXISTS (
+    SELECT 1 FROM reservations o
+    WHERE o.id <> r.id
+      AND o.tunnel_id = r.tunnel_id
+      AND o.status IN ('held', 'confirmed')
+      AND r.status IN ('held', 'confirmed')
+      AND o.start_time >= r.end_time
+      AND o.start_time < r.end_time + make_interval(mins => t.turnover_buffer_minutes)
+  );
+
+UPDATE reservations r
+SET coach_turnover_end = r.end_time + make_interval(mins => c.turnover_buffer_minutes)
+FROM coaches c
+WHERE c.id = r.coach_id
+  AND NOT EXISTS (
+    SELECT 1 FROM reservations o
+    WHERE o.id <> r.id
+      AND o.coach_id = r.coach_id
+      AND o.status IN ('held', 'confirmed')
+      AND r.status IN ('held', 'confirmed')
+      AND o.start_time >= r.end_time
+      AND o.start_time < r.end_time + make_interval(mins => c.turnover_buffer_minutes)
+  );
+
+ALTER TABLE reservations
+  ALTER COLUMN tunnel_turnover_end SET NOT NULL,
+  ALTER COLUMN coach_turnover_end SET NOT NULL;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION reservations_set_turnover() RETURNS trigger AS $$
+BEGIN
+    NEW.tunnel_turnover_end := NEW.end_time + make_interval(mins => COALESCE(
+        (SELECT turnover_buffer_minutes FROM tunnels WHERE id = NEW.tunnel_id), 0));
+    NEW.coach_turnover_end := NEW.end_time + make_interval(mins => COALESCE(
+        (SELECT turnover_buffer_minutes FROM coaches WHERE id = NEW.coach_id), 0));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER reservations_turnover_on_insert
+  BEFORE INSERT ON reservations
+  FOR EACH ROW EXECUTE FUNCTION reservations_set_turnover();
+
+-- only when the booking moves, so editing notes on an old back-to-back pair still works
+CREATE TRIGGER reservations_turnover_on_move
+  BEFORE UPDATE ON reservations
+  FOR EACH ROW
+  WHEN (OLD.end_time IS DISTINCT FROM NEW.end_time
+     OR OLD.tunnel_id IS DISTINCT FROM NEW.tunnel_id
+     OR OLD.coach_id IS DISTINCT FROM NEW.coach_id)
+  EXECUTE FUNCTION reservations_set_turnover();
+
+ALTER TABLE reservations DROP CONSTRAINT tunnel_no_overlap;
+ALTER TABLE reservations DROP CONSTRAINT coach_no_overlap;
+
+ALTER TABLE reservations
+  ADD CONSTRAINT tunnel_no_overlap
+  EXCLUDE USING gist (
+    tunnel_id WITH =,
+    tstzrange(start_time, tunnel_turnover_end, '[)') WITH &&
+  )
+  WHERE (tunnel_id IS NOT NULL AND status IN ('held','confirmed'));
+
+ALTER TABLE reservations
+  ADD CONSTRAINT coach_no_overlap
+  EXCLUDE USING gist (
+    coach_id WITH =,
+    tstzrange(start_time, coach_turnover_end, '[)') WITH &&
+  )
+  WHERE (coach_id IS NOT NULL AND status IN ('held','confirmed'));
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
@@ -0,0 +1,26 @@
+-- +goose Up
+-- Booking rules per reservation kind, optionally narrowed to a customer tier.
+CREATE TABLE booking_rules (
+  id                        uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  reservation_kind          reservation_kind NOT NULL,
+  customer_tier             text,                       -- NULL => the kind's default rule
+  allowed_durations         int[] NOT NULL DEFAULT '{}', -- empty => any duration
+  start_granularity_minutes int NOT NULL DEFAULT 1 CHECK (start_granularity_minutes BETWEEN 1 AND 1440),
+  min_lead_minutes          int NOT NULL DEFAULT 0 CHECK (min_lead_minutes >= 0),
+  max_horizon_days          int CHECK (max_horizon_days > 0), -- NULL => no limit
+  created_at                timestamptz NOT NULL DEFAULT now(),
+  updated_at                timestamptz NOT NULL DEFAULT now(),
+
+  UNIQUE NULLS NOT DISTINCT (reservation_kind, customer_tier),
+  CHECK (0 < ALL (allowed_durations))
+);
+
+-- the tier a booking was made under, decides which rule applies to it
+ALTER TABLE reservations ADD COLUMN customer_tier text;
+
+INSERT INTO booking_rules (reservation_kind, allowed_durations, start_granularity_minutes, min_lead_minutes, max_horizon_days) VALUES
+  ('tunnel', '{30,60,90}', 30, 120, 30),
+  ('lesson', '{30,60}',    30, 1440, 60);
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
@@ -0,0 +1,24 @@
+-- +goose Up
+-- The kinds of lessons on offer and the coach specialty each one needs.
+CREATE TABLE lesson_types (
+  id                       uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  name                     text NOT NULL UNIQUE,
+  specialty                coach_specialty NOT NULL,
+  default_duration_minutes int NOT NULL CHECK (default_duration_minutes > 0),
+  price_cents              int NOT NULL CHECK (price_cents >= 0),
+  is_active                boolean NOT NULL DEFAULT TRUE, -- false => no longer bookable, kept for past lessons
+  created_at               timestamptz NOT NULL DEFAULT now(),
+  updated_at               timestamptz NOT NULL DEFAULT now()
+);
+
+-- lessons booked before the catalog existed keep a NULL lesson type, the API
+-- requires one for new lessons
+ALTER TABLE reservations
+  ADD COLUMN lesson_type_id uuid REFERENCES lesson_types(id) ON DELETE RESTRICT,
+  ADD CONSTRAINT reservations_lesson_type_only_lessons
+  CHECK (reservation_kind = 'lesson' OR lesson_type_id IS NULL);
+
+CREATE INDEX idx_reservations_lesson_type ON reservations (lesson_type_id) WHERE lesson_type_id IS NOT NULL;
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Tunnel blocks taken by a clinic or drop-in session. On its own because a
+-- new enum value can't be used in the transaction that adds it.
+ALTER TYPE reservation_kind ADD VALUE IF NOT EXISTS 'session';
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
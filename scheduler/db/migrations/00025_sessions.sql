@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'session_kind') THEN
+        CREATE TYPE session_kind AS ENUM ('clinic', 'drop_in');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'enrollment_status') THEN
+        CREATE TYPE enrollment_status AS ENUM ('enrolled', 'withdrawn');
+    END IF;
+END$$;
+-- +goose StatementEnd
+
+-- Capacity-based sessions (a pitching clinic, open hitting) run on one or
+-- more tunnels at once, each tunnel is blocked by a 'session' reservation.
+CREATE TABLE sessions (
+  id                uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  session_kind      session_kind NOT NULL,
+  name              text NOT NULL,
+  coach_id          uuid REFERENCES coaches(id) ON DELETE RESTRICT, -- NULL => unsupervised drop-in
+  start_time        timestamptz NOT NULL,
+  end_time          timestamptz NOT NULL,
+  max_participants  int NOT NULL CHECK (max_participants > 0),
+  price_cents       int NOT NULL CHECK (price_cents >= 0), -- per participant
+  notes             text,
+  cancelled_at      timestamptz,
+  created_at        timestamptz NOT NULL DEFAULT now(),
+  updated_at        timestamptz NOT NULL DEFAULT now(),
+
+  CHECK (end_time > start_time),
+  CONSTRAINT sessions_clinic_needs_coach CHECK (session_kind <> 'clinic' OR coach_id IS NOT NULL)
+);
+
+CREATE INDEX idx_sessions_start ON sessions (start_time);
+
+-- The roster, withdrawn participants are kept for the record.
+CREATE TABLE session_enrollments (
+  id                     uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  session_id             uuid NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+  participant_first_name text NOT NULL,
+  participant_last_name  text NOT NULL,
+  participant_phone      text NOT NULL,
+  participant_email      text,
+  status                 enrollment_status NOT NULL DEFAULT 'enrolled',
+  overbooked             boolean NOT NULL DEFAULT FALSE, -- staff enrolled them past max_participants
+  notes                  text,
+  enrolled_at            timestamptz NOT NULL DEFAULT now(),
+  withdrawn_at           timestamptz,
+  updated_at             timestamptz NOT NULL DEFAULT now()
+);
+
+-- a participant (phone + name, siblings share a phone) is enrolled at most once
+CREATE UNIQUE INDEX uq_session_enrollments_participant
+  ON session_enrollments (session_id, participant_phone, lower(participant_first_name), lower(participant_last_name))
+  WHERE status = 'enrolled';
+
+ALTER TABLE reservations
+  ADD COLUMN session_id uuid REFERENCES sessions(id) ON DELETE RESTRICT,
+  ADD CONSTRAINT reservations_session_blocks
+  CHECK ((reservation_kind = 'session') = (session_id IS NOT NULL)),
+  ADD CONSTRAINT reservations_session_needs_tunnel
+  CHECK (reservation_kind <> 'session' OR tunnel_id IS NOT NULL);
+
+CREATE INDEX idx_reservations_session ON reservations (session_id) WHERE session_id IS NOT NULL;
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
@@ -0,0 +1,59 @@
+-- +goose Up
+-- Camp days are sessions of a program, 'camp' isn't used below so it can be
+-- added in this transaction.
+ALTER TYPE session_kind ADD VALUE IF NOT EXISTS 'camp';
+
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'program_enrollment_status') THEN
+        CREATE TYPE program_enrollment_status AS ENUM ('enrolled', 'withdrawn', 'refunded');
+    END IF;
+END$$;
+-- +goose StatementEnd
+
+-- Multi-day camps and programs, each day is a session blocking the program's
+-- tunnels and coaches. Athletes enroll in the whole program.
+CREATE TABLE programs (
+  id              uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  name            text NOT NULL,
+  description     text,
+  price_cents     int NOT NULL CHECK (price_cents >= 0), -- per athlete for the whole program
+  max_enrollment  int NOT NULL CHECK (max_enrollment > 0),
+  min_age         int CHECK (min_age >= 0),              -- ages are taken on the first day
+  max_age         int CHECK (max_age >= 0),
+  cancelled_at    timestamptz,
+  cancel_reason   text,
+  created_at      timestamptz NOT NULL DEFAULT now(),
+  updated_at      timestamptz NOT NULL DEFAULT now(),
+
+  CONSTRAINT programs_age_range CHECK (min_age IS NULL OR max_age IS NULL OR max_age >= min_age)
+);
+
+ALTER TABLE sessions
+  ADD COLUMN program_id uuid REFERENCES programs(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_sessions_program ON sessions (program_id) WHERE program_id IS NOT NULL;
+
+CREATE TABLE program_enrollments (
+  id                 uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  program_id         uuid NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+  athlete_first_name text NOT NULL,
+  athlete_last_name  text NOT NULL,
+  athlete_birth_date date NOT NULL,
+  contact_phone      text NOT NULL,
+  contact_email      text,
+  status             program_enrollment_status NOT NULL DEFAULT 'enrolled',
+  refund_cents       int CHECK (refund_cents >= 0), -- set once refunded
+  notes              text,
+  enrolled_at        timestamptz NOT NULL DEFAULT now(),
+  withdrawn_at       timestamptz,
+  updated_at         timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX uq_program_enrollments_athlete
+  ON program_enrollments (program_id, lower(athlete_first_name), lower(athlete_last_name), athlete_birth_date)
+  WHERE status = 'enrolled';
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
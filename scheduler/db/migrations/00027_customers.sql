@@ -0,0 +1,160 @@
+-- +goose Up
+-- Phone numbers and emails are compared in this form so "(812) 555-0100",
+-- "812.555.0100" and "+1 812 555 0100" are the same customer.
+-- +goose StatementBegin
+CREATE FUNCTION normalize_phone(phone text) RETURNS text AS $$
+    SELECT NULLIF(CASE WHEN length(digits) = 11 AND left(digits, 1) = '1' THEN substr(digits, 2) ELSE digits END, '')
+    FROM (SELECT regexp_replace(phone, '\D', '', 'g') AS digits) d
+$$ LANGUAGE sql IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION normalize_email(email text) RETURNS text AS $$
+    SELECT NULLIF(lower(btrim(email)), '')
+$$ LANGUAGE sql IMMUTABLE;
+-- +goose StatementEnd
+
+-- The people who book, a family shares one customer. Reservations keep their
+-- customer_* columns as entered at booking time.
+CREATE TABLE customers (
+  id                uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  first_name        text NOT NULL,
+  last_name         text NOT NULL,
+  phone             text NOT NULL,
+  email             text,
+  phone_normalized  text GENERATED ALWAYS AS (normalize_phone(phone)) STORED,
+  email_normalized  text GENERATED ALWAYS AS (normalize_email(email)) STORED,
+  notes             text,
+  created_at        timestamptz NOT NULL DEFAULT now(),
+  updated_at        timestamptz NOT NULL DEFAULT now(),
+
+  CONSTRAINT customers_phone_has_digits CHECK (normalize_phone(phone) IS NOT NULL)
+);
+
+CREATE INDEX idx_customers_phone ON customers (phone_normalized);
+CREATE INDEX idx_customers_email ON customers (email_normalized) WHERE email_normalized IS NOT NULL;
+CREATE INDEX idx_customers_name ON customers (lower(last_name), lower(first_name));
+
+ALTER TABLE reservations
+  ADD COLUMN customer_id uuid REFERENCES customers(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_reservations_customer ON reservations (customer_id, start_time) WHERE customer_id IS NOT NULL;
+
+-- What backfill_customers() would do with the reservations not linked to a
+-- customer yet, one row per normalized phone number. Session blocks are
+-- skipped, their customer_* columns hold the session name. A group is only
+-- linked when it can't be two people: 'ambiguous' rows say why in reason and
+-- are left for the front desk to link by hand.
+CREATE VIEW customer_backfill_report AS
+WITH unlinked AS (
+    SELECT
+        r.id,
+        normalize_phone(r.customer_phone) AS phone_key,
+        normalize_email(r.customer_email) AS email_key,
+        btrim(r.customer_first_name || ' ' || r.customer_last_name) AS full_name
+    FROM reservations r
+    WHERE r.customer_id IS NULL AND r.session_id IS NULL
+),
+groups AS (
+    SELECT
+        u.phone_key,
+        COUNT(*)::int AS reservation_count,
+        COALESCE(array_agg(DISTINCT u.email_key) FILTER (WHERE u.email_key IS NOT NULL), '{}') AS emails,
+        array_agg(DISTINCT u.full_name) AS names
+    FROM unlinked u
+    GROUP BY u.phone_key
+)
+SELECT
+    g.phone_key,
+    g.reservation_count,
+    g.emails,
+    g.names,
+    m.customer_ids AS matching_customer_ids,
+    CASE
+        WHEN a.reason IS NOT NULL THEN 'ambiguous'
+        WHEN cardinality(m.customer_ids) = 1 THEN 'link'
+        ELSE 'create'
+    END AS action,
+    a.reason
+FROM groups g
+CROSS JOIN LATERAL (
+    SELECT
+        COALESCE(array_agg(c.id ORDER BY c.created_at), '{}') AS customer_ids,
+        COALESCE(bool_and(c.email_normalized IS NULL OR cardinality(g.emails) = 0 OR c.email_normalized = ANY (g.emails)), true) AS emails_agree
+    FROM customers c
+    WHERE c.phone_normalized = g.phone_key OR c.email_normalized = ANY (g.emails)
+) m
+CROSS JOIN LATERAL (
+    SELECT CASE
+        WHEN g.phone_key IS NULL THEN 'No usable phone number.'
+        WHEN cardinality(g.emails) > 1 THEN 'The phone number was booked with several emails.'
+        WHEN EXISTS (
+            SELECT 1 FROM unlinked o
+            WHERE o.email_key = ANY (g.emails) AND o.phone_key IS DISTINCT FROM g.phone_key
+        ) THEN 'The email was also booked with another phone number.'
+        WHEN cardinality(m.customer_ids) > 1 THEN 'Matches several customers.'
+        WHEN NOT m.emails_agree THEN 'Matches a customer with another email.'
+    END AS reason
+) a;
+
+-- Links every unambiguous group of customer_backfill_report to its customer,
+-- creating the customer from the group's latest reservation when there is
+-- none, and returns the groups it handled with the customer they went to.
+-- Safe to run again once ambiguous groups have been sorted out.
+-- +goose StatementBegin
+CREATE FUNCTION backfill_customers()
+RETURNS TABLE (
+    phone_key             text,
+    reservation_count     int,
+    emails                text[],
+    names                 text[],
+    matching_customer_ids uuid[],
+    action                text,
+    reason                text,
+    customer_id           uuid
+) AS $$
+#variable_conflict use_column
+DECLARE
+    g      record;
+    picked uuid;
+BEGIN
+    FOR g IN SELECT * FROM customer_backfill_report b WHERE b.action <> 'ambiguous' LOOP
+        IF g.action = 'link' THEN
+            picked := g.matching_customer_ids[1];
+        ELSE
+            INSERT INTO customers (first_name, last_name, phone, email)
+            SELECT r.customer_first_name, r.customer_last_name, r.customer_phone, COALESCE(r.customer_email, g.emails[1])
+            FROM reservations r
+            WHERE r.customer_id IS NULL
+                AND r.session_id IS NULL
+                AND normalize_phone(r.customer_phone) = g.phone_key
+            ORDER BY r.created_at DESC
+            LIMIT 1
+            RETURNING id INTO picked;
+        END IF;
+
+        UPDATE reservations r
+        SET customer_id = picked
+        WHERE r.customer_id IS NULL
+            AND r.session_id IS NULL
+            AND normalize_phone(r.customer_phone) = g.phone_key;
+
+        phone_key := g.phone_key;
+        reservation_count := g.reservation_count;
+        emails := g.emails;
+        names := g.names;
+        matching_customer_ids := g.matching_customer_ids;
+        action := g.action;
+        reason := g.reason;
+        customer_id := picked;
+        RETURN NEXT;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- Backfill what is unambiguous now, GET /api/customers/backfill lists the rest.
+SELECT COUNT(*) FROM backfill_customers();
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'sport') THEN
+        CREATE TYPE sport AS ENUM ('baseball', 'softball');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'handedness') THEN
+        CREATE TYPE handedness AS ENUM ('right', 'left', 'switch');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'field_position') THEN
+        CREATE TYPE field_position AS ENUM (
+            'pitcher', 'catcher', 'first_base', 'second_base', 'third_base', 'shortstop',
+            'left_field', 'center_field', 'right_field', 'infield', 'outfield', 'utility'
+        );
+    END IF;
+END$$;
+-- +goose StatementEnd
+
+-- The kids a customer (the paying guardian) books for.
+CREATE TABLE athletes (
+  id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  customer_id uuid NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+  first_name  text NOT NULL,
+  last_name   text NOT NULL,
+  birth_year  int NOT NULL CHECK (birth_year >= 1900),
+  sport       sport NOT NULL DEFAULT 'baseball',
+  bats        handedness,
+  throws      handedness CHECK (throws <> 'switch'),
+  position    field_position,
+  notes       text,
+  is_active   boolean NOT NULL DEFAULT TRUE, -- false => aged out or left, kept for past reservations
+  created_at  timestamptz NOT NULL DEFAULT now(),
+  updated_at  timestamptz NOT NULL DEFAULT now(),
+
+  CONSTRAINT athletes_id_customer UNIQUE (id, customer_id)
+);
+
+CREATE INDEX idx_athletes_customer ON athletes (customer_id);
+
+-- The athlete has to be one of the reservation's customer's athletes.
+ALTER TABLE reservations
+  ADD COLUMN athlete_id uuid,
+  ADD CONSTRAINT reservations_athlete_customer_fkey
+  FOREIGN KEY (athlete_id, customer_id) REFERENCES athletes (id, customer_id) ON DELETE RESTRICT,
+  ADD CONSTRAINT reservations_athlete_needs_customer
+  CHECK (athlete_id IS NULL OR customer_id IS NOT NULL);
+
+CREATE INDEX idx_reservations_athlete ON reservations (athlete_id) WHERE athlete_id IS NOT NULL;
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
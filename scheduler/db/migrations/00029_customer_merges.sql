@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm; -- similarity() and % for fuzzy name matches
+
+CREATE INDEX idx_customers_name_trgm ON customers USING gin (lower(first_name || ' ' || last_name) gin_trgm_ops);
+
+-- Moving an athlete to another customer takes their reservations along, a
+-- merge relies on this.
+ALTER TABLE reservations
+  DROP CONSTRAINT reservations_athlete_customer_fkey,
+  ADD CONSTRAINT reservations_athlete_customer_fkey
+  FOREIGN KEY (athlete_id, customer_id) REFERENCES athletes (id, customer_id) ON DELETE RESTRICT ON UPDATE CASCADE;
+
+-- Audit trail of duplicate customers merged into another, the merged
+-- customer is deleted so its row is kept here as it was.
+CREATE TABLE customer_merges (
+  id                    uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  surviving_customer_id uuid NOT NULL REFERENCES customers(id) ON DELETE RESTRICT,
+  merged_customer_id    uuid NOT NULL, -- no longer exists
+  merged_customer       jsonb NOT NULL,
+  reservations_moved    int NOT NULL CHECK (reservations_moved >= 0),
+  athletes_moved        int NOT NULL CHECK (athletes_moved >= 0),
+  merged_by             text,
+  reason                text,
+  merged_at             timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_customer_merges_surviving ON customer_merges (surviving_customer_id, merged_at);
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
@@ -0,0 +1,8 @@
+-- +goose Up
+-- The tier picks the booking rules of the customer's reservations, staff set
+-- it on the customer rather than the booking form sending it. Existing
+-- customers start without one, i.e. on the kinds' default rules.
+ALTER TABLE customers ADD COLUMN tier text; -- NULL => the kind's default rule
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Lesson series and lesson waitlist entries name their lesson type, so the
+-- lessons they book are checked against the coach like any other. Those from
+-- before the catalog keep a NULL lesson type, the API requires one for new ones.
+ALTER TABLE reservation_series
+  ADD COLUMN lesson_type_id uuid REFERENCES lesson_types(id) ON DELETE RESTRICT,
+  ADD CONSTRAINT reservation_series_lesson_type_only_lessons
+  CHECK (reservation_kind = 'lesson' OR lesson_type_id IS NULL);
+
+ALTER TABLE waitlist_entries
+  ADD COLUMN lesson_type_id uuid REFERENCES lesson_types(id) ON DELETE RESTRICT,
+  ADD CONSTRAINT waitlist_entries_lesson_type_only_lessons
+  CHECK (reservation_kind = 'lesson' OR lesson_type_id IS NULL);
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.
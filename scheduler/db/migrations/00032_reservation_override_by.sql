@@ -0,0 +1,8 @@
+-- +goose Up
+-- Who overrode the schedule checks, recorded next to override_reason. Older
+-- overrides keep a NULL, the API requires it for new ones.
+ALTER TABLE reservations
+  ADD COLUMN override_by text;
+
+-- +goose Down
+-- Forward-only policy: no down migration provided.